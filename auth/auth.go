@@ -0,0 +1,365 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/encryption/hash"
+)
+
+const (
+	GUARD_WEB        = "web"
+	GUARD_API        = "api"
+	PASSWORD_KEY     = "password"
+	REQUEST_AUTH_KEY = "auth"
+	AUTH_DEPENDENCY  = "auth"
+)
+
+const UnauthenticatedMessage = "Unauthenticated."
+
+var (
+	ErrGuardNotFound = errors.New("auth guard does not exist")
+)
+
+type Authenticatable interface {
+	AuthIdentifier() string
+	AuthPassword() string
+}
+
+type Credentials map[string]string
+
+type UserProvider interface {
+	RetrieveById(id string) (Authenticatable, error)
+	RetrieveByToken(token string) (Authenticatable, error)
+	RetrieveByCredentials(credentials Credentials) (Authenticatable, error)
+	ValidateCredentials(user Authenticatable, credentials Credentials) bool
+}
+
+type Guard interface {
+	Check() bool
+	Guest() bool
+	User() Authenticatable
+	Id() string
+	Validate(credentials Credentials) bool
+	Attempt(credentials Credentials) bool
+	Login(user Authenticatable)
+	Logout()
+}
+
+type Driver func(req *http.Request, provider UserProvider) Guard
+
+type guard struct {
+	driver   Driver
+	provider UserProvider
+}
+
+type Manager struct {
+	guards       map[string]*guard
+	defaultGuard string
+	redirect     string
+	home         string
+	mutex        sync.RWMutex
+}
+
+type Authenticator struct {
+	manager *Manager
+	request *http.Request
+	guards  map[string]Guard
+	current string
+}
+
+// Requests of servers without an attached manager use the default manager.
+var auth = NewManager()
+
+// Comment
+func NewManager() *Manager {
+	return &Manager{
+		guards:       map[string]*guard{},
+		defaultGuard: GUARD_WEB,
+		redirect:     "login",
+		home:         "/",
+	}
+}
+
+// Comment
+func DefaultManager() *Manager {
+	return auth
+}
+
+// Comment
+func Attach(server *http.HTTP) {
+	auth.Attach(server)
+	gate.Attach(server)
+}
+
+// Comment
+func Use(provider UserProvider) *Manager {
+	return auth.Use(provider)
+}
+
+// Comment
+func Register(name string, driver Driver, provider UserProvider) *Manager {
+	return auth.Register(name, driver, provider)
+}
+
+// Comment
+func Default(name string) *Manager {
+	return auth.Default(name)
+}
+
+// Comment
+func RedirectTo(path string) *Manager {
+	return auth.RedirectTo(path)
+}
+
+// Comment
+func Home(path string) *Manager {
+	return auth.Home(path)
+}
+
+// Comment
+func (ctx *Manager) Attach(server *http.HTTP) *Manager {
+	server.Set(AUTH_DEPENDENCY, ctx).ViewHelper("auth", func(req *http.Request) interface{} {
+		return func() *Authenticator { return Auth(req) }
+	})
+
+	return ctx
+}
+
+// Comment
+func (ctx *Manager) Use(provider UserProvider) *Manager {
+	return ctx.Register(GUARD_WEB, SessionGuard, provider).Register(GUARD_API, TokenGuard, provider)
+}
+
+// Comment
+func (ctx *Manager) Register(name string, driver Driver, provider UserProvider) *Manager {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.guards[name] = &guard{driver: driver, provider: provider}
+
+	return ctx
+}
+
+// Comment
+func (ctx *Manager) Default(name string) *Manager {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.defaultGuard = name
+
+	return ctx
+}
+
+// Comment
+func (ctx *Manager) RedirectTo(path string) *Manager {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.redirect = path
+
+	return ctx
+}
+
+// Comment
+func (ctx *Manager) Home(path string) *Manager {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.home = path
+
+	return ctx
+}
+
+// Comment
+func managerOf(req *http.Request) *Manager {
+	if req.Server != nil {
+		if manager, ok := req.Server.Get(AUTH_DEPENDENCY).(*Manager); ok {
+			return manager
+		}
+	}
+
+	return auth
+}
+
+// Comment
+func Auth(req *http.Request) *Authenticator {
+	if authenticator, ok := req.Get(REQUEST_AUTH_KEY).(*Authenticator); ok {
+		return authenticator
+	}
+
+	authenticator := &Authenticator{manager: managerOf(req), request: req, guards: map[string]Guard{}}
+
+	// The response depends on the user, so it must not be shared by the response cache.
+	req.Set(REQUEST_AUTH_KEY, authenticator).Private()
+
+	return authenticator
+}
+
+// Comment
+func (ctx *Authenticator) name(name ...string) string {
+	if len(name) > 0 && name[0] != "" {
+		return name[0]
+	}
+
+	if ctx.current != "" {
+		return ctx.current
+	}
+
+	return ctx.manager.defaultGuard
+}
+
+// Comment
+func (ctx *Authenticator) Has(name ...string) bool {
+	ctx.manager.mutex.RLock()
+	defer ctx.manager.mutex.RUnlock()
+
+	_, ok := ctx.manager.guards[ctx.name(name...)]
+
+	return ok
+}
+
+// Comment
+func (ctx *Authenticator) Guard(name ...string) Guard {
+	ctx.manager.mutex.RLock()
+	defer ctx.manager.mutex.RUnlock()
+
+	key := ctx.name(name...)
+
+	if guard, ok := ctx.guards[key]; ok {
+		return guard
+	}
+
+	config, ok := ctx.manager.guards[key]
+
+	// A missing guard is a configuration error, requests are treated as guests instead of panicking.
+	if !ok {
+		ctx.request.Logger().Error(ErrGuardNotFound.Error(), slog.String("guard", key))
+
+		ctx.guards[key] = guestGuard{}
+
+		return ctx.guards[key]
+	}
+
+	guard := config.driver(ctx.request, config.provider)
+
+	ctx.guards[key] = guard
+
+	return guard
+}
+
+// Comment
+func (ctx *Authenticator) ShouldUse(name string) *Authenticator {
+	ctx.current = name
+
+	return ctx
+}
+
+// Comment
+func (ctx *Authenticator) Check() bool {
+	return ctx.Guard().Check()
+}
+
+// Comment
+func (ctx *Authenticator) Guest() bool {
+	return ctx.Guard().Guest()
+}
+
+// Comment
+func (ctx *Authenticator) User() Authenticatable {
+	return ctx.Guard().User()
+}
+
+// Comment
+func (ctx *Authenticator) Id() string {
+	return ctx.Guard().Id()
+}
+
+// Comment
+func (ctx *Authenticator) Validate(credentials Credentials) bool {
+	return ctx.Guard().Validate(credentials)
+}
+
+// Comment
+func (ctx *Authenticator) Attempt(credentials Credentials) bool {
+	return ctx.Guard().Attempt(credentials)
+}
+
+// Comment
+func (ctx *Authenticator) Login(user Authenticatable) {
+	ctx.Guard().Login(user)
+}
+
+// Comment
+func (ctx *Authenticator) Logout() {
+	ctx.Guard().Logout()
+}
+
+// Comment
+func ValidatePassword(user Authenticatable, credentials Credentials) bool {
+	if user == nil || credentials[PASSWORD_KEY] == "" {
+		return false
+	}
+
+	return hash.Check(credentials[PASSWORD_KEY], user.AuthPassword())
+}
+
+// Comment
+func Authenticated(guards ...string) http.Middleware {
+	if len(guards) == 0 {
+		guards = []string{""}
+	}
+
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		for _, name := range guards {
+			if Auth(req).Guard(name).Check() {
+				if name != "" {
+					Auth(req).ShouldUse(name)
+				}
+
+				return next()
+			}
+		}
+
+		return unauthenticated(req, res)
+	}
+}
+
+// Comment
+func Guest(guards ...string) http.Middleware {
+	if len(guards) == 0 {
+		guards = []string{""}
+	}
+
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		for _, name := range guards {
+			if Auth(req).Guard(name).Check() {
+				manager := managerOf(req)
+
+				manager.mutex.RLock()
+				defer manager.mutex.RUnlock()
+
+				return res.Redirect(manager.home)
+			}
+		}
+
+		return next()
+	}
+}
+
+// Comment
+func unauthenticated(req *http.Request, res *http.Response) *http.Response {
+	if strings.ToLower(req.ContentType()) == "application/json" || req.WantsJson() || req.GetHeader("authorization") != "" {
+		return res.Error(http.Unauthorized(UnauthenticatedMessage))
+	}
+
+	manager := managerOf(req)
+
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	return res.Redirect(manager.redirect)
+}
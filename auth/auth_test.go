@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/encryption/hash"
+	"github.com/lucas11776-golang/http/types"
+	str "github.com/lucas11776-golang/http/utils/strings"
+	"github.com/lucas11776-golang/orm"
+	"github.com/lucas11776-golang/orm/databases/sqlite"
+	"github.com/spf13/cast"
+)
+
+type User struct {
+	Connection string `connection:"auth"`
+	Table      string `table:"users"`
+	ID         int64  `column:"id" type:"primary_key"`
+	Email      string `column:"email" type:"string"`
+	Password   string `column:"password" type:"string"`
+	ApiToken   string `column:"api_token" type:"string"`
+}
+
+// Comment
+func (ctx User) AuthIdentifier() string {
+	return cast.ToString(ctx.ID)
+}
+
+// Comment
+func (ctx User) AuthPassword() string {
+	return ctx.Password
+}
+
+func TestAuth(t *testing.T) {
+	orm.DB.Add("auth", sqlite.Connect(":memory:"))
+
+	if err := orm.DB.Database("auth").Migration().Migrate(orm.Models{User{}}); err != nil {
+		t.Fatalf("Something went wrong when trying to migrate users table: %v", err)
+	}
+
+	password, _ := hash.Make("secret")
+	token, hashed := NewToken()
+
+	if _, err := orm.Model(User{}).Insert(orm.Values{"email": "jeo@doe.com", "password": password, "api_token": hashed}); err != nil {
+		t.Fatalf("Something went wrong when trying to insert user: %v", err)
+	}
+
+	Use(NewOrmUserProvider(User{}))
+
+	sessions := http.InitSession("session", []byte(str.Random(10)), http.NewMemorySessionStore())
+
+	request := func(t *testing.T, headers types.Headers) *http.Request {
+		req, err := http.NewRequest(http.METHOD_GET, "http://app.test/dashboard", "HTTP/1.1", headers, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		req.Session = sessions.Session(req)
+		req.Response.Session = req.Session
+
+		return req
+	}
+
+	cookie := func(t *testing.T, req *http.Request) types.Headers {
+		req.Session.Save()
+
+		cookie, err := url.ParseQuery(strings.ReplaceAll(req.Response.GetHeader("Set-Cookie"), "; ", "&"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return types.Headers{"cookie": strings.Join([]string{"session", cookie.Get("session")}, "=")}
+	}
+
+	t.Run("TestSessionGuard", func(t *testing.T) {
+		req := request(t, types.Headers{})
+
+		if Auth(req).Attempt(Credentials{"email": "jeo@doe.com", "password": "wrong"}) {
+			t.Fatalf("Expected attempt with wrong password to fail")
+		}
+
+		if Auth(req).Attempt(Credentials{"password": "secret"}) {
+			t.Fatalf("Expected attempt without identifying credentials to fail")
+		}
+
+		if !Auth(req).Attempt(Credentials{"email": "jeo@doe.com", "password": "secret"}) {
+			t.Fatalf("Expected attempt with valid credentials to succeed")
+		}
+
+		headers := cookie(t, req)
+
+		req = request(t, headers)
+
+		if !Auth(req).Check() || Auth(req).Id() != "1" {
+			t.Fatalf("Expected authenticated user id to be (%s) but got (%s)", "1", Auth(req).Id())
+		}
+
+		if user, ok := Auth(req).User().(*User); !ok || user.Email != "jeo@doe.com" {
+			t.Fatalf("Expected authenticated user email to be (%s) but got (%v)", "jeo@doe.com", Auth(req).User())
+		}
+
+		Auth(req).Logout()
+
+		if Auth(req).Check() {
+			t.Fatalf("Expected user to be logged out")
+		}
+
+		cookie(t, req)
+
+		if req := request(t, headers); Auth(req).Check() {
+			t.Fatalf("Expected logged out session to be a guest")
+		}
+	})
+
+	t.Run("TestTokenGuard", func(t *testing.T) {
+		req := request(t, types.Headers{"authorization": "Bearer " + token})
+
+		if Auth(req).Guard(GUARD_API).Id() != "1" {
+			t.Fatalf("Expected token user id to be (%s) but got (%s)", "1", Auth(req).Guard(GUARD_API).Id())
+		}
+
+		if Auth(req).Check() {
+			t.Fatalf("Expected session guard to be a guest")
+		}
+
+		if req := request(t, types.Headers{"authorization": "Bearer invalid"}); Auth(req).Guard(GUARD_API).Check() {
+			t.Fatalf("Expected invalid token to be a guest")
+		}
+	})
+
+	t.Run("TestMissingGuard", func(t *testing.T) {
+		req := request(t, types.Headers{})
+
+		if Auth(req).Has("missing") || Auth(req).Guard("missing").Check() || !Auth(req).Guard("missing").Guest() {
+			t.Fatalf("Expected missing guard to be a guest")
+		}
+
+		if Auth(req).Guard("missing").Attempt(Credentials{"email": "jeo@doe.com", "password": "secret"}) {
+			t.Fatalf("Expected attempt on missing guard to fail")
+		}
+	})
+
+	t.Run("TestAuthenticated", func(t *testing.T) {
+		handle := func(middleware http.Middleware, req *http.Request) *http.Response {
+			return middleware(req, req.Response, func() *http.Response {
+				return req.Response.Html("<h1>Dashboard</h1>")
+			})
+		}
+
+		res := handle(Authenticated(), request(t, types.Headers{}))
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_TEMPORARY_REDIRECT) || res.Bag.Redirect.To != "login" {
+			t.Fatalf("Expected guest to be redirected to (%s) but got status (%d)", "login", res.StatusCode)
+		}
+
+		res = handle(Authenticated(), request(t, types.Headers{"accept": "application/json"}))
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_UNAUTHORIZED) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_UNAUTHORIZED, res.StatusCode)
+		}
+
+		req := request(t, types.Headers{"authorization": "Bearer " + token})
+		res = handle(Authenticated(GUARD_WEB, GUARD_API), req)
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_OK) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_OK, res.StatusCode)
+		}
+
+		if Auth(req).Id() != "1" {
+			t.Fatalf("Expected authenticated guard user id to be (%s) but got (%s)", "1", Auth(req).Id())
+		}
+
+		req = request(t, types.Headers{})
+		Auth(req).Login(&User{ID: 1})
+
+		if res := handle(Guest(), req); res.StatusCode != int(http.HTTP_RESPONSE_TEMPORARY_REDIRECT) || res.Bag.Redirect.To != "" {
+			t.Fatalf("Expected authenticated user to be redirected home but got status (%d)", res.StatusCode)
+		}
+
+		if res := handle(Guest(), request(t, types.Headers{})); res.StatusCode != int(http.HTTP_RESPONSE_OK) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_OK, res.StatusCode)
+		}
+	})
+
+	t.Run("TestViewHelper", func(t *testing.T) {
+		view := http.NewView(fstest.MapFS{
+			"nav.html": {Data: []byte(`{% if auth().Check() %}User {{ auth().Id() }}{% else %}Guest{% end %}`)},
+		}, "html")
+
+		server := http.Server("127.0.0.1", 0)
+
+		Attach(server)
+
+		req := request(t, types.Headers{})
+		req.Server = server
+
+		if html, _ := view.Read("nav", http.ViewData{}, req); string(html) != "Guest" {
+			t.Fatalf("Expected view to be (%s) but got (%s)", "Guest", string(html))
+		}
+
+		Auth(req).Login(&User{ID: 1})
+
+		if html, _ := view.Read("nav", http.ViewData{}, req); string(html) != "User 1" {
+			t.Fatalf("Expected view to be (%s) but got (%s)", "User 1", string(html))
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestManager", func(t *testing.T) {
+		server := http.Server("127.0.0.1", 0)
+
+		// Guards registered on the manager of a server are not visible to other servers.
+		NewManager().Register("manager", TokenGuard, NewOrmUserProvider(User{})).Attach(server)
+
+		req := request(t, types.Headers{})
+		req.Server = server
+
+		if !Auth(req).Has("manager") || Auth(request(t, types.Headers{})).Has("manager") {
+			t.Fatalf("Expected guard to only be registered on the attached manager")
+		}
+
+		server.Close()
+	})
+}
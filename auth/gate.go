@@ -0,0 +1,330 @@
+package auth
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/lucas11776-golang/http"
+)
+
+type Ability func(user Authenticatable, arguments ...interface{}) bool
+
+type BeforeCallback func(user Authenticatable, ability string, arguments ...interface{}) (bool, bool)
+
+type AfterCallback func(user Authenticatable, ability string, result bool, arguments ...interface{}) (bool, bool)
+
+type ArgumentResolver func(req *http.Request) interface{}
+
+type Gate struct {
+	abilities map[string]Ability
+	policies  map[reflect.Type]interface{}
+	guests    map[string]bool
+	before    []BeforeCallback
+	after     []AfterCallback
+	mutex     sync.RWMutex
+}
+
+const GATE_DEPENDENCY = "gate"
+
+// Requests of servers without an attached gate use the default gate.
+var gate = NewGate()
+
+// Comment
+func NewGate() *Gate {
+	return &Gate{
+		abilities: map[string]Ability{},
+		policies:  map[reflect.Type]interface{}{},
+		guests:    map[string]bool{},
+	}
+}
+
+// Comment
+func DefaultGate() *Gate {
+	return gate
+}
+
+// Comment
+func Define(ability string, callback Ability) *Gate {
+	return gate.Define(ability, callback)
+}
+
+// Comment
+func Policy(model interface{}, policy interface{}) *Gate {
+	return gate.Policy(model, policy)
+}
+
+// Comment
+func AllowGuests(abilities ...string) *Gate {
+	return gate.AllowGuests(abilities...)
+}
+
+// Comment
+func Before(callback BeforeCallback) *Gate {
+	return gate.Before(callback)
+}
+
+// Comment
+func After(callback AfterCallback) *Gate {
+	return gate.After(callback)
+}
+
+// Comment
+func (ctx *Gate) Attach(server *http.HTTP) *Gate {
+	server.Set(GATE_DEPENDENCY, ctx).Authorizer(func(req *http.Request, ability string, arguments ...interface{}) bool {
+		return Allows(req, ability, arguments...)
+	}).ViewHelper("can", func(req *http.Request) interface{} {
+		return func(ability string, arguments ...interface{}) bool { return Allows(req, ability, arguments...) }
+	})
+
+	return ctx
+}
+
+// Comment
+func gateOf(req *http.Request) *Gate {
+	if req.Server != nil {
+		if gate, ok := req.Server.Get(GATE_DEPENDENCY).(*Gate); ok {
+			return gate
+		}
+	}
+
+	return gate
+}
+
+// Comment
+func Allows(req *http.Request, ability string, arguments ...interface{}) bool {
+	var user Authenticatable
+
+	if Auth(req).Has() {
+		user = Auth(req).User()
+	}
+
+	return gateOf(req).Check(user, ability, arguments...)
+}
+
+// Comment
+func Denies(req *http.Request, ability string, arguments ...interface{}) bool {
+	return !Allows(req, ability, arguments...)
+}
+
+// Comment
+func modelType(model interface{}) reflect.Type {
+	t := reflect.TypeOf(model)
+
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// Comment
+func methodName(ability string) string {
+	words := strings.FieldsFunc(ability, func(r rune) bool {
+		return r == '-' || r == '_' || r == ' ' || r == '.'
+	})
+
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+
+	return strings.Join(words, "")
+}
+
+// Comment
+func (ctx *Gate) Define(ability string, callback Ability) *Gate {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.abilities[ability] = callback
+
+	return ctx
+}
+
+// Comment
+func (ctx *Gate) Policy(model interface{}, policy interface{}) *Gate {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.policies[modelType(model)] = policy
+
+	return ctx
+}
+
+// Comment
+func (ctx *Gate) AllowGuests(abilities ...string) *Gate {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	for _, ability := range abilities {
+		ctx.guests[ability] = true
+	}
+
+	return ctx
+}
+
+// Comment
+func (ctx *Gate) Before(callback BeforeCallback) *Gate {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.before = append(ctx.before, callback)
+
+	return ctx
+}
+
+// Comment
+func (ctx *Gate) After(callback AfterCallback) *Gate {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.after = append(ctx.after, callback)
+
+	return ctx
+}
+
+// Comment
+func (ctx *Gate) Has(ability string) bool {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+
+	_, ok := ctx.abilities[ability]
+
+	return ok
+}
+
+// Comment
+func (ctx *Gate) Check(user Authenticatable, ability string, arguments ...interface{}) bool {
+	ctx.mutex.RLock()
+
+	before, after := ctx.before, ctx.after
+	callback, defined := ctx.abilities[ability]
+	guests := ctx.guests[ability]
+
+	var policy interface{}
+
+	if len(arguments) != 0 {
+		policy = ctx.policies[modelType(arguments[0])]
+	}
+
+	ctx.mutex.RUnlock()
+
+	// Guests are denied before any callback runs unless the ability opted in to guests.
+	if isGuest(user) {
+		if !guests {
+			return false
+		}
+
+		user = nil
+	}
+
+	for _, callback := range before {
+		if result, decided := callback(user, ability, arguments...); decided {
+			return result
+		}
+	}
+
+	result := false
+
+	switch {
+	case defined:
+		result = callback(user, arguments...)
+
+	case policy != nil:
+		result = callPolicy(policy, methodName(ability), user, arguments)
+	}
+
+	for _, callback := range after {
+		if override, decided := callback(user, ability, result, arguments...); decided {
+			result = override
+		}
+	}
+
+	return result
+}
+
+// Comment
+func (ctx *Gate) Denies(user Authenticatable, ability string, arguments ...interface{}) bool {
+	return !ctx.Check(user, ability, arguments...)
+}
+
+// Comment
+func isGuest(user Authenticatable) bool {
+	if user == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(user)
+
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// Comment
+func callPolicy(policy interface{}, name string, user Authenticatable, arguments []interface{}) bool {
+	method := reflect.ValueOf(policy).MethodByName(name)
+
+	if !method.IsValid() {
+		return false
+	}
+
+	t := method.Type()
+
+	if t.NumOut() != 1 || t.Out(0).Kind() != reflect.Bool || t.NumIn() == 0 || t.IsVariadic() {
+		return false
+	}
+
+	values := []interface{}{user}
+
+	// Policy methods without a model parameter (e.g. Create) only receive the user.
+	if t.NumIn() > 1 {
+		values = append(values, arguments...)
+	}
+
+	if t.NumIn() != len(values) {
+		return false
+	}
+
+	in := make([]reflect.Value, len(values))
+
+	for i, value := range values {
+		v := reflect.ValueOf(value)
+
+		switch {
+		case value == nil:
+			in[i] = reflect.Zero(t.In(i))
+
+		case v.Type().AssignableTo(t.In(i)):
+			in[i] = v
+
+		// Views pass models by value, so values and pointers are converted to what the policy expects.
+		case t.In(i).Kind() == reflect.Pointer && v.Type().AssignableTo(t.In(i).Elem()):
+			in[i] = reflect.New(v.Type())
+			in[i].Elem().Set(v)
+
+		case v.Kind() == reflect.Pointer && !v.IsNil() && v.Elem().Type().AssignableTo(t.In(i)):
+			in[i] = v.Elem()
+
+		default:
+			return false
+		}
+	}
+
+	return method.Call(in)[0].Bool()
+}
+
+// Comment
+func Can(ability string, resolvers ...ArgumentResolver) http.Middleware {
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		arguments := []interface{}{}
+
+		for _, resolver := range resolvers {
+			arguments = append(arguments, resolver(req))
+		}
+
+		if Denies(req, ability, arguments...) {
+			return res.Error(http.Forbidden(http.AuthorizationMessage))
+		}
+
+		return next()
+	}
+}
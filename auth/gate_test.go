@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/types"
+)
+
+type Post struct {
+	ID     int64
+	UserID int64
+}
+
+type PostPolicy struct{}
+
+// Comment
+func (ctx PostPolicy) Create(user *User) bool {
+	return user != nil
+}
+
+// Comment
+func (ctx PostPolicy) Update(user *User, post *Post) bool {
+	return user != nil && user.ID == post.UserID
+}
+
+// Comment
+func (ctx PostPolicy) ForceDelete(user *User, post *Post) bool {
+	return false
+}
+
+func TestGate(t *testing.T) {
+	owner := &User{ID: 1}
+	other := &User{ID: 2}
+	admin := &User{ID: 3, Email: "admin@app.test"}
+	post := &Post{ID: 1, UserID: 1}
+
+	t.Run("TestDefine", func(t *testing.T) {
+		gate := NewGate().Define("publish", func(user Authenticatable, arguments ...interface{}) bool {
+			return user != nil && user.AuthIdentifier() == "1"
+		})
+
+		if !gate.Check(owner, "publish") || gate.Check(other, "publish") || gate.Check(nil, "publish") {
+			t.Fatalf("Expected only owner to be allowed to publish")
+		}
+
+		if gate.Check(owner, "undefined") {
+			t.Fatalf("Expected undefined ability to be denied")
+		}
+	})
+
+	t.Run("TestPolicy", func(t *testing.T) {
+		gate := NewGate().Policy(Post{}, PostPolicy{})
+
+		if !gate.Check(owner, "update", post) || gate.Check(other, "update", post) {
+			t.Fatalf("Expected only owner to be allowed to update post")
+		}
+
+		if !gate.Check(owner, "create", (*Post)(nil)) || gate.Check(nil, "create", Post{}) {
+			t.Fatalf("Expected only users to be allowed to create posts")
+		}
+
+		if gate.Check(owner, "force-delete", post) || !gate.Denies(owner, "force_delete", post) {
+			t.Fatalf("Expected force delete to be denied")
+		}
+
+		if gate.Check(owner, "update", "post") {
+			t.Fatalf("Expected ability without policy to be denied")
+		}
+	})
+
+	t.Run("TestGuests", func(t *testing.T) {
+		calls := 0
+
+		gate := NewGate().Policy(Post{}, PostPolicy{}).
+			Define("view", func(user Authenticatable, arguments ...interface{}) bool {
+				calls++
+
+				return user == nil
+			}).
+			Define("comment", func(user Authenticatable, arguments ...interface{}) bool {
+				calls++
+
+				return true
+			}).
+			Before(func(user Authenticatable, ability string, arguments ...interface{}) (bool, bool) {
+				calls++
+
+				return true, ability == "force-delete"
+			}).
+			AllowGuests("view")
+
+		if gate.Check(nil, "comment") || gate.Check((*User)(nil), "comment") || gate.Check(nil, "force-delete", post) {
+			t.Fatalf("Expected guests to be denied")
+		}
+
+		if calls != 0 {
+			t.Fatalf("Expected callbacks to not be called for guests but got (%d) calls", calls)
+		}
+
+		if !gate.Check((*User)(nil), "view") {
+			t.Fatalf("Expected guests to be allowed to view")
+		}
+	})
+
+	t.Run("TestBeforeAfter", func(t *testing.T) {
+		gate := NewGate().Policy(Post{}, PostPolicy{}).
+			Before(func(user Authenticatable, ability string, arguments ...interface{}) (bool, bool) {
+				if user, ok := user.(*User); ok && user.Email == "admin@app.test" {
+					return true, true
+				}
+
+				return false, false
+			}).
+			After(func(user Authenticatable, ability string, result bool, arguments ...interface{}) (bool, bool) {
+				return false, ability == "update" && user != nil && user.AuthIdentifier() == "1"
+			})
+
+		if !gate.Check(admin, "force-delete", post) {
+			t.Fatalf("Expected super admin to be allowed to force delete")
+		}
+
+		if gate.Check(owner, "update", post) {
+			t.Fatalf("Expected after hook to override owner update")
+		}
+	})
+
+	t.Run("TestRequest", func(t *testing.T) {
+		Register("gate", TokenGuard, NewOrmUserProvider(User{}))
+		Policy(Post{}, PostPolicy{})
+
+		server := http.Server("127.0.0.1", 0)
+
+		Attach(server)
+
+		request := func(t *testing.T, user *User, headers types.Headers) *http.Request {
+			req, err := http.NewRequest(http.METHOD_PUT, "http://app.test/posts/1", "HTTP/1.1", headers, strings.NewReader(""))
+
+			if err != nil {
+				t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+			}
+
+			req.Server = server
+
+			if user != nil {
+				Auth(req).ShouldUse("gate").Login(user)
+			}
+
+			return req
+		}
+
+		handle := func(req *http.Request) *http.Response {
+			return Can("update", func(req *http.Request) interface{} { return post })(req, req.Response, func() *http.Response {
+				return req.Response.Html("<h1>Post updated</h1>")
+			})
+		}
+
+		if res := handle(request(t, owner, types.Headers{})); res.StatusCode != int(http.HTTP_RESPONSE_OK) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_OK, res.StatusCode)
+		}
+
+		if res := handle(request(t, other, types.Headers{})); res.StatusCode != int(http.HTTP_RESPONSE_FORBIDDEN) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_FORBIDDEN, res.StatusCode)
+		}
+
+		res := handle(request(t, other, types.Headers{"accept": "application/json"}))
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_FORBIDDEN) || res.GetHeader("content-type") != http.PROBLEM_JSON_CONTENT_TYPE {
+			t.Fatalf("Expected forbidden problem response but got (%d, %s)", res.StatusCode, res.GetHeader("content-type"))
+		}
+
+		if err := request(t, other, types.Headers{}).Authorize("update", post); http.ToHttpError(err).Status != http.HTTP_RESPONSE_FORBIDDEN {
+			t.Fatalf("Expected authorize error to be forbidden but got (%v)", err)
+		}
+
+		if err := request(t, owner, types.Headers{}).Authorize("update", post); err != nil {
+			t.Fatalf("Expected owner to be authorized but got (%v)", err)
+		}
+
+		Define("gate-comment", func(user Authenticatable, arguments ...interface{}) bool { return true })
+
+		guest := request(t, nil, types.Headers{})
+
+		res = Can("gate-comment")(guest, guest.Response, func() *http.Response {
+			return guest.Response.Html("<h1>Comment added</h1>")
+		})
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_FORBIDDEN) {
+			t.Fatalf("Expected guest status code to be (%d) but got (%d)", http.HTTP_RESPONSE_FORBIDDEN, res.StatusCode)
+		}
+
+		view := http.NewView(fstest.MapFS{
+			"post.html": {Data: []byte(`{% if can("update", post) %}Edit{% else %}View{% end %}`)},
+		}, "html")
+
+		for user, expected := range map[*User]string{owner: "Edit", other: "View"} {
+			if html, _ := view.Read("post", http.ViewData{"post": post}, request(t, user, types.Headers{})); string(html) != expected {
+				t.Fatalf("Expected view to be (%s) but got (%s)", expected, string(html))
+			}
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestAttach", func(t *testing.T) {
+		server := http.Server("127.0.0.1", 0)
+		other := http.Server("127.0.0.1", 0)
+
+		// Each server checks abilities with its own gate.
+		NewGate().Define("gate-attach", func(user Authenticatable, arguments ...interface{}) bool { return true }).AllowGuests("gate-attach").Attach(server)
+		NewGate().Attach(other)
+
+		for s, expected := range map[*http.HTTP]bool{server: true, other: false} {
+			req, _ := http.NewRequest(http.METHOD_GET, "http://app.test/posts", "HTTP/1.1", types.Headers{}, strings.NewReader(""))
+
+			req.Server = s
+
+			if req.Can("gate-attach") != expected {
+				t.Fatalf("Expected ability of attached gate to be (%t) but got (%t)", expected, !expected)
+			}
+		}
+
+		server.Close()
+		other.Close()
+	})
+}
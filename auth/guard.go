@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/lucas11776-golang/http"
+)
+
+const TOKEN_QUERY_NAME = "api_token"
+
+type sessionGuard struct {
+	request  *http.Request
+	provider UserProvider
+	user     Authenticatable
+	resolved bool
+}
+
+type guestGuard struct{}
+
+type tokenGuard struct {
+	request  *http.Request
+	provider UserProvider
+	retrieve func(token string) (Authenticatable, error)
+	user     Authenticatable
+	resolved bool
+}
+
+// Comment
+func SessionGuard(req *http.Request, provider UserProvider) Guard {
+	return &sessionGuard{request: req, provider: provider}
+}
+
+// Comment
+func (ctx *sessionGuard) User() Authenticatable {
+	if ctx.resolved {
+		return ctx.user
+	}
+
+	ctx.resolved = true
+
+	// Users waiting for the two factor challenge are guests until the challenge is passed.
+	if TwoFactorPending(ctx.request) {
+		return nil
+	}
+
+	ctx.user = ctx.retrieve()
+
+	return ctx.user
+}
+
+// Comment
+func (ctx *sessionGuard) retrieve() Authenticatable {
+	if ctx.request.Session == nil || ctx.request.Session.User() == "" {
+		return nil
+	}
+
+	user, err := ctx.provider.RetrieveById(ctx.request.Session.User())
+
+	if err != nil || user == nil {
+		return nil
+	}
+
+	return user
+}
+
+// Comment
+func (ctx *sessionGuard) pending() Authenticatable {
+	if !TwoFactorPending(ctx.request) {
+		return nil
+	}
+
+	return ctx.retrieve()
+}
+
+// Comment
+func (ctx *sessionGuard) Check() bool {
+	return ctx.User() != nil
+}
+
+// Comment
+func (ctx *sessionGuard) Guest() bool {
+	return !ctx.Check()
+}
+
+// Comment
+func (ctx *sessionGuard) Id() string {
+	if user := ctx.User(); user != nil {
+		return user.AuthIdentifier()
+	}
+
+	return ""
+}
+
+// Comment
+func (ctx *sessionGuard) Validate(credentials Credentials) bool {
+	user, err := ctx.provider.RetrieveByCredentials(credentials)
+
+	if err != nil || user == nil {
+		return false
+	}
+
+	return ctx.provider.ValidateCredentials(user, credentials)
+}
+
+// Comment
+func (ctx *sessionGuard) Attempt(credentials Credentials) bool {
+	user, err := ctx.provider.RetrieveByCredentials(credentials)
+
+	if err != nil || user == nil || !ctx.provider.ValidateCredentials(user, credentials) {
+		return false
+	}
+
+	ctx.Login(user)
+
+	return true
+}
+
+// Comment
+func (ctx *sessionGuard) Login(user Authenticatable) {
+	if ctx.request.Session != nil {
+		ctx.request.Session.Regenerate().SetUser(user.AuthIdentifier())
+
+		markTwoFactorPending(ctx.request, user)
+	}
+
+	ctx.user = user
+	ctx.resolved = true
+
+	if TwoFactorPending(ctx.request) {
+		ctx.user = nil
+	}
+}
+
+// Comment
+func (ctx *sessionGuard) Logout() {
+	if ctx.request.Session != nil {
+		ctx.request.Session.Invalidate()
+	}
+
+	ctx.user = nil
+	ctx.resolved = true
+}
+
+// Comment
+func TokenGuard(req *http.Request, provider UserProvider) Guard {
+	return &tokenGuard{request: req, provider: provider, retrieve: provider.RetrieveByToken}
+}
+
+// Comment
+func BearerToken(req *http.Request) string {
+	header := req.GetHeader("authorization")
+
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+
+	return ""
+}
+
+// Comment
+func (ctx *tokenGuard) token() string {
+	if token := BearerToken(ctx.request); token != "" {
+		return token
+	}
+
+	return ctx.request.GetQuery(TOKEN_QUERY_NAME)
+}
+
+// Comment
+func (ctx *tokenGuard) User() Authenticatable {
+	if ctx.resolved {
+		return ctx.user
+	}
+
+	ctx.resolved = true
+
+	token := ctx.token()
+
+	if token == "" {
+		return nil
+	}
+
+	if user, err := ctx.retrieve(token); err == nil && user != nil {
+		ctx.user = user
+	}
+
+	return ctx.user
+}
+
+// Comment
+func (ctx *tokenGuard) Check() bool {
+	return ctx.User() != nil
+}
+
+// Comment
+func (ctx *tokenGuard) Guest() bool {
+	return !ctx.Check()
+}
+
+// Comment
+func (ctx *tokenGuard) Id() string {
+	if user := ctx.User(); user != nil {
+		return user.AuthIdentifier()
+	}
+
+	return ""
+}
+
+// Comment
+func (ctx *tokenGuard) Validate(credentials Credentials) bool {
+	user, err := ctx.provider.RetrieveByCredentials(credentials)
+
+	if err != nil || user == nil {
+		return false
+	}
+
+	return ctx.provider.ValidateCredentials(user, credentials)
+}
+
+// Comment
+func (ctx *tokenGuard) Attempt(credentials Credentials) bool {
+	user, err := ctx.provider.RetrieveByCredentials(credentials)
+
+	if err != nil || user == nil || !ctx.provider.ValidateCredentials(user, credentials) {
+		return false
+	}
+
+	ctx.Login(user)
+
+	return true
+}
+
+// Comment
+func (ctx *tokenGuard) Login(user Authenticatable) {
+	ctx.user = user
+	ctx.resolved = true
+}
+
+// Comment
+func (ctx *tokenGuard) Logout() {
+	ctx.user = nil
+	ctx.resolved = true
+}
+
+// Comment
+func (ctx guestGuard) Check() bool {
+	return false
+}
+
+// Comment
+func (ctx guestGuard) Guest() bool {
+	return true
+}
+
+// Comment
+func (ctx guestGuard) User() Authenticatable {
+	return nil
+}
+
+// Comment
+func (ctx guestGuard) Id() string {
+	return ""
+}
+
+// Comment
+func (ctx guestGuard) Validate(credentials Credentials) bool {
+	return false
+}
+
+// Comment
+func (ctx guestGuard) Attempt(credentials Credentials) bool {
+	return false
+}
+
+// Comment
+func (ctx guestGuard) Login(user Authenticatable) {}
+
+// Comment
+func (ctx guestGuard) Logout() {}
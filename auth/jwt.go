@@ -0,0 +1,336 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/cache"
+	"github.com/lucas11776-golang/http/encryption/crypt"
+	"github.com/lucas11776-golang/http/encryption/jwt"
+)
+
+const (
+	REQUEST_JWT_KEY     = "jwt"
+	JWT_ACCESS_TTL      = time.Minute * 15
+	JWT_REFRESH_TTL     = time.Hour * 24 * 30
+	JWT_DENYLIST_KEY    = "jwt-denylist|"
+	JWT_TOKEN_TYPE      = "Bearer"
+	JWT_INVALID_TOKEN   = "invalid_token"
+	JWT_MIN_SECRET      = 32
+	InvalidTokenMessage = "The access token is invalid or has expired."
+)
+
+var (
+	ErrTokenRevoked     = errors.New("token has been revoked")
+	ErrTokenReused      = errors.New("refresh token has already been used")
+	ErrDenylistRequired = errors.New("jwt denylist is required to revoke tokens")
+	ErrSecretTooShort   = fmt.Errorf("jwt secret must be at least %d bytes", JWT_MIN_SECRET)
+	ErrSigningKey       = errors.New("jwt has no key to sign tokens")
+)
+
+type Denylist interface {
+	Revoke(id string, expires time.Time) error
+	RevokeOnce(id string, expires time.Time) (bool, error)
+	Revoked(id string) bool
+}
+
+type CacheDenylist struct {
+	store cache.Store
+	mutex sync.Mutex
+}
+
+type JwtConfig struct {
+	Secret     []byte
+	Keys       *jwt.KeySet
+	Verifier   jwt.Verifier
+	Issuer     string
+	Audience   []string
+	Leeway     time.Duration
+	AccessTtl  time.Duration
+	RefreshTtl time.Duration
+	Denylist   Denylist
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type JwtClaims[C any] interface {
+	*C
+	jwt.Claimer
+}
+
+type Jwt[C any, P JwtClaims[C]] struct {
+	config JwtConfig
+}
+
+// Comment
+func NewCacheDenylist(store cache.Store) *CacheDenylist {
+	return &CacheDenylist{store: store}
+}
+
+// Comment
+func (ctx *CacheDenylist) Revoke(id string, expires time.Time) error {
+	return ctx.store.Set(JWT_DENYLIST_KEY+id, []byte{1}, time.Until(expires))
+}
+
+// Comment
+func (ctx *CacheDenylist) RevokeOnce(id string, expires time.Time) (bool, error) {
+	// The cache store has no atomic add, the revocation is only atomic in this process.
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.Revoked(id) {
+		return false, nil
+	}
+
+	return true, ctx.Revoke(id, expires)
+}
+
+// Comment
+func (ctx *CacheDenylist) Revoked(id string) bool {
+	_, ok := ctx.store.Get(JWT_DENYLIST_KEY + id)
+
+	return ok
+}
+
+// Comment
+func NewJwt[C any, P JwtClaims[C]](config JwtConfig) *Jwt[C, P] {
+	if config.AccessTtl == 0 {
+		config.AccessTtl = JWT_ACCESS_TTL
+	}
+
+	if config.RefreshTtl == 0 {
+		config.RefreshTtl = JWT_REFRESH_TTL
+	}
+
+	// Revocations must survive restarts and cache flushes, so there is no implicit default.
+	if config.Denylist == nil {
+		panic(ErrDenylistRequired)
+	}
+
+	// An empty or short hmac secret would let anyone sign tokens, services that only verify tokens need no secret.
+	if config.Keys == nil && (config.Verifier == nil || len(config.Secret) != 0) {
+		if len(config.Secret) < JWT_MIN_SECRET {
+			panic(ErrSecretTooShort)
+		}
+
+		config.Keys = jwt.NewKeySet(jwt.NewHmacKey("", config.Secret))
+	}
+
+	if config.Verifier == nil {
+		config.Verifier = config.Keys
+	}
+
+	return &Jwt[C, P]{config: config}
+}
+
+// Comment
+func tokenId() string {
+	return base64.RawURLEncoding.EncodeToString(crypt.RandomBytes(16))
+}
+
+// Comment
+func (ctx *Jwt[C, P]) options() jwt.Options {
+	return jwt.Options{Issuer: ctx.config.Issuer, Audience: ctx.config.Audience, Leeway: ctx.config.Leeway}
+}
+
+// Comment
+func (ctx *Jwt[C, P]) sign(claims P, tokenType string, family string, ttl time.Duration) (string, error) {
+	if ctx.config.Keys == nil {
+		return "", ErrSigningKey
+	}
+
+	token := *claims
+	base := P(&token).Base()
+	now := time.Now()
+
+	base.ID = tokenId()
+	base.TokenType = tokenType
+	base.Family = family
+	base.IssuedAt = gojwt.NewNumericDate(now)
+	base.NotBefore = gojwt.NewNumericDate(now)
+	base.ExpiresAt = gojwt.NewNumericDate(now.Add(ttl))
+
+	if ctx.config.Issuer != "" {
+		base.Issuer = ctx.config.Issuer
+	}
+
+	if len(ctx.config.Audience) != 0 {
+		base.Audience = ctx.config.Audience
+	}
+
+	return ctx.config.Keys.Sign(P(&token))
+}
+
+// Comment
+func (ctx *Jwt[C, P]) pair(claims P, family string) (*TokenPair, error) {
+	access, err := ctx.sign(claims, jwt.ACCESS_TOKEN, family, ctx.config.AccessTtl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := ctx.sign(claims, jwt.REFRESH_TOKEN, family, ctx.config.RefreshTtl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    JWT_TOKEN_TYPE,
+		ExpiresIn:    int64(ctx.config.AccessTtl.Seconds()),
+	}, nil
+}
+
+// Comment
+func (ctx *Jwt[C, P]) Issue(claims P) (*TokenPair, error) {
+	return ctx.pair(claims, tokenId())
+}
+
+// Comment
+func (ctx *Jwt[C, P]) parse(token string, tokenType string) (P, error) {
+	claims := P(new(C))
+
+	if err := ctx.config.Verifier.Verify(token, claims, ctx.options()); err != nil {
+		return nil, err
+	}
+
+	if claims.Base().TokenType != tokenType {
+		return nil, jwt.ErrInvalidTokenType
+	}
+
+	return claims, nil
+}
+
+// Comment
+func (ctx *Jwt[C, P]) Verify(token string) (P, error) {
+	claims, err := ctx.parse(token, jwt.ACCESS_TOKEN)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.config.Denylist.Revoked(claims.Base().ID) || ctx.config.Denylist.Revoked(claims.Base().Family) {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// Comment
+func (ctx *Jwt[C, P]) Refresh(token string) (*TokenPair, error) {
+	claims, err := ctx.parse(token, jwt.REFRESH_TOKEN)
+
+	if err != nil {
+		return nil, err
+	}
+
+	base := claims.Base()
+
+	if ctx.config.Denylist.Revoked(base.Family) {
+		return nil, ErrTokenRevoked
+	}
+
+	// The denylist lets a single request of every replica redeem the token, a second use revokes the family.
+	redeemed, err := ctx.config.Denylist.RevokeOnce(base.ID, base.ExpiresAt.Time)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !redeemed {
+		if err := ctx.config.Denylist.Revoke(base.Family, time.Now().Add(ctx.config.RefreshTtl)); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrTokenReused
+	}
+
+	return ctx.pair(claims, base.Family)
+}
+
+// Comment
+func (ctx *Jwt[C, P]) Revoke(token string) error {
+	claims := P(new(C))
+
+	if err := ctx.config.Verifier.Verify(token, claims, ctx.options()); err != nil {
+		return err
+	}
+
+	return ctx.config.Denylist.Revoke(claims.Base().ID, claims.Base().ExpiresAt.Time)
+}
+
+// Comment
+func (ctx *Jwt[C, P]) RevokeFamily(token string) error {
+	claims := P(new(C))
+
+	if err := ctx.config.Verifier.Verify(token, claims, ctx.options()); err != nil {
+		return err
+	}
+
+	return ctx.config.Denylist.Revoke(claims.Base().Family, time.Now().Add(ctx.config.RefreshTtl))
+}
+
+// Comment
+func (ctx *Jwt[C, P]) Middleware() http.Middleware {
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		claims, err := ctx.Verify(BearerToken(req))
+
+		if err != nil {
+			res.SetHeader("WWW-Authenticate", JWT_TOKEN_TYPE+` error="`+JWT_INVALID_TOKEN+`"`)
+
+			return res.Error(http.Unauthorized(InvalidTokenMessage))
+		}
+
+		req.Set(REQUEST_JWT_KEY, claims)
+
+		return next()
+	}
+}
+
+// Comment
+func (ctx *Jwt[C, P]) Keys() *jwt.KeySet {
+	return ctx.config.Keys
+}
+
+// Comment
+func Jwks(router *http.Router, keys *jwt.KeySet) *http.Route {
+	return router.Get(jwt.JWKS_PATH, func(req *http.Request, res *http.Response) *http.Response {
+		return res.Cache(int(jwt.JWKS_TTL.Seconds()), http.CACHE_PUBLIC).Json(keys.Jwks())
+	})
+}
+
+// Comment
+func Claims[C any](req *http.Request) *C {
+	claims, _ := req.Get(REQUEST_JWT_KEY).(*C)
+
+	return claims
+}
+
+// Comment
+func JwtGuard[C any, P JwtClaims[C]](issuer *Jwt[C, P]) Driver {
+	return func(req *http.Request, provider UserProvider) Guard {
+		return &tokenGuard{request: req, provider: provider, retrieve: func(token string) (Authenticatable, error) {
+			claims, err := issuer.Verify(token)
+
+			if err != nil {
+				return nil, err
+			}
+
+			req.Set(REQUEST_JWT_KEY, claims)
+
+			return provider.RetrieveById(claims.Base().Subject)
+		}}
+	}
+}
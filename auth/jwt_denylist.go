@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lucas11776-golang/http/utils/database"
+	"github.com/lucas11776-golang/orm"
+	"github.com/spf13/cast"
+)
+
+const JWT_DENYLIST_TABLE = "jwt_denylist"
+
+type DenylistRecord struct {
+	Table     string `table:"jwt_denylist"`
+	ID        int64  `column:"id" type:"primary_key"`
+	TokenId   string `column:"token_id" type:"string"`
+	ExpiresAt int64  `column:"expires_at" type:"integer"`
+}
+
+type DatabaseDenylist struct {
+	connection string
+}
+
+// Comment
+func NewDatabaseDenylist(connection string) *DatabaseDenylist {
+	return &DatabaseDenylist{connection: connection}
+}
+
+// Comment
+func (ctx *DatabaseDenylist) database() (orm.Database, error) {
+	db := orm.DB.Database(ctx.connection)
+
+	if db == nil {
+		return nil, fmt.Errorf("database connection %s does not exists", ctx.connection)
+	}
+
+	return db, nil
+}
+
+// Comment
+func (ctx *DatabaseDenylist) Migrate() error {
+	db, err := database.Connection(ctx.connection)
+
+	if err != nil {
+		return err
+	}
+
+	if err := orm.DB.Database(ctx.connection).Migration().Migrate(orm.Models{DenylistRecord{}}); err != nil {
+		return err
+	}
+
+	return database.Unique(db, JWT_DENYLIST_TABLE, "token_id")
+}
+
+// Comment
+func (ctx *DatabaseDenylist) Revoke(id string, expires time.Time) error {
+	revoked, err := ctx.RevokeOnce(id, expires)
+
+	if err != nil || revoked {
+		return err
+	}
+
+	// Revoking twice keeps the latest expiry so a family revocation can not be shortened.
+	_, err = database.Update(ctx.connection, JWT_DENYLIST_TABLE, orm.Values{"expires_at": expires.Unix()},
+		&orm.Where{Key: "token_id", Operator: orm.EQUALS, Value: id},
+		&orm.Where{Key: "expires_at", Operator: orm.LESS_THEN, Value: expires.Unix()},
+	)
+
+	return err
+}
+
+// Comment
+func (ctx *DatabaseDenylist) RevokeOnce(id string, expires time.Time) (bool, error) {
+	return database.Insert(ctx.connection, JWT_DENYLIST_TABLE, orm.Values{"token_id": id, "expires_at": expires.Unix()}, "token_id")
+}
+
+// Comment
+func (ctx *DatabaseDenylist) Revoked(id string) bool {
+	db, err := ctx.database()
+
+	if err != nil {
+		return true
+	}
+
+	results, err := db.Query(&orm.Statement{
+		Table: JWT_DENYLIST_TABLE,
+		Where: []interface{}{&orm.Where{Key: "token_id", Operator: orm.EQUALS, Value: id}},
+		Limit: 1,
+	})
+
+	// Tokens are treated as revoked when the denylist can not be read.
+	if err != nil {
+		return true
+	}
+
+	return len(results) != 0 && cast.ToInt64(results[0]["expires_at"]) > time.Now().Unix()
+}
+
+// Comment
+func (ctx *DatabaseDenylist) Gc() error {
+	db, err := ctx.database()
+
+	if err != nil {
+		return err
+	}
+
+	return db.Delete(&orm.Statement{
+		Table: JWT_DENYLIST_TABLE,
+		Where: []interface{}{&orm.Where{Key: "expires_at", Operator: orm.LESS_THEN_EQUALS, Value: time.Now().Unix()}},
+	})
+}
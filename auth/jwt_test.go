@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/cache"
+	"github.com/lucas11776-golang/http/encryption/jwt"
+	htesting "github.com/lucas11776-golang/http/testing"
+	"github.com/lucas11776-golang/http/types"
+	"github.com/lucas11776-golang/orm"
+	"github.com/lucas11776-golang/orm/databases/sqlite"
+)
+
+type ApiClaims struct {
+	jwt.Claims
+	Role string `json:"role"`
+}
+
+func TestJwt(t *testing.T) {
+	config := JwtConfig{
+		Secret:   []byte("jwt-secret-at-least-thirty-two-bytes"),
+		Issuer:   "https://app.test",
+		Audience: []string{"api"},
+		Leeway:   time.Second * 5,
+		Denylist: NewCacheDenylist(cache.NewMemoryStore()),
+	}
+
+	issuer := NewJwt[ApiClaims](config)
+
+	claims := func(subject string) *ApiClaims {
+		return &ApiClaims{Claims: jwt.Claims{RegisteredClaims: gojwt.RegisteredClaims{Subject: subject}}, Role: "admin"}
+	}
+
+	request := func(t *testing.T, token string) *http.Request {
+		req, err := http.NewRequest(http.METHOD_GET, "http://app.test/api/orders", "HTTP/1.1", types.Headers{
+			"authorization": strings.Join([]string{"Bearer", token}, " "),
+		}, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		return req
+	}
+
+	handle := func(req *http.Request) *http.Response {
+		return issuer.Middleware()(req, req.Response, func() *http.Response {
+			return req.Response.Json(Claims[ApiClaims](req))
+		})
+	}
+
+	t.Run("TestMiddleware", func(t *testing.T) {
+		pair, err := issuer.Issue(claims("1"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := request(t, pair.AccessToken)
+
+		if res := handle(req); res.StatusCode != int(http.HTTP_RESPONSE_OK) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_OK, res.StatusCode)
+		}
+
+		if claims := Claims[ApiClaims](req); claims.Subject != "1" || claims.Role != "admin" || claims.Issuer != config.Issuer {
+			t.Fatalf("Expected claims subject (%s) and role (%s) but got (%s, %s)", "1", "admin", claims.Subject, claims.Role)
+		}
+
+		res := handle(request(t, pair.RefreshToken))
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_UNAUTHORIZED) {
+			t.Fatalf("Expected refresh token status code to be (%d) but got (%d)", http.HTTP_RESPONSE_UNAUTHORIZED, res.StatusCode)
+		}
+
+		if res.GetHeader("WWW-Authenticate") != `Bearer error="invalid_token"` {
+			t.Fatalf("Expected challenge to be (%s) but got (%s)", `Bearer error="invalid_token"`, res.GetHeader("WWW-Authenticate"))
+		}
+	})
+
+	t.Run("TestValidation", func(t *testing.T) {
+		sign := func(modify func(claims *ApiClaims)) string {
+			c := claims("1")
+			c.TokenType = jwt.ACCESS_TOKEN
+			c.Issuer = config.Issuer
+			c.Audience = config.Audience
+			c.ExpiresAt = gojwt.NewNumericDate(time.Now().Add(time.Minute))
+
+			modify(c)
+
+			token, _ := jwt.Sign(config.Secret, c)
+
+			return token
+		}
+
+		tests := map[string]struct {
+			token    string
+			expected http.Status
+		}{
+			"leeway":   {sign(func(c *ApiClaims) { c.ExpiresAt = gojwt.NewNumericDate(time.Now().Add(-time.Second * 2)) }), http.HTTP_RESPONSE_OK},
+			"expired":  {sign(func(c *ApiClaims) { c.ExpiresAt = gojwt.NewNumericDate(time.Now().Add(-time.Minute)) }), http.HTTP_RESPONSE_UNAUTHORIZED},
+			"nbf":      {sign(func(c *ApiClaims) { c.NotBefore = gojwt.NewNumericDate(time.Now().Add(time.Minute)) }), http.HTTP_RESPONSE_UNAUTHORIZED},
+			"issuer":   {sign(func(c *ApiClaims) { c.Issuer = "https://evil.test" }), http.HTTP_RESPONSE_UNAUTHORIZED},
+			"audience": {sign(func(c *ApiClaims) { c.Audience = gojwt.ClaimStrings{"web"} }), http.HTTP_RESPONSE_UNAUTHORIZED},
+			"missing":  {"", http.HTTP_RESPONSE_UNAUTHORIZED},
+		}
+
+		for name, test := range tests {
+			if res := handle(request(t, test.token)); res.StatusCode != int(test.expected) {
+				t.Fatalf("Expected (%s) token status code to be (%d) but got (%d)", name, test.expected, res.StatusCode)
+			}
+		}
+	})
+
+	t.Run("TestRefreshRotation", func(t *testing.T) {
+		pair, _ := issuer.Issue(claims("1"))
+
+		if _, err := issuer.Refresh(pair.AccessToken); err == nil {
+			t.Fatalf("Expected access token not to be accepted as refresh token")
+		}
+
+		rotated, err := issuer.Refresh(pair.RefreshToken)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if rotated.RefreshToken == pair.RefreshToken {
+			t.Fatalf("Expected refresh token to be rotated")
+		}
+
+		if claims, err := issuer.Verify(rotated.AccessToken); err != nil || claims.Role != "admin" {
+			t.Fatalf("Expected rotated access token to keep claims but got (%v)", err)
+		}
+
+		if _, err := issuer.Refresh(pair.RefreshToken); err != ErrTokenReused {
+			t.Fatalf("Expected reused refresh token error to be (%v) but got (%v)", ErrTokenReused, err)
+		}
+
+		if _, err := issuer.Refresh(rotated.RefreshToken); err != ErrTokenRevoked {
+			t.Fatalf("Expected token family to be revoked after reuse but got (%v)", err)
+		}
+
+		if _, err := issuer.Verify(rotated.AccessToken); err != ErrTokenRevoked {
+			t.Fatalf("Expected access token of revoked family to be rejected but got (%v)", err)
+		}
+	})
+
+	t.Run("TestRevoke", func(t *testing.T) {
+		pair, _ := issuer.Issue(claims("1"))
+
+		if err := issuer.Revoke(pair.AccessToken); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := issuer.Verify(pair.AccessToken); err != ErrTokenRevoked {
+			t.Fatalf("Expected revoked token error to be (%v) but got (%v)", ErrTokenRevoked, err)
+		}
+
+		if _, err := issuer.Refresh(pair.RefreshToken); err != nil {
+			t.Fatalf("Expected refresh token to stay valid but got (%v)", err)
+		}
+	})
+
+	t.Run("TestJwks", func(t *testing.T) {
+		key, _ := jwt.GenerateKey("2025-01", jwt.ES256)
+
+		issuer := NewJwt[ApiClaims](JwtConfig{Keys: jwt.NewKeySet(key), Denylist: NewCacheDenylist(cache.NewMemoryStore())})
+
+		server := http.Server("127.0.0.1", 0)
+
+		Jwks(server.Route(), issuer.Keys())
+
+		res := htesting.NewTestCase(t, server, false).Request().Get("/" + jwt.JWKS_PATH)
+
+		res.AssertOk().AssertHeader("content-type", "application/json")
+
+		body, _ := io.ReadAll(res.Response.Body)
+
+		keys, err := jwt.ParseJwks(body)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pair, _ := issuer.Issue(claims("1"))
+
+		if err := keys.Verify(pair.AccessToken, &ApiClaims{}, jwt.Options{}); err != nil {
+			t.Fatalf("Expected published jwks to verify access token but got (%v)", err)
+		}
+
+		verifier := NewJwt[ApiClaims](JwtConfig{Verifier: keys, Denylist: NewCacheDenylist(cache.NewMemoryStore())})
+
+		if claims, err := verifier.Verify(pair.AccessToken); err != nil || claims.Role != "admin" {
+			t.Fatalf("Expected verifier to accept access token but got (%v)", err)
+		}
+	})
+
+	t.Run("TestDatabaseDenylist", func(t *testing.T) {
+		orm.DB.Add("jwt", sqlite.Connect(":memory:"))
+
+		denylist := NewDatabaseDenylist("jwt")
+
+		if err := denylist.Migrate(); err != nil {
+			t.Fatalf("Something went wrong when trying to migrate denylist: %v", err)
+		}
+
+		issuer := NewJwt[ApiClaims](JwtConfig{Secret: []byte("jwt-secret-at-least-thirty-two-bytes"), Denylist: denylist})
+		pair, _ := issuer.Issue(claims("1"))
+
+		if err := issuer.Revoke(pair.AccessToken); err != nil {
+			t.Fatalf("Something went wrong when trying to revoke token: %v", err)
+		}
+
+		// A flushed response cache must not bring revoked tokens back.
+		cache.Flush()
+
+		if _, err := issuer.Verify(pair.AccessToken); err != ErrTokenRevoked {
+			t.Fatalf("Expected error to be (%v) but got (%v)", ErrTokenRevoked, err)
+		}
+
+		if err := denylist.Revoke("expired", time.Now().Add(-time.Minute)); err != nil || denylist.Revoked("expired") {
+			t.Fatalf("Expected expired revocation to be ignored")
+		}
+
+		if err := denylist.Revoke("expired", time.Now().Add(time.Minute)); err != nil || !denylist.Revoked("expired") {
+			t.Fatalf("Expected revocation to be extended")
+		}
+
+		if err := denylist.Gc(); err != nil || !denylist.Revoked("expired") {
+			t.Fatalf("Expected gc to keep active revocations")
+		}
+
+		// Two issuers sharing the denylist act as two replicas.
+		replicas := []*Jwt[ApiClaims, *ApiClaims]{issuer, NewJwt[ApiClaims](JwtConfig{Secret: []byte("jwt-secret-at-least-thirty-two-bytes"), Denylist: NewDatabaseDenylist("jwt")})}
+		pair, _ = issuer.Issue(claims("1"))
+
+		var group sync.WaitGroup
+		var redeemed atomic.Int64
+
+		for i := 0; i < 10; i++ {
+			group.Add(1)
+
+			go func(issuer *Jwt[ApiClaims, *ApiClaims]) {
+				defer group.Done()
+
+				if _, err := issuer.Refresh(pair.RefreshToken); err == nil {
+					redeemed.Add(1)
+				}
+			}(replicas[i%len(replicas)])
+		}
+
+		group.Wait()
+
+		if redeemed.Load() != 1 {
+			t.Fatalf("Expected refresh token to be redeemed (%d) times but got (%d)", 1, redeemed.Load())
+		}
+	})
+
+	t.Run("TestDenylistRequired", func(t *testing.T) {
+		defer func() {
+			if err := recover(); err != ErrDenylistRequired {
+				t.Fatalf("Expected panic (%v) but got (%v)", ErrDenylistRequired, err)
+			}
+		}()
+
+		NewJwt[ApiClaims](JwtConfig{Secret: []byte("jwt-secret-at-least-thirty-two-bytes")})
+	})
+
+	t.Run("TestSecretTooShort", func(t *testing.T) {
+		secrets := map[string][]byte{"empty": nil, "short": []byte("jwt-secret")}
+
+		for name, secret := range secrets {
+			func() {
+				defer func() {
+					if err := recover(); err != ErrSecretTooShort {
+						t.Fatalf("Expected (%s) secret panic to be (%v) but got (%v)", name, ErrSecretTooShort, err)
+					}
+				}()
+
+				NewJwt[ApiClaims](JwtConfig{Secret: secret, Denylist: NewCacheDenylist(cache.NewMemoryStore())})
+			}()
+		}
+
+		verifier := NewJwt[ApiClaims](JwtConfig{Verifier: jwt.NewKeySet(), Denylist: NewCacheDenylist(cache.NewMemoryStore())})
+
+		if _, err := verifier.Issue(claims("1")); err != ErrSigningKey {
+			t.Fatalf("Expected verifier issue error to be (%v) but got (%v)", ErrSigningKey, err)
+		}
+	})
+}
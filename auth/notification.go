@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/mail"
+	"github.com/lucas11776-golang/http/utils/env"
+)
+
+//go:embed views/*.html
+var views embed.FS
+
+type Mailer func(to string, subject string, body string) error
+
+// Comment
+func DefaultMailer(to string, subject string, body string) error {
+	return mail.NewMail().From(env.Env("MAIL_FROM_ADDRESS")).To(to).Subject(subject).SendHtml(body)
+}
+
+// Comment
+func DefaultView() *http.View {
+	sub, err := fs.Sub(views, "views")
+
+	if err != nil {
+		panic(err)
+	}
+
+	return http.NewView(sub, "html")
+}
+
+// Comment
+func notify(req *http.Request, view *http.View, mailer Mailer, to string, subject string, name string, data http.ViewData) error {
+	body, err := view.Read(name, data, req)
+
+	if err != nil {
+		return err
+	}
+
+	return mailer(to, subject, string(body))
+}
@@ -0,0 +1,527 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	h "net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/encryption/jwt"
+)
+
+const (
+	OAUTH_STATE_KEY     = "oauth_state"
+	OAUTH_VERIFIER_KEY  = "oauth_code_verifier"
+	OAUTH_NONCE_KEY     = "oauth_nonce"
+	OIDC_DISCOVERY_PATH = ".well-known/openid-configuration"
+	OIDC_SCOPE          = "openid"
+	OAUTH_MAX_RESPONSE  = 1024 * 1024
+)
+
+var (
+	ErrInvalidState        = errors.New("oauth state is invalid")
+	ErrMissingCode         = errors.New("oauth authorization code is missing")
+	ErrInvalidNonce        = errors.New("id token nonce is invalid")
+	ErrMissingIdToken      = errors.New("oauth token response has no id token")
+	ErrInvalidIssuer       = errors.New("discovery issuer does not match")
+	ErrSubjectMismatch     = errors.New("user info subject does not match id token")
+	ErrUserMapperNotFound  = errors.New("oauth user mapper is not defined")
+	ErrUserInfoUnavailable = errors.New("oauth provider has no id token or user info endpoint")
+)
+
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+type OAuthConfig struct {
+	ClientId              string
+	ClientSecret          string
+	RedirectUrl           string
+	Scopes                []string
+	Issuer                string
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	UserInfoEndpoint      string
+	JwksUri               string
+	Leeway                time.Duration
+}
+
+type Discovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	UserInfoEndpoint      string   `json:"userinfo_endpoint,omitempty"`
+	JwksUri               string   `json:"jwks_uri"`
+	ScopesSupported       []string `json:"scopes_supported,omitempty"`
+	ResponseTypes         []string `json:"response_types_supported,omitempty"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported,omitempty"`
+	IntrospectionEndpoint string   `json:"introspection_endpoint,omitempty"`
+	RevocationEndpoint    string   `json:"revocation_endpoint,omitempty"`
+}
+
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	IdToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type IdTokenClaims struct {
+	gojwt.RegisteredClaims
+	Nonce         string `json:"nonce,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Picture       string `json:"picture,omitempty"`
+}
+
+type OAuthUser struct {
+	Id     string
+	Email  string
+	Name   string
+	Avatar string
+	Token  *OAuthToken
+	Claims map[string]interface{}
+}
+
+type OAuthUserMapper func(user *OAuthUser) (Authenticatable, error)
+
+type OAuthProvider struct {
+	config OAuthConfig
+	client *h.Client
+	keys   jwt.Verifier
+	mapper OAuthUserMapper
+}
+
+// Comment
+func (ctx *OAuthError) Error() string {
+	if ctx.Description == "" {
+		return "oauth: " + ctx.Code
+	}
+
+	return "oauth: " + ctx.Code + ": " + ctx.Description
+}
+
+// Comment
+func Discover(client *h.Client, issuer string) (*Discovery, error) {
+	issuer = strings.TrimRight(issuer, "/")
+	discovery := &Discovery{}
+
+	if err := oauthGet(client, issuer+"/"+OIDC_DISCOVERY_PATH, "", discovery); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimRight(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: expected %s but got %s", ErrInvalidIssuer, issuer, discovery.Issuer)
+	}
+
+	return discovery, nil
+}
+
+// Comment
+func NewOAuthProvider(config OAuthConfig) *OAuthProvider {
+	provider := &OAuthProvider{config: config, client: h.DefaultClient}
+
+	if config.JwksUri != "" {
+		provider.keys = jwt.NewRemoteKeySet(config.JwksUri, 0)
+	}
+
+	return provider
+}
+
+// Comment
+func NewOidcProvider(issuer string, config OAuthConfig, client ...*h.Client) (*OAuthProvider, error) {
+	c := h.DefaultClient
+
+	if len(client) != 0 {
+		c = client[0]
+	}
+
+	discovery, err := Discover(c, issuer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.Issuer = discovery.Issuer
+	config.AuthorizationEndpoint = discovery.AuthorizationEndpoint
+	config.TokenEndpoint = discovery.TokenEndpoint
+	config.UserInfoEndpoint = discovery.UserInfoEndpoint
+	config.JwksUri = discovery.JwksUri
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{OIDC_SCOPE, "profile", "email"}
+	}
+
+	return NewOAuthProvider(config).Client(c), nil
+}
+
+// Comment
+func (ctx *OAuthProvider) Client(client *h.Client) *OAuthProvider {
+	ctx.client = client
+
+	if keys, ok := ctx.keys.(*jwt.RemoteKeySet); ok {
+		keys.Client(client)
+	}
+
+	return ctx
+}
+
+// Comment
+func (ctx *OAuthProvider) Keys(keys jwt.Verifier) *OAuthProvider {
+	ctx.keys = keys
+
+	return ctx
+}
+
+// Comment
+func (ctx *OAuthProvider) Map(mapper OAuthUserMapper) *OAuthProvider {
+	ctx.mapper = mapper
+
+	return ctx
+}
+
+// Comment
+func (ctx *OAuthProvider) Config() OAuthConfig {
+	return ctx.config
+}
+
+// Comment
+func (ctx *OAuthProvider) openid() bool {
+	for _, scope := range ctx.config.Scopes {
+		if scope == OIDC_SCOPE {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Comment
+func randomString(size int) string {
+	raw := make([]byte, size)
+
+	if _, err := rand.Read(raw); err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Comment
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Comment
+func (ctx *OAuthProvider) AuthorizationUrl(req *http.Request) string {
+	state, verifier := randomString(32), randomString(48)
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {ctx.config.ClientId},
+		"redirect_uri":          {ctx.config.RedirectUrl},
+		"scope":                 {strings.Join(ctx.config.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	req.Session.Set(OAUTH_STATE_KEY, state).Set(OAUTH_VERIFIER_KEY, verifier).Remove(OAUTH_NONCE_KEY)
+
+	if ctx.openid() {
+		nonce := randomString(32)
+
+		query.Set("nonce", nonce)
+		req.Session.Set(OAUTH_NONCE_KEY, nonce)
+	}
+
+	separator := "?"
+
+	if strings.Contains(ctx.config.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return ctx.config.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// Comment
+func (ctx *OAuthProvider) Redirect(req *http.Request, res *http.Response) *http.Response {
+	return res.Redirect(ctx.AuthorizationUrl(req))
+}
+
+// Comment
+func (ctx *OAuthProvider) User(req *http.Request) (*OAuthUser, error) {
+	if code := req.GetQuery("error"); code != "" {
+		return nil, &OAuthError{Code: code, Description: req.GetQuery("error_description")}
+	}
+
+	state, verifier, nonce := req.Session.Get(OAUTH_STATE_KEY), req.Session.Get(OAUTH_VERIFIER_KEY), req.Session.Get(OAUTH_NONCE_KEY)
+
+	// The state, verifier and nonce can only be used once.
+	req.Session.Remove(OAUTH_STATE_KEY).Remove(OAUTH_VERIFIER_KEY).Remove(OAUTH_NONCE_KEY)
+
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(req.GetQuery("state"))) != 1 {
+		return nil, ErrInvalidState
+	}
+
+	code := req.GetQuery("code")
+
+	if code == "" {
+		return nil, ErrMissingCode
+	}
+
+	token, err := ctx.exchange(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {ctx.config.RedirectUrl},
+		"code_verifier": {verifier},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.UserFromToken(token, nonce)
+}
+
+// Comment
+func (ctx *OAuthProvider) Refresh(refreshToken string) (*OAuthToken, error) {
+	return ctx.exchange(url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+// Comment
+func (ctx *OAuthProvider) exchange(form url.Values) (*OAuthToken, error) {
+	form.Set("client_id", ctx.config.ClientId)
+
+	r, err := h.NewRequest(h.MethodPost, ctx.config.TokenEndpoint, strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Accept", "application/json")
+
+	if ctx.config.ClientSecret != "" {
+		r.SetBasicAuth(url.QueryEscape(ctx.config.ClientId), url.QueryEscape(ctx.config.ClientSecret))
+	}
+
+	token := &OAuthToken{}
+
+	if err := oauthDo(ctx.client, r, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Comment
+func (ctx *OAuthProvider) UserFromToken(token *OAuthToken, nonce string) (*OAuthUser, error) {
+	user := &OAuthUser{Token: token, Claims: map[string]interface{}{}}
+
+	if token.IdToken != "" {
+		claims, err := ctx.VerifyIdToken(token.IdToken, nonce)
+
+		if err != nil {
+			return nil, err
+		}
+
+		user.Id, user.Email, user.Name, user.Avatar = claims.Subject, claims.Email, claims.Name, claims.Picture
+
+		if err := ctx.claims(token.IdToken, user.Claims); err != nil {
+			return nil, err
+		}
+	} else if ctx.openid() {
+		return nil, ErrMissingIdToken
+	}
+
+	if ctx.config.UserInfoEndpoint == "" {
+		if token.IdToken == "" {
+			return nil, ErrUserInfoUnavailable
+		}
+
+		return user, nil
+	}
+
+	info := map[string]interface{}{}
+
+	if err := oauthGet(ctx.client, ctx.config.UserInfoEndpoint, token.AccessToken, &info); err != nil {
+		return nil, err
+	}
+
+	subject := claimString(info, "sub", "id")
+
+	if user.Id != "" && subject != user.Id {
+		return nil, ErrSubjectMismatch
+	}
+
+	for key, value := range info {
+		user.Claims[key] = value
+	}
+
+	user.Id = subject
+	user.Email = firstString(claimString(info, "email"), user.Email)
+	user.Name = firstString(claimString(info, "name", "login"), user.Name)
+	user.Avatar = firstString(claimString(info, "picture", "avatar_url"), user.Avatar)
+
+	return user, nil
+}
+
+// Comment
+func (ctx *OAuthProvider) VerifyIdToken(token string, nonce string) (*IdTokenClaims, error) {
+	claims := &IdTokenClaims{}
+
+	if ctx.keys == nil {
+		return nil, jwt.ErrKeyNotFound
+	}
+
+	err := ctx.keys.Verify(token, claims, jwt.Options{
+		Issuer:   ctx.config.Issuer,
+		Audience: []string{ctx.config.ClientId},
+		Leeway:   ctx.config.Leeway,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if nonce != "" && subtle.ConstantTimeCompare([]byte(nonce), []byte(claims.Nonce)) != 1 {
+		return nil, ErrInvalidNonce
+	}
+
+	return claims, nil
+}
+
+// Comment
+func (ctx *OAuthProvider) claims(token string, claims map[string]interface{}) error {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return gojwt.ErrTokenMalformed
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(payload, &claims)
+}
+
+// Comment
+func (ctx *OAuthProvider) Login(req *http.Request, guard ...string) (Authenticatable, error) {
+	if ctx.mapper == nil {
+		return nil, ErrUserMapperNotFound
+	}
+
+	info, err := ctx.User(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := ctx.mapper(info)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	Auth(req).Guard(guard...).Login(user)
+
+	return user, nil
+}
+
+// Comment
+func claimString(claims map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch value := claims[key].(type) {
+		case string:
+			return value
+		case float64:
+			return fmt.Sprintf("%.0f", value)
+		}
+	}
+
+	return ""
+}
+
+// Comment
+func firstString(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// Comment
+func oauthGet(client *h.Client, endpoint string, token string, value interface{}) error {
+	r, err := h.NewRequest(h.MethodGet, endpoint, nil)
+
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set("Accept", "application/json")
+
+	if token != "" {
+		r.Header.Set("Authorization", JWT_TOKEN_TYPE+" "+token)
+	}
+
+	return oauthDo(client, r, value)
+}
+
+// Comment
+func oauthDo(client *h.Client, r *h.Request, value interface{}) error {
+	res, err := client.Do(r)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, OAUTH_MAX_RESPONSE))
+
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		e := &OAuthError{}
+
+		if json.Unmarshal(body, e) == nil && e.Code != "" {
+			return e
+		}
+
+		return fmt.Errorf("oauth: %s responded with status %d", r.URL.Redacted(), res.StatusCode)
+	}
+
+	return json.Unmarshal(body, value)
+}
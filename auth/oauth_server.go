@@ -0,0 +1,979 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/utils/database"
+	"github.com/lucas11776-golang/orm"
+	"github.com/spf13/cast"
+)
+
+const (
+	OAUTH_CLIENTS_TABLE        = "oauth_clients"
+	OAUTH_AUTH_CODES_TABLE     = "oauth_auth_codes"
+	OAUTH_ACCESS_TOKENS_TABLE  = "oauth_access_tokens"
+	OAUTH_REFRESH_TOKENS_TABLE = "oauth_refresh_tokens"
+	OAUTH_CONSENT_VIEW         = "authorize"
+	OAUTH_CODE_TTL             = time.Minute * 10
+	OAUTH_ACCESS_TTL           = time.Hour
+	OAUTH_REFRESH_TTL          = time.Hour * 24 * 30
+	REQUEST_OAUTH_KEY          = "oauth"
+)
+
+const (
+	GRANT_AUTHORIZATION_CODE = "authorization_code"
+	GRANT_CLIENT_CREDENTIALS = "client_credentials"
+	GRANT_REFRESH_TOKEN      = "refresh_token"
+)
+
+const (
+	OAUTH_INVALID_REQUEST        = "invalid_request"
+	OAUTH_INVALID_CLIENT         = "invalid_client"
+	OAUTH_INVALID_GRANT          = "invalid_grant"
+	OAUTH_INVALID_SCOPE          = "invalid_scope"
+	OAUTH_UNAUTHORIZED_CLIENT    = "unauthorized_client"
+	OAUTH_UNSUPPORTED_GRANT      = "unsupported_grant_type"
+	OAUTH_UNSUPPORTED_RESPONSE   = "unsupported_response_type"
+	OAUTH_ACCESS_DENIED          = "access_denied"
+	OAUTH_INSUFFICIENT_SCOPE     = "insufficient_scope"
+	OAUTH_UNSUPPORTED_TOKEN_TYPE = "unsupported_token_type"
+)
+
+const OAuthCsrfMismatchMessage = "CSRF token mismatch."
+
+var ErrOAuthClientNotFound = errors.New("oauth client not found")
+
+type OAuthClientRecord struct {
+	Table        string `table:"oauth_clients"`
+	ID           int64  `column:"id" type:"primary_key"`
+	ClientId     string `column:"client_id" type:"string"`
+	Name         string `column:"name" type:"string"`
+	Secret       string `column:"secret" type:"string"`
+	RedirectUris string `column:"redirect_uris" type:"text"`
+	Grants       string `column:"grants" type:"string"`
+	Scopes       string `column:"scopes" type:"text"`
+	Revoked      int64  `column:"revoked" type:"integer"`
+	CreatedAt    int64  `column:"created_at" type:"integer"`
+}
+
+type OAuthAuthCodeRecord struct {
+	Table       string `table:"oauth_auth_codes"`
+	ID          int64  `column:"id" type:"primary_key"`
+	Code        string `column:"code" type:"string"`
+	ClientId    string `column:"client_id" type:"string"`
+	UserId      string `column:"user_id" type:"string"`
+	Scopes      string `column:"scopes" type:"text"`
+	RedirectUri string `column:"redirect_uri" type:"text"`
+	Challenge   string `column:"challenge" type:"string"`
+	Revoked     int64  `column:"revoked" type:"integer"`
+	ExpiresAt   int64  `column:"expires_at" type:"integer"`
+}
+
+type OAuthAccessTokenRecord struct {
+	Table     string `table:"oauth_access_tokens"`
+	ID        int64  `column:"id" type:"primary_key"`
+	Token     string `column:"token" type:"string"`
+	ClientId  string `column:"client_id" type:"string"`
+	UserId    string `column:"user_id" type:"string"`
+	Scopes    string `column:"scopes" type:"text"`
+	Revoked   int64  `column:"revoked" type:"integer"`
+	CreatedAt int64  `column:"created_at" type:"integer"`
+	ExpiresAt int64  `column:"expires_at" type:"integer"`
+}
+
+type OAuthRefreshTokenRecord struct {
+	Table       string `table:"oauth_refresh_tokens"`
+	ID          int64  `column:"id" type:"primary_key"`
+	Token       string `column:"token" type:"string"`
+	AccessToken string `column:"access_token" type:"string"`
+	ClientId    string `column:"client_id" type:"string"`
+	UserId      string `column:"user_id" type:"string"`
+	Scopes      string `column:"scopes" type:"text"`
+	Revoked     int64  `column:"revoked" type:"integer"`
+	ExpiresAt   int64  `column:"expires_at" type:"integer"`
+}
+
+type OAuthClient struct {
+	Id           string
+	Name         string
+	RedirectUris []string
+	Grants       []string
+	Scopes       []string
+	Confidential bool
+	Revoked      bool
+	secret       string
+}
+
+type OAuthScope struct {
+	Name        string
+	Description string
+}
+
+type OAuthAccessToken struct {
+	ClientId  string
+	UserId    string
+	Scopes    []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type OAuthServer struct {
+	connection string
+	prefix     string
+	scopes     []OAuthScope
+	view       *http.View
+	codeTtl    time.Duration
+	accessTtl  time.Duration
+	refreshTtl time.Duration
+}
+
+type oauthError struct {
+	status      http.Status
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// Comment
+func (ctx *oauthError) Error() string {
+	return ctx.Code + ": " + ctx.Description
+}
+
+// Comment
+func newOAuthError(status http.Status, code string, description string) *oauthError {
+	return &oauthError{status: status, Code: code, Description: description}
+}
+
+// Comment
+func NewOAuthServer(connection string) *OAuthServer {
+	return &OAuthServer{
+		connection: connection,
+		prefix:     "oauth",
+		scopes:     []OAuthScope{},
+		view:       DefaultView(),
+		codeTtl:    OAUTH_CODE_TTL,
+		accessTtl:  OAUTH_ACCESS_TTL,
+		refreshTtl: OAUTH_REFRESH_TTL,
+	}
+}
+
+// Comment
+func (ctx *OAuthServer) Scope(name string, description string) *OAuthServer {
+	ctx.scopes = append(ctx.scopes, OAuthScope{Name: name, Description: description})
+
+	return ctx
+}
+
+// Comment
+func (ctx *OAuthServer) View(view *http.View) *OAuthServer {
+	ctx.view = view
+
+	return ctx
+}
+
+// Comment
+func (ctx *OAuthServer) Prefix(prefix string) *OAuthServer {
+	ctx.prefix = strings.Trim(prefix, "/")
+
+	return ctx
+}
+
+// Comment
+func (ctx *OAuthServer) CodeTtl(ttl time.Duration) *OAuthServer {
+	ctx.codeTtl = ttl
+
+	return ctx
+}
+
+// Comment
+func (ctx *OAuthServer) AccessTtl(ttl time.Duration) *OAuthServer {
+	ctx.accessTtl = ttl
+
+	return ctx
+}
+
+// Comment
+func (ctx *OAuthServer) RefreshTtl(ttl time.Duration) *OAuthServer {
+	ctx.refreshTtl = ttl
+
+	return ctx
+}
+
+// Comment
+func (ctx *OAuthServer) database() (orm.Database, error) {
+	db := orm.DB.Database(ctx.connection)
+
+	if db == nil {
+		return nil, fmt.Errorf("database connection %s does not exists", ctx.connection)
+	}
+
+	return db, nil
+}
+
+// Comment
+func (ctx *OAuthServer) Migrate() error {
+	db, err := ctx.database()
+
+	if err != nil {
+		return err
+	}
+
+	return db.Migration().Migrate(orm.Models{
+		OAuthClientRecord{},
+		OAuthAuthCodeRecord{},
+		OAuthAccessTokenRecord{},
+		OAuthRefreshTokenRecord{},
+	})
+}
+
+// Comment
+func (ctx *OAuthServer) first(table string, column string, value string) (orm.Result, error) {
+	db, err := ctx.database()
+
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := db.Query(&orm.Statement{
+		Table: table,
+		Where: []interface{}{&orm.Where{Key: column, Operator: orm.EQUALS, Value: value}},
+		Limit: 1,
+	})
+
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+
+	return results[0], nil
+}
+
+// Comment
+func (ctx *OAuthServer) insert(table string, values orm.Values) error {
+	db, err := ctx.database()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Insert(&orm.Statement{Table: table, Values: values, PrimaryKey: "id"})
+
+	return err
+}
+
+// Comment
+func (ctx *OAuthServer) update(table string, column string, value string, values orm.Values) error {
+	db, err := ctx.database()
+
+	if err != nil {
+		return err
+	}
+
+	return db.Update(&orm.Statement{
+		Table:  table,
+		Where:  []interface{}{&orm.Where{Key: column, Operator: orm.EQUALS, Value: value}},
+		Values: values,
+	})
+}
+
+// Comment
+func (ctx *OAuthServer) consume(table string, column string, value string) (bool, error) {
+	affected, err := database.Update(ctx.connection, table, orm.Values{"revoked": 1},
+		&orm.Where{Key: column, Operator: orm.EQUALS, Value: value},
+		&orm.Where{Key: "revoked", Operator: orm.EQUALS, Value: 0},
+	)
+
+	return affected == 1, err
+}
+
+// Comment
+func (ctx *OAuthServer) revokeGrant(client string, user string) error {
+	for _, table := range []string{OAUTH_ACCESS_TOKENS_TABLE, OAUTH_REFRESH_TOKENS_TABLE} {
+		_, err := database.Update(ctx.connection, table, orm.Values{"revoked": 1},
+			&orm.Where{Key: "client_id", Operator: orm.EQUALS, Value: client},
+			&orm.Where{Key: "user_id", Operator: orm.EQUALS, Value: user},
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Comment
+func (ctx *OAuthServer) Gc() error {
+	db, err := ctx.database()
+
+	if err != nil {
+		return err
+	}
+
+	for _, table := range []string{OAUTH_AUTH_CODES_TABLE, OAUTH_ACCESS_TOKENS_TABLE, OAUTH_REFRESH_TOKENS_TABLE} {
+		err := db.Delete(&orm.Statement{
+			Table: table,
+			Where: []interface{}{&orm.Where{Key: "expires_at", Operator: orm.LESS_THEN_EQUALS, Value: time.Now().Unix()}},
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Comment
+func (ctx *OAuthServer) CreateClient(name string, redirectUris []string, confidential bool, grants ...string) (*OAuthClient, string, error) {
+	if len(grants) == 0 {
+		grants = []string{GRANT_AUTHORIZATION_CODE, GRANT_REFRESH_TOKEN}
+	}
+
+	id, _ := NewToken()
+	secret, hashed := "", ""
+
+	if confidential {
+		secret, hashed = NewToken()
+	}
+
+	client := &OAuthClient{
+		Id:           id[:32],
+		Name:         name,
+		RedirectUris: redirectUris,
+		Grants:       grants,
+		Scopes:       []string{},
+		Confidential: confidential,
+		secret:       hashed,
+	}
+
+	for _, scope := range ctx.scopes {
+		client.Scopes = append(client.Scopes, scope.Name)
+	}
+
+	err := ctx.insert(OAUTH_CLIENTS_TABLE, orm.Values{
+		"client_id":     client.Id,
+		"name":          client.Name,
+		"secret":        hashed,
+		"redirect_uris": strings.Join(redirectUris, " "),
+		"grants":        strings.Join(grants, " "),
+		"scopes":        strings.Join(client.Scopes, " "),
+		"revoked":       0,
+		"created_at":    time.Now().Unix(),
+	})
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return client, secret, nil
+}
+
+// Comment
+func (ctx *OAuthServer) Client(id string) (*OAuthClient, error) {
+	record, err := ctx.first(OAUTH_CLIENTS_TABLE, "client_id", id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if record == nil {
+		return nil, ErrOAuthClientNotFound
+	}
+
+	return &OAuthClient{
+		Id:           cast.ToString(record["client_id"]),
+		Name:         cast.ToString(record["name"]),
+		RedirectUris: strings.Fields(cast.ToString(record["redirect_uris"])),
+		Grants:       strings.Fields(cast.ToString(record["grants"])),
+		Scopes:       strings.Fields(cast.ToString(record["scopes"])),
+		Confidential: cast.ToString(record["secret"]) != "",
+		Revoked:      cast.ToInt64(record["revoked"]) != 0,
+		secret:       cast.ToString(record["secret"]),
+	}, nil
+}
+
+// Comment
+func (ctx *OAuthServer) RevokeClient(id string) error {
+	return ctx.update(OAUTH_CLIENTS_TABLE, "client_id", id, orm.Values{"revoked": 1})
+}
+
+// Comment
+func (ctx *OAuthClient) HasGrant(grant string) bool {
+	return slices.Contains(ctx.Grants, grant)
+}
+
+// Comment
+func (ctx *OAuthClient) ValidSecret(secret string) bool {
+	return ctx.Confidential && subtle.ConstantTimeCompare([]byte(ctx.secret), []byte(HashToken(secret))) == 1
+}
+
+// Comment
+func (ctx *OAuthAccessToken) Can(scope string) bool {
+	return slices.Contains(ctx.Scopes, scope) || slices.Contains(ctx.Scopes, "*")
+}
+
+// Comment
+func (ctx *OAuthServer) Routes(router *http.Router) *OAuthServer {
+	router.Get(ctx.prefix+"/authorize", ctx.authorize)
+	router.Post(ctx.prefix+"/authorize", ctx.approve)
+	router.Post(ctx.prefix+"/token", ctx.token)
+	router.Post(ctx.prefix+"/introspect", ctx.introspect)
+	router.Post(ctx.prefix+"/revoke", ctx.revoke)
+
+	return ctx
+}
+
+// Comment
+func input(req *http.Request, key string) string {
+	if req.Form != nil && req.Form.Has(key) {
+		return req.Form.Get(key)
+	}
+
+	return req.GetQuery(key)
+}
+
+// Comment
+func oauthErrorResponse(res *http.Response, err error) *http.Response {
+	e, ok := err.(*oauthError)
+
+	if !ok {
+		return res.Error(err)
+	}
+
+	if e.Code == OAUTH_INVALID_CLIENT {
+		res.SetHeader("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+
+	return res.SetStatus(e.status).SetHeader("Cache-Control", "no-store").Json(e)
+}
+
+// Comment
+func (ctx *OAuthServer) scopeList(requested string, allowed []string) ([]string, error) {
+	scopes := strings.Fields(requested)
+
+	for _, scope := range scopes {
+		defined := slices.ContainsFunc(ctx.scopes, func(s OAuthScope) bool { return s.Name == scope })
+
+		if !defined || !slices.Contains(allowed, scope) {
+			return nil, newOAuthError(http.HTTP_RESPONSE_BAD_REQUEST, OAUTH_INVALID_SCOPE, "scope "+scope+" is not allowed")
+		}
+	}
+
+	return scopes, nil
+}
+
+// Comment
+func (ctx *OAuthServer) authorizationRequest(req *http.Request) (*OAuthClient, string, []string, error) {
+	client, err := ctx.Client(input(req, "client_id"))
+
+	if err != nil || client.Revoked || !client.HasGrant(GRANT_AUTHORIZATION_CODE) {
+		return nil, "", nil, newOAuthError(http.HTTP_RESPONSE_BAD_REQUEST, OAUTH_INVALID_CLIENT, "client is invalid")
+	}
+
+	redirect := input(req, "redirect_uri")
+
+	if redirect == "" && len(client.RedirectUris) == 1 {
+		redirect = client.RedirectUris[0]
+	}
+
+	// Redirect uris must match exactly, errors are only redirected to registered uris.
+	if !slices.Contains(client.RedirectUris, redirect) {
+		return nil, "", nil, newOAuthError(http.HTTP_RESPONSE_BAD_REQUEST, OAUTH_INVALID_REQUEST, "redirect uri is invalid")
+	}
+
+	if input(req, "response_type") != "code" {
+		return client, redirect, nil, newOAuthError(http.HTTP_RESPONSE_BAD_REQUEST, OAUTH_UNSUPPORTED_RESPONSE, "response type must be code")
+	}
+
+	if input(req, "code_challenge") == "" || input(req, "code_challenge_method") != "S256" {
+		return client, redirect, nil, newOAuthError(http.HTTP_RESPONSE_BAD_REQUEST, OAUTH_INVALID_REQUEST, "code challenge with method S256 is required")
+	}
+
+	scopes, err := ctx.scopeList(input(req, "scope"), client.Scopes)
+
+	if err != nil {
+		return client, redirect, nil, err
+	}
+
+	return client, redirect, scopes, nil
+}
+
+// Comment
+func redirectWith(res *http.Response, redirect string, values url.Values) *http.Response {
+	separator := "?"
+
+	if strings.Contains(redirect, "?") {
+		separator = "&"
+	}
+
+	return res.Redirect(redirect + separator + values.Encode())
+}
+
+// Comment
+func (ctx *OAuthServer) authorizationError(req *http.Request, res *http.Response, redirect string, err error) *http.Response {
+	e, ok := err.(*oauthError)
+
+	if !ok || redirect == "" {
+		if ok {
+			return res.Error(http.BadRequest(e.Description))
+		}
+
+		return res.Error(err)
+	}
+
+	values := url.Values{"error": {e.Code}, "error_description": {e.Description}}
+
+	if state := input(req, "state"); state != "" {
+		values.Set("state", state)
+	}
+
+	return redirectWith(res, redirect, values)
+}
+
+// Comment
+func (ctx *OAuthServer) authorize(req *http.Request, res *http.Response) *http.Response {
+	if Auth(req).Guest() {
+		return unauthenticated(req, res)
+	}
+
+	client, redirect, scopes, err := ctx.authorizationRequest(req)
+
+	if err != nil {
+		return ctx.authorizationError(req, res, redirect, err)
+	}
+
+	described := []OAuthScope{}
+
+	for _, scope := range ctx.scopes {
+		if slices.Contains(scopes, scope.Name) {
+			described = append(described, scope)
+		}
+	}
+
+	html, err := ctx.view.Read(OAUTH_CONSENT_VIEW, http.ViewData{
+		"action":                "/" + ctx.prefix + "/authorize",
+		"client":                client.Name,
+		"client_id":             client.Id,
+		"redirect_uri":          redirect,
+		"scope":                 strings.Join(scopes, " "),
+		"scopes":                &described,
+		"state":                 input(req, "state"),
+		"code_challenge":        input(req, "code_challenge"),
+		"code_challenge_method": input(req, "code_challenge_method"),
+	}, req)
+
+	if err != nil {
+		return res.Error(err)
+	}
+
+	return res.Html(string(html))
+}
+
+// Comment
+func (ctx *OAuthServer) approve(req *http.Request, res *http.Response) *http.Response {
+	if Auth(req).Guest() {
+		return unauthenticated(req, res)
+	}
+
+	// The token routes are used by clients without a session, so the consent form checks its own token.
+	if !http.ValidCsrfToken(req.Session.CsrfToken(), input(req, http.CSRF_INPUT_NAME)) {
+		return res.Error(http.NewHttpError(http.HTTP_RESPONSE_PAGE_EXPIRED, OAuthCsrfMismatchMessage))
+	}
+
+	client, redirect, scopes, err := ctx.authorizationRequest(req)
+
+	if err != nil {
+		return ctx.authorizationError(req, res, redirect, err)
+	}
+
+	if input(req, "approve") == "" {
+		return ctx.authorizationError(req, res, redirect, newOAuthError(http.HTTP_RESPONSE_FORBIDDEN, OAUTH_ACCESS_DENIED, "the user denied the request"))
+	}
+
+	code, hashed := NewToken()
+
+	err = ctx.insert(OAUTH_AUTH_CODES_TABLE, orm.Values{
+		"code":         hashed,
+		"client_id":    client.Id,
+		"user_id":      Auth(req).Id(),
+		"scopes":       strings.Join(scopes, " "),
+		"redirect_uri": redirect,
+		"challenge":    input(req, "code_challenge"),
+		"revoked":      0,
+		"expires_at":   time.Now().Add(ctx.codeTtl).Unix(),
+	})
+
+	if err != nil {
+		return res.Error(err)
+	}
+
+	values := url.Values{"code": {code}}
+
+	if state := input(req, "state"); state != "" {
+		values.Set("state", state)
+	}
+
+	return redirectWith(res, redirect, values)
+}
+
+// Comment
+func (ctx *OAuthServer) authenticateClient(req *http.Request) (*OAuthClient, error) {
+	id, secret, basic := req.BasicAuth()
+
+	if basic {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = input(req, "client_id"), input(req, "client_secret")
+	}
+
+	client, err := ctx.Client(id)
+
+	if err != nil || client.Revoked {
+		return nil, newOAuthError(http.HTTP_RESPONSE_UNAUTHORIZED, OAUTH_INVALID_CLIENT, "client authentication failed")
+	}
+
+	if client.Confidential && !client.ValidSecret(secret) {
+		return nil, newOAuthError(http.HTTP_RESPONSE_UNAUTHORIZED, OAUTH_INVALID_CLIENT, "client authentication failed")
+	}
+
+	return client, nil
+}
+
+// Comment
+func (ctx *OAuthServer) token(req *http.Request, res *http.Response) *http.Response {
+	client, err := ctx.authenticateClient(req)
+
+	if err != nil {
+		return oauthErrorResponse(res, err)
+	}
+
+	grant := input(req, "grant_type")
+
+	if !slices.Contains([]string{GRANT_AUTHORIZATION_CODE, GRANT_CLIENT_CREDENTIALS, GRANT_REFRESH_TOKEN}, grant) {
+		return oauthErrorResponse(res, newOAuthError(http.HTTP_RESPONSE_BAD_REQUEST, OAUTH_UNSUPPORTED_GRANT, "grant type is not supported"))
+	}
+
+	if !client.HasGrant(grant) {
+		return oauthErrorResponse(res, newOAuthError(http.HTTP_RESPONSE_BAD_REQUEST, OAUTH_UNAUTHORIZED_CLIENT, "client can not use grant "+grant))
+	}
+
+	var pair *TokenPair
+
+	switch grant {
+	case GRANT_AUTHORIZATION_CODE:
+		pair, err = ctx.authorizationCodeGrant(req, client)
+
+	case GRANT_CLIENT_CREDENTIALS:
+		pair, err = ctx.clientCredentialsGrant(req, client)
+
+	case GRANT_REFRESH_TOKEN:
+		pair, err = ctx.refreshTokenGrant(req, client)
+	}
+
+	if err != nil {
+		return oauthErrorResponse(res, err)
+	}
+
+	return res.SetHeader("Cache-Control", "no-store").Json(pair)
+}
+
+// Comment
+func (ctx *OAuthServer) authorizationCodeGrant(req *http.Request, client *OAuthClient) (*TokenPair, error) {
+	hashed := HashToken(input(req, "code"))
+	record, err := ctx.first(OAUTH_AUTH_CODES_TABLE, "code", hashed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := newOAuthError(http.HTTP_RESPONSE_BAD_REQUEST, OAUTH_INVALID_GRANT, "authorization code is invalid")
+
+	if record == nil {
+		return nil, invalid
+	}
+
+	consumed, err := ctx.consume(OAUTH_AUTH_CODES_TABLE, "code", hashed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Authorization codes can only be exchanged once, a replayed code revokes the tokens issued with it.
+	if !consumed {
+		if err := ctx.revokeGrant(cast.ToString(record["client_id"]), cast.ToString(record["user_id"])); err != nil {
+			return nil, err
+		}
+
+		return nil, invalid
+	}
+
+	if cast.ToString(record["client_id"]) != client.Id || time.Now().Unix() > cast.ToInt64(record["expires_at"]) {
+		return nil, invalid
+	}
+
+	if cast.ToString(record["redirect_uri"]) != input(req, "redirect_uri") {
+		return nil, invalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(CodeChallenge(input(req, "code_verifier"))), []byte(cast.ToString(record["challenge"]))) != 1 {
+		return nil, newOAuthError(http.HTTP_RESPONSE_BAD_REQUEST, OAUTH_INVALID_GRANT, "code verifier is invalid")
+	}
+
+	return ctx.issue(client, cast.ToString(record["user_id"]), strings.Fields(cast.ToString(record["scopes"])), true)
+}
+
+// Comment
+func (ctx *OAuthServer) clientCredentialsGrant(req *http.Request, client *OAuthClient) (*TokenPair, error) {
+	if !client.Confidential {
+		return nil, newOAuthError(http.HTTP_RESPONSE_BAD_REQUEST, OAUTH_UNAUTHORIZED_CLIENT, "public clients can not use client credentials")
+	}
+
+	scopes, err := ctx.scopeList(input(req, "scope"), client.Scopes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.issue(client, "", scopes, false)
+}
+
+// Comment
+func (ctx *OAuthServer) refreshTokenGrant(req *http.Request, client *OAuthClient) (*TokenPair, error) {
+	hashed := HashToken(input(req, "refresh_token"))
+	record, err := ctx.first(OAUTH_REFRESH_TOKENS_TABLE, "token", hashed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := newOAuthError(http.HTTP_RESPONSE_BAD_REQUEST, OAUTH_INVALID_GRANT, "refresh token is invalid")
+
+	if record == nil || cast.ToString(record["client_id"]) != client.Id {
+		return nil, invalid
+	}
+
+	if cast.ToInt64(record["revoked"]) != 0 || time.Now().Unix() > cast.ToInt64(record["expires_at"]) {
+		return nil, invalid
+	}
+
+	scopes := strings.Fields(cast.ToString(record["scopes"]))
+
+	// The requested scope may narrow but never widen the original grant.
+	if requested := input(req, "scope"); requested != "" {
+		narrowed, err := ctx.scopeList(requested, scopes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		scopes = narrowed
+	}
+
+	// Concurrent requests with the same refresh token can only consume it once.
+	if consumed, err := ctx.consume(OAUTH_REFRESH_TOKENS_TABLE, "token", hashed); err != nil || !consumed {
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, invalid
+	}
+
+	if err := ctx.update(OAUTH_ACCESS_TOKENS_TABLE, "token", cast.ToString(record["access_token"]), orm.Values{"revoked": 1}); err != nil {
+		return nil, err
+	}
+
+	return ctx.issue(client, cast.ToString(record["user_id"]), scopes, true)
+}
+
+// Comment
+func (ctx *OAuthServer) issue(client *OAuthClient, user string, scopes []string, refresh bool) (*TokenPair, error) {
+	access, accessHash := NewToken()
+	now := time.Now()
+
+	err := ctx.insert(OAUTH_ACCESS_TOKENS_TABLE, orm.Values{
+		"token":      accessHash,
+		"client_id":  client.Id,
+		"user_id":    user,
+		"scopes":     strings.Join(scopes, " "),
+		"revoked":    0,
+		"created_at": now.Unix(),
+		"expires_at": now.Add(ctx.accessTtl).Unix(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	pair := &TokenPair{AccessToken: access, TokenType: JWT_TOKEN_TYPE, ExpiresIn: int64(ctx.accessTtl.Seconds())}
+
+	if !refresh || !client.HasGrant(GRANT_REFRESH_TOKEN) {
+		return pair, nil
+	}
+
+	token, hashed := NewToken()
+
+	err = ctx.insert(OAUTH_REFRESH_TOKENS_TABLE, orm.Values{
+		"token":        hashed,
+		"access_token": accessHash,
+		"client_id":    client.Id,
+		"user_id":      user,
+		"scopes":       strings.Join(scopes, " "),
+		"revoked":      0,
+		"expires_at":   now.Add(ctx.refreshTtl).Unix(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	pair.RefreshToken = token
+
+	return pair, nil
+}
+
+// Comment
+func (ctx *OAuthServer) revokeRefreshToken(hashed string, access string) error {
+	if err := ctx.update(OAUTH_REFRESH_TOKENS_TABLE, "token", hashed, orm.Values{"revoked": 1}); err != nil {
+		return err
+	}
+
+	return ctx.update(OAUTH_ACCESS_TOKENS_TABLE, "token", access, orm.Values{"revoked": 1})
+}
+
+// Comment
+func (ctx *OAuthServer) AccessToken(token string) (*OAuthAccessToken, bool) {
+	record, err := ctx.first(OAUTH_ACCESS_TOKENS_TABLE, "token", HashToken(token))
+
+	if err != nil || record == nil {
+		return nil, false
+	}
+
+	if cast.ToInt64(record["revoked"]) != 0 || time.Now().Unix() > cast.ToInt64(record["expires_at"]) {
+		return nil, false
+	}
+
+	return &OAuthAccessToken{
+		ClientId:  cast.ToString(record["client_id"]),
+		UserId:    cast.ToString(record["user_id"]),
+		Scopes:    strings.Fields(cast.ToString(record["scopes"])),
+		IssuedAt:  time.Unix(cast.ToInt64(record["created_at"]), 0),
+		ExpiresAt: time.Unix(cast.ToInt64(record["expires_at"]), 0),
+	}, true
+}
+
+// Comment
+func (ctx *OAuthServer) introspect(req *http.Request, res *http.Response) *http.Response {
+	client, err := ctx.authenticateClient(req)
+
+	if err != nil || !client.Confidential {
+		return oauthErrorResponse(res, newOAuthError(http.HTTP_RESPONSE_UNAUTHORIZED, OAUTH_INVALID_CLIENT, "client authentication failed"))
+	}
+
+	res.SetHeader("Cache-Control", "no-store")
+
+	token, ok := ctx.AccessToken(input(req, "token"))
+
+	if !ok {
+		return res.Json(map[string]interface{}{"active": false})
+	}
+
+	introspection := map[string]interface{}{
+		"active":     true,
+		"scope":      strings.Join(token.Scopes, " "),
+		"client_id":  token.ClientId,
+		"token_type": JWT_TOKEN_TYPE,
+		"iat":        token.IssuedAt.Unix(),
+		"exp":        token.ExpiresAt.Unix(),
+	}
+
+	if token.UserId != "" {
+		introspection["sub"] = token.UserId
+	}
+
+	return res.Json(introspection)
+}
+
+// Comment
+func (ctx *OAuthServer) revoke(req *http.Request, res *http.Response) *http.Response {
+	client, err := ctx.authenticateClient(req)
+
+	if err != nil {
+		return oauthErrorResponse(res, err)
+	}
+
+	hashed := HashToken(input(req, "token"))
+	hint := input(req, "token_type_hint")
+
+	if hint != "" && hint != "access_token" && hint != "refresh_token" {
+		return oauthErrorResponse(res, newOAuthError(http.HTTP_RESPONSE_BAD_REQUEST, OAUTH_UNSUPPORTED_TOKEN_TYPE, "token type is not supported"))
+	}
+
+	// Unknown tokens or tokens of other clients are ignored (RFC 7009 section 2.2).
+	if record, err := ctx.first(OAUTH_REFRESH_TOKENS_TABLE, "token", hashed); err == nil && record != nil && cast.ToString(record["client_id"]) == client.Id {
+		if err := ctx.revokeRefreshToken(hashed, cast.ToString(record["access_token"])); err != nil {
+			return res.Error(err)
+		}
+	} else if record, err := ctx.first(OAUTH_ACCESS_TOKENS_TABLE, "token", hashed); err == nil && record != nil && cast.ToString(record["client_id"]) == client.Id {
+		if err := ctx.update(OAUTH_ACCESS_TOKENS_TABLE, "token", hashed, orm.Values{"revoked": 1}); err != nil {
+			return res.Error(err)
+		}
+	}
+
+	return res.SetHeader("Cache-Control", "no-store").Json(map[string]interface{}{})
+}
+
+// Comment
+func OAuthAccess(req *http.Request) *OAuthAccessToken {
+	token, _ := req.Get(REQUEST_OAUTH_KEY).(*OAuthAccessToken)
+
+	return token
+}
+
+// Comment
+func (ctx *OAuthServer) Scopes(scopes ...string) http.Middleware {
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		token, ok := ctx.AccessToken(BearerToken(req))
+
+		if !ok {
+			return res.SetHeader("WWW-Authenticate", fmt.Sprintf(`%s error="%s"`, JWT_TOKEN_TYPE, JWT_INVALID_TOKEN)).
+				Error(http.Unauthorized(InvalidTokenMessage))
+		}
+
+		for _, scope := range scopes {
+			if !token.Can(scope) {
+				return res.SetHeader("WWW-Authenticate", fmt.Sprintf(`%s error="%s", scope="%s"`, JWT_TOKEN_TYPE, OAUTH_INSUFFICIENT_SCOPE, strings.Join(scopes, " "))).
+					Error(http.Forbidden(http.AuthorizationMessage))
+			}
+		}
+
+		req.Set(REQUEST_OAUTH_KEY, token)
+
+		return next()
+	}
+}
+
+// Comment
+func (ctx *OAuthServer) Guard() Driver {
+	return func(req *http.Request, provider UserProvider) Guard {
+		return &tokenGuard{
+			request:  req,
+			provider: provider,
+			retrieve: func(token string) (Authenticatable, error) {
+				access, ok := ctx.AccessToken(token)
+
+				if !ok || access.UserId == "" {
+					return nil, nil
+				}
+
+				return provider.RetrieveById(access.UserId)
+			},
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/types"
+	str "github.com/lucas11776-golang/http/utils/strings"
+	"github.com/lucas11776-golang/orm"
+	"github.com/lucas11776-golang/orm/databases/sqlite"
+)
+
+func TestOAuthServer(t *testing.T) {
+	orm.DB.Add("oauth-server", sqlite.Connect(":memory:"))
+
+	server := NewOAuthServer("oauth-server").
+		Scope("orders:read", "Read your orders").
+		Scope("orders:write", "Update your orders")
+
+	if err := server.Migrate(); err != nil {
+		t.Fatalf("Something went wrong when trying to migrate oauth tables: %v", err)
+	}
+
+	public, _, err := server.CreateClient("Partner App", []string{"https://partner.test/callback"}, false)
+
+	if err != nil {
+		t.Fatalf("Something went wrong when trying to create client: %v", err)
+	}
+
+	confidential, secret, err := server.CreateClient("Reporting", []string{"https://reporting.test/callback"}, true, GRANT_CLIENT_CREDENTIALS)
+
+	if err != nil {
+		t.Fatalf("Something went wrong when trying to create client: %v", err)
+	}
+
+	sessions := http.InitSession("session", []byte(str.Random(10)), http.NewMemorySessionStore())
+	verifier := str.Random(43)
+	admin := &Admin{ID: "1"}
+
+	Register("oauth-server", SessionGuard, admins{"1": admin})
+
+	request := func(t *testing.T, method http.Method, uri string, form url.Values, headers types.Headers) *http.Request {
+		req, err := http.NewRequest(method, uri, "HTTP/1.1", headers, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		req.Form = form
+		req.Session = sessions.Session(req)
+		req.Response.Session = req.Session
+
+		Auth(req).ShouldUse("oauth-server")
+
+		return req
+	}
+
+	authorization := url.Values{
+		"client_id":             {public.Id},
+		"redirect_uri":          {"https://partner.test/callback"},
+		"response_type":         {"code"},
+		"scope":                 {"orders:read"},
+		"state":                 {"xyz"},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	approve := func(t *testing.T) string {
+		form := url.Values{"approve": {"1"}}
+
+		for k, v := range authorization {
+			form[k] = v
+		}
+
+		req := request(t, http.METHOD_POST, "/oauth/authorize", form, types.Headers{})
+		Auth(req).Login(admin)
+		req.Form.Set(http.CSRF_INPUT_NAME, http.MaskCsrfToken(req.Session.CsrfToken()))
+
+		u, _ := url.Parse(server.approve(req, req.Response).Bag.Redirect.To)
+
+		if u.Host != "partner.test" || u.Query().Get("state") != "xyz" || u.Query().Get("code") == "" {
+			t.Fatalf("Expected redirect with code and state but got (%s)", u.String())
+		}
+
+		return u.Query().Get("code")
+	}
+
+	token := func(t *testing.T, form url.Values, headers types.Headers) (*http.Response, map[string]interface{}) {
+		req := request(t, http.METHOD_POST, "/oauth/token", form, headers)
+		res := server.token(req, req.Response)
+		body := map[string]interface{}{}
+
+		json.NewDecoder(res.Body).Decode(&body)
+
+		return res, body
+	}
+
+	basic := types.Headers{"authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(confidential.Id+":"+secret))}
+
+	t.Run("TestConsent", func(t *testing.T) {
+		req := request(t, http.METHOD_GET, "/oauth/authorize?"+authorization.Encode(), nil, types.Headers{})
+
+		if res := server.authorize(req, req.Response); res.Bag.Redirect.To != "login" {
+			t.Fatalf("Expected guest to be redirected to (login) but got (%s)", res.Bag.Redirect.To)
+		}
+
+		Auth(req).Login(admin)
+
+		content, _ := io.ReadAll(server.authorize(req, req.Response).Body)
+		html := string(content)
+
+		if !strings.Contains(html, "Partner App") || !strings.Contains(html, "Read your orders") || strings.Contains(html, "Update your orders") {
+			t.Fatalf("Expected consent page to show client and requested scopes but got (%s)", html)
+		}
+
+		query := url.Values{}
+
+		for k, v := range authorization {
+			query[k] = v
+		}
+
+		query.Set("redirect_uri", "https://evil.test/callback")
+
+		req = request(t, http.METHOD_GET, "/oauth/authorize?"+query.Encode(), nil, types.Headers{})
+		Auth(req).Login(admin)
+
+		if res := server.authorize(req, req.Response); res.StatusCode != int(http.HTTP_RESPONSE_BAD_REQUEST) || res.Bag.Redirect != nil {
+			t.Fatalf("Expected unregistered redirect uri to be rejected but got (%d)", res.StatusCode)
+		}
+	})
+
+	t.Run("TestConsentCsrf", func(t *testing.T) {
+		form := url.Values{"approve": {"1"}, http.CSRF_INPUT_NAME: {"forged"}}
+
+		for k, v := range authorization {
+			form[k] = v
+		}
+
+		req := request(t, http.METHOD_POST, "/oauth/authorize", form, types.Headers{})
+		Auth(req).Login(admin)
+
+		if res := server.approve(req, req.Response); res.StatusCode != int(http.HTTP_RESPONSE_PAGE_EXPIRED) || res.Bag.Redirect != nil {
+			t.Fatalf("Expected consent without csrf token status code to be (%d) but got (%d)", http.HTTP_RESPONSE_PAGE_EXPIRED, res.StatusCode)
+		}
+	})
+
+	t.Run("TestDeny", func(t *testing.T) {
+		form := url.Values{}
+
+		for k, v := range authorization {
+			form[k] = v
+		}
+
+		req := request(t, http.METHOD_POST, "/oauth/authorize", form, types.Headers{})
+		Auth(req).Login(admin)
+		req.Form.Set(http.CSRF_INPUT_NAME, http.MaskCsrfToken(req.Session.CsrfToken()))
+
+		u, _ := url.Parse(server.approve(req, req.Response).Bag.Redirect.To)
+
+		if u.Query().Get("error") != OAUTH_ACCESS_DENIED || u.Query().Get("state") != "xyz" {
+			t.Fatalf("Expected redirect with error (%s) but got (%s)", OAUTH_ACCESS_DENIED, u.String())
+		}
+	})
+
+	var pair map[string]interface{}
+
+	t.Run("TestAuthorizationCode", func(t *testing.T) {
+		code := approve(t)
+		form := url.Values{
+			"grant_type":    {GRANT_AUTHORIZATION_CODE},
+			"client_id":     {public.Id},
+			"code":          {code},
+			"redirect_uri":  {"https://partner.test/callback"},
+			"code_verifier": {"forged"},
+		}
+
+		if _, body := token(t, form, types.Headers{}); body["error"] != OAUTH_INVALID_GRANT {
+			t.Fatalf("Expected error to be (%s) but got (%v)", OAUTH_INVALID_GRANT, body)
+		}
+
+		form.Set("code", approve(t))
+		form.Set("code_verifier", verifier)
+
+		res, body := token(t, form, types.Headers{})
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_OK) || body["access_token"] == nil || body["refresh_token"] == nil {
+			t.Fatalf("Expected token pair but got (%d, %v)", res.StatusCode, body)
+		}
+
+		access, ok := server.AccessToken(body["access_token"].(string))
+
+		if !ok || access.UserId != "1" || access.ClientId != public.Id || !access.Can("orders:read") || access.Can("orders:write") {
+			t.Fatalf("Expected access token to be issued for user with requested scopes but got (%+v)", access)
+		}
+
+		pair = body
+	})
+
+	t.Run("TestRefreshToken", func(t *testing.T) {
+		form := url.Values{
+			"grant_type":    {GRANT_REFRESH_TOKEN},
+			"client_id":     {public.Id},
+			"refresh_token": {pair["refresh_token"].(string)},
+		}
+
+		res, body := token(t, form, types.Headers{})
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_OK) || body["refresh_token"] == pair["refresh_token"] {
+			t.Fatalf("Expected rotated token pair but got (%d, %v)", res.StatusCode, body)
+		}
+
+		if _, ok := server.AccessToken(pair["access_token"].(string)); ok {
+			t.Fatalf("Expected previous access token to be revoked")
+		}
+
+		if _, body := token(t, form, types.Headers{}); body["error"] != OAUTH_INVALID_GRANT {
+			t.Fatalf("Expected rotated refresh token to be rejected but got (%v)", body)
+		}
+
+		pair = body
+	})
+
+	t.Run("TestClientCredentials", func(t *testing.T) {
+		form := url.Values{"grant_type": {GRANT_CLIENT_CREDENTIALS}}
+
+		if res, _ := token(t, form, types.Headers{"authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(confidential.Id+":wrong"))}); res.StatusCode != int(http.HTTP_RESPONSE_UNAUTHORIZED) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_UNAUTHORIZED, res.StatusCode)
+		}
+
+		res, body := token(t, form, basic)
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_OK) || body["access_token"] == nil || body["refresh_token"] != nil {
+			t.Fatalf("Expected access token without refresh token but got (%d, %v)", res.StatusCode, body)
+		}
+
+		form.Set("client_id", public.Id)
+
+		if _, body := token(t, form, types.Headers{}); body["error"] != OAUTH_UNAUTHORIZED_CLIENT {
+			t.Fatalf("Expected error to be (%s) but got (%v)", OAUTH_UNAUTHORIZED_CLIENT, body)
+		}
+	})
+
+	t.Run("TestIntrospectAndRevoke", func(t *testing.T) {
+		introspect := func(t *testing.T, headers types.Headers) (*http.Response, map[string]interface{}) {
+			req := request(t, http.METHOD_POST, "/oauth/introspect", url.Values{"token": {pair["access_token"].(string)}}, headers)
+			res := server.introspect(req, req.Response)
+			body := map[string]interface{}{}
+
+			json.NewDecoder(res.Body).Decode(&body)
+
+			return res, body
+		}
+
+		if res, _ := introspect(t, types.Headers{}); res.StatusCode != int(http.HTTP_RESPONSE_UNAUTHORIZED) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_UNAUTHORIZED, res.StatusCode)
+		}
+
+		if _, body := introspect(t, basic); body["active"] != true || body["sub"] != "1" || body["scope"] != "orders:read" {
+			t.Fatalf("Expected active token but got (%v)", body)
+		}
+
+		// Tokens of other clients are ignored.
+		req := request(t, http.METHOD_POST, "/oauth/revoke", url.Values{"token": {pair["access_token"].(string)}}, basic)
+
+		if res := server.revoke(req, req.Response); res.StatusCode != int(http.HTTP_RESPONSE_OK) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_OK, res.StatusCode)
+		}
+
+		if _, body := introspect(t, basic); body["active"] != true {
+			t.Fatalf("Expected token of another client to stay active but got (%v)", body)
+		}
+
+		req = request(t, http.METHOD_POST, "/oauth/revoke", url.Values{"client_id": {public.Id}, "token": {pair["refresh_token"].(string)}}, types.Headers{})
+		server.revoke(req, req.Response)
+
+		if _, body := introspect(t, basic); body["active"] != false {
+			t.Fatalf("Expected revoked token to be inactive but got (%v)", body)
+		}
+	})
+
+	t.Run("TestScopes", func(t *testing.T) {
+		code := approve(t)
+		_, body := token(t, url.Values{
+			"grant_type":    {GRANT_AUTHORIZATION_CODE},
+			"client_id":     {public.Id},
+			"code":          {code},
+			"redirect_uri":  {"https://partner.test/callback"},
+			"code_verifier": {verifier},
+		}, types.Headers{})
+
+		handle := func(token string, scopes ...string) *http.Response {
+			req := request(t, http.METHOD_GET, "/api/orders", nil, types.Headers{"authorization": "Bearer " + token, "accept": "application/json"})
+
+			return server.Scopes(scopes...)(req, req.Response, func() *http.Response {
+				if OAuthAccess(req).UserId != "1" {
+					t.Fatalf("Expected access token to be set on request")
+				}
+
+				return req.Response.Json(map[string]string{})
+			})
+		}
+
+		if res := handle("invalid", "orders:read"); res.StatusCode != int(http.HTTP_RESPONSE_UNAUTHORIZED) || !strings.Contains(res.Header.Get("WWW-Authenticate"), "invalid_token") {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_UNAUTHORIZED, res.StatusCode)
+		}
+
+		if res := handle(body["access_token"].(string), "orders:write"); res.StatusCode != int(http.HTTP_RESPONSE_FORBIDDEN) || !strings.Contains(res.Header.Get("WWW-Authenticate"), OAUTH_INSUFFICIENT_SCOPE) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_FORBIDDEN, res.StatusCode)
+		}
+
+		if res := handle(body["access_token"].(string), "orders:read"); res.StatusCode != int(http.HTTP_RESPONSE_OK) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_OK, res.StatusCode)
+		}
+
+		Register("oauth-api", server.Guard(), admins{"1": admin})
+
+		req := request(t, http.METHOD_GET, "/api/user", nil, types.Headers{"authorization": "Bearer " + body["access_token"].(string)})
+
+		if Auth(req).Guard("oauth-api").Id() != "1" {
+			t.Fatalf("Expected guard to resolve user from access token")
+		}
+	})
+
+	t.Run("TestAuthorizationCodeReplay", func(t *testing.T) {
+		form := url.Values{
+			"grant_type":    {GRANT_AUTHORIZATION_CODE},
+			"client_id":     {public.Id},
+			"code":          {approve(t)},
+			"redirect_uri":  {"https://partner.test/callback"},
+			"code_verifier": {verifier},
+		}
+
+		var group sync.WaitGroup
+		var mutex sync.Mutex
+
+		issued := []map[string]interface{}{}
+
+		for i := 0; i < 10; i++ {
+			group.Add(1)
+
+			go func() {
+				defer group.Done()
+
+				if _, body := token(t, form, types.Headers{}); body["access_token"] != nil {
+					mutex.Lock()
+					issued = append(issued, body)
+					mutex.Unlock()
+				}
+			}()
+		}
+
+		group.Wait()
+
+		if len(issued) != 1 {
+			t.Fatalf("Expected code to be exchanged (%d) times but got (%d)", 1, len(issued))
+		}
+
+		if _, body := token(t, form, types.Headers{}); body["error"] != OAUTH_INVALID_GRANT {
+			t.Fatalf("Expected replayed code to be rejected but got (%v)", body)
+		}
+
+		if _, ok := server.AccessToken(issued[0]["access_token"].(string)); ok {
+			t.Fatalf("Expected access token of replayed code to be revoked")
+		}
+
+		refresh := url.Values{
+			"grant_type":    {GRANT_REFRESH_TOKEN},
+			"client_id":     {public.Id},
+			"refresh_token": {issued[0]["refresh_token"].(string)},
+		}
+
+		if _, body := token(t, refresh, types.Headers{}); body["error"] != OAUTH_INVALID_GRANT {
+			t.Fatalf("Expected refresh token of replayed code to be rejected but got (%v)", body)
+		}
+	})
+}
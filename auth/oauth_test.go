@@ -0,0 +1,289 @@
+package auth
+
+import (
+	"io"
+	h "net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/encryption/jwt"
+	"github.com/lucas11776-golang/http/types"
+	str "github.com/lucas11776-golang/http/utils/strings"
+)
+
+type authorization struct {
+	challenge string
+	nonce     string
+	redirect  string
+}
+
+// Comment
+func identityProvider(t *testing.T, clientId string, clientSecret string) (*http.HTTP, string) {
+	key, err := jwt.GenerateKey("idp-1", jwt.RS256)
+
+	if err != nil {
+		t.Fatalf("Something went wrong when trying to generate key: %v", err)
+	}
+
+	keys := jwt.NewKeySet(key)
+	server := http.Server("127.0.0.1", 0)
+	issuer := "http://" + server.Host()
+	codes := map[string]authorization{}
+	mutex := sync.Mutex{}
+
+	server.Route().Get(OIDC_DISCOVERY_PATH, func(req *http.Request, res *http.Response) *http.Response {
+		return res.Json(Discovery{
+			Issuer:                issuer,
+			AuthorizationEndpoint: issuer + "/authorize",
+			TokenEndpoint:         issuer + "/token",
+			UserInfoEndpoint:      issuer + "/userinfo",
+			JwksUri:               issuer + "/" + jwt.JWKS_PATH,
+			CodeChallengeMethods:  []string{"S256"},
+		})
+	})
+
+	server.Route().Get("authorize", func(req *http.Request, res *http.Response) *http.Response {
+		if req.GetQuery("client_id") != clientId || req.GetQuery("code_challenge_method") != "S256" {
+			return res.SetStatus(http.HTTP_RESPONSE_BAD_REQUEST).Json(OAuthError{Code: "invalid_request"})
+		}
+
+		code := str.Random(20)
+
+		mutex.Lock()
+		codes[code] = authorization{req.GetQuery("code_challenge"), req.GetQuery("nonce"), req.GetQuery("redirect_uri")}
+		mutex.Unlock()
+
+		return res.Redirect(req.GetQuery("redirect_uri") + "?" + url.Values{"code": {code}, "state": {req.GetQuery("state")}}.Encode())
+	})
+
+	server.Route().Post("token", func(req *http.Request, res *http.Response) *http.Response {
+		id, secret, _ := req.BasicAuth()
+
+		mutex.Lock()
+		code, ok := codes[req.Form.Get("code")]
+		delete(codes, req.Form.Get("code"))
+		mutex.Unlock()
+
+		if id != clientId || secret != clientSecret {
+			return res.SetStatus(http.HTTP_RESPONSE_UNAUTHORIZED).Json(OAuthError{Code: "invalid_client"})
+		}
+
+		if !ok || code.redirect != req.Form.Get("redirect_uri") || CodeChallenge(req.Form.Get("code_verifier")) != code.challenge {
+			return res.SetStatus(http.HTTP_RESPONSE_BAD_REQUEST).Json(OAuthError{Code: "invalid_grant", Description: "code is invalid"})
+		}
+
+		token, err := keys.Sign(&IdTokenClaims{
+			RegisteredClaims: gojwt.RegisteredClaims{
+				Issuer:    issuer,
+				Subject:   "248289761001",
+				Audience:  gojwt.ClaimStrings{clientId},
+				IssuedAt:  gojwt.NewNumericDate(time.Now()),
+				ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+			Nonce: code.nonce,
+			Email: "jane@doe.com",
+			Name:  "Jane Doe",
+		})
+
+		if err != nil {
+			return res.Error(err)
+		}
+
+		return res.Json(OAuthToken{AccessToken: "access-token", TokenType: "Bearer", ExpiresIn: 3600, IdToken: token})
+	})
+
+	server.Route().Get("userinfo", func(req *http.Request, res *http.Response) *http.Response {
+		if BearerToken(req) != "access-token" {
+			return res.SetStatus(http.HTTP_RESPONSE_UNAUTHORIZED).Json(OAuthError{Code: "invalid_token"})
+		}
+
+		return res.Json(map[string]interface{}{
+			"sub":     "248289761001",
+			"email":   "jane@doe.com",
+			"picture": "https://idp.test/jane.png",
+			"locale":  "en",
+		})
+	})
+
+	Jwks(server.Route(), keys)
+
+	go server.Listen()
+
+	return server, issuer
+}
+
+func TestOAuth(t *testing.T) {
+	idp, issuer := identityProvider(t, "app", "app-secret")
+
+	defer idp.Close()
+
+	sessions := http.InitSession("session", []byte(str.Random(10)), http.NewMemorySessionStore())
+	client := &h.Client{Timeout: time.Second * 5}
+	redirect := regexp.MustCompile(`url='([^']*)'`)
+
+	Register("oauth", SessionGuard, admins{})
+
+	request := func(t *testing.T, to string, session http.SessionManager) *http.Request {
+		u, _ := url.Parse(to)
+
+		req, err := http.NewRequest(http.METHOD_GET, u.RequestURI(), "HTTP/1.1", types.Headers{}, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		if session == nil {
+			session = sessions.Session(req)
+		}
+
+		req.Session = session
+		req.Response.Session = session
+
+		return req
+	}
+
+	// The test acts as the browser: following the authorization url and the identity provider redirect.
+	authorize := func(t *testing.T, provider *OAuthProvider) *http.Request {
+		req := request(t, "/login/idp", nil)
+		to := provider.Redirect(req, req.Response).Bag.Redirect.To
+
+		res, err := client.Get(to)
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to authorize: %v", err)
+		}
+
+		defer res.Body.Close()
+
+		body, _ := io.ReadAll(res.Body)
+		match := redirect.FindStringSubmatch(string(body))
+
+		if match == nil {
+			t.Fatalf("Expected identity provider to redirect but got (%d, %s)", res.StatusCode, string(body))
+		}
+
+		return request(t, match[1], req.Session)
+	}
+
+	provider, err := NewOidcProvider(issuer, OAuthConfig{
+		ClientId:     "app",
+		ClientSecret: "app-secret",
+		RedirectUrl:  "http://app.test/login/idp/callback",
+	}, client)
+
+	if err != nil {
+		t.Fatalf("Something went wrong when trying to discover provider: %v", err)
+	}
+
+	t.Run("TestDiscovery", func(t *testing.T) {
+		if provider.Config().TokenEndpoint != issuer+"/token" || provider.Config().JwksUri != issuer+"/"+jwt.JWKS_PATH {
+			t.Fatalf("Expected endpoints to be discovered but got (%+v)", provider.Config())
+		}
+
+		if _, err := Discover(client, strings.Replace(issuer, "127.0.0.1", "localhost", 1)); err == nil {
+			t.Fatalf("Expected discovery with mismatched issuer to fail")
+		}
+	})
+
+	t.Run("TestAuthorizationUrl", func(t *testing.T) {
+		req := request(t, "/login/idp", nil)
+		u, _ := url.Parse(provider.AuthorizationUrl(req))
+		query := u.Query()
+
+		if query.Get("state") != req.Session.Get(OAUTH_STATE_KEY) || query.Get("nonce") != req.Session.Get(OAUTH_NONCE_KEY) {
+			t.Fatalf("Expected state and nonce to be stored in session")
+		}
+
+		if query.Get("code_challenge") != CodeChallenge(req.Session.Get(OAUTH_VERIFIER_KEY)) || query.Get("scope") != "openid profile email" {
+			t.Fatalf("Expected code challenge to match stored verifier but got (%s)", u.String())
+		}
+	})
+
+	var token *OAuthToken
+
+	t.Run("TestLogin", func(t *testing.T) {
+		var mapped *OAuthUser
+
+		provider.Map(func(user *OAuthUser) (Authenticatable, error) {
+			mapped = user
+
+			return &Member{ID: 7, Email: user.Email}, nil
+		})
+
+		callback := authorize(t, provider)
+		user, err := provider.Login(callback, "oauth")
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to login: %v", err)
+		}
+
+		if user.AuthIdentifier() != "7" || Auth(callback).Guard("oauth").Id() != "7" {
+			t.Fatalf("Expected mapped user to be logged in but got (%s)", user.AuthIdentifier())
+		}
+
+		if mapped.Id != "248289761001" || mapped.Name != "Jane Doe" || mapped.Avatar != "https://idp.test/jane.png" || mapped.Claims["locale"] != "en" {
+			t.Fatalf("Expected user info to be mapped but got (%+v)", mapped)
+		}
+
+		token = mapped.Token
+
+		if _, err := provider.User(callback); err != ErrInvalidState {
+			t.Fatalf("Expected replayed callback error to be (%v) but got (%v)", ErrInvalidState, err)
+		}
+	})
+
+	t.Run("TestInvalidState", func(t *testing.T) {
+		callback := authorize(t, provider)
+		query := callback.URL.Query()
+
+		query.Set("state", "forged")
+		callback.URL.RawQuery = query.Encode()
+
+		if _, err := provider.User(callback); err != ErrInvalidState {
+			t.Fatalf("Expected error to be (%v) but got (%v)", ErrInvalidState, err)
+		}
+	})
+
+	t.Run("TestInvalidVerifier", func(t *testing.T) {
+		callback := authorize(t, provider)
+
+		callback.Session.Set(OAUTH_VERIFIER_KEY, "forged")
+
+		err := func() error { _, err := provider.User(callback); return err }()
+
+		if e, ok := err.(*OAuthError); !ok || e.Code != "invalid_grant" {
+			t.Fatalf("Expected invalid grant error but got (%v)", err)
+		}
+	})
+
+	t.Run("TestIdToken", func(t *testing.T) {
+		callback := authorize(t, provider)
+
+		callback.Session.Set(OAUTH_NONCE_KEY, "forged")
+
+		if _, err := provider.User(callback); err != ErrInvalidNonce {
+			t.Fatalf("Expected error to be (%v) but got (%v)", ErrInvalidNonce, err)
+		}
+
+		other := NewOAuthProvider(provider.Config()).Client(client)
+		other.config.ClientId = "other"
+
+		if _, err := other.VerifyIdToken(token.IdToken, ""); err == nil || !strings.Contains(err.Error(), "audience") {
+			t.Fatalf("Expected id token for another audience to be rejected but got (%v)", err)
+		}
+	})
+
+	t.Run("TestProviderError", func(t *testing.T) {
+		callback := request(t, "/login/idp/callback?error=access_denied&error_description=User+denied", nil)
+
+		if _, err := provider.User(callback); err == nil || err.Error() != "oauth: access_denied: User denied" {
+			t.Fatalf("Expected provider error but got (%v)", err)
+		}
+	})
+}
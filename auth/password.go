@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/utils/database"
+	"github.com/lucas11776-golang/http/utils/helper"
+	"github.com/lucas11776-golang/orm"
+	"github.com/spf13/cast"
+)
+
+const (
+	PASSWORD_RESET_TABLE    = "password_reset_tokens"
+	PASSWORD_RESET_PATH     = "password/reset"
+	PASSWORD_RESET_VIEW     = "reset-password"
+	PASSWORD_RESET_SUBJECT  = "Reset Password Notification"
+	PASSWORD_RESET_EXPIRE   = time.Hour
+	PASSWORD_RESET_THROTTLE = time.Minute
+	EMAIL_KEY               = "email"
+	TOKEN_KEY               = "token"
+)
+
+var (
+	ErrUserNotFound       = errors.New("we can't find a user with that email address")
+	ErrInvalidResetToken  = errors.New("this password reset token is invalid")
+	ErrResetThrottled     = errors.New("please wait before retrying")
+	ErrCannotResetPasword = errors.New("user can not reset password")
+)
+
+type CanResetPassword interface {
+	Authenticatable
+	AuthEmail() string
+}
+
+type PasswordResetRecord struct {
+	Table     string `table:"password_reset_tokens"`
+	ID        int64  `column:"id" type:"primary_key"`
+	Email     string `column:"email" type:"string"`
+	Token     string `column:"token" type:"string"`
+	CreatedAt int64  `column:"created_at" type:"integer"`
+}
+
+type ResetUrl func(token string, email string) string
+
+type PasswordBroker struct {
+	connection string
+	provider   UserProvider
+	expire     time.Duration
+	throttle   time.Duration
+	mailer     Mailer
+	view       *http.View
+	url        ResetUrl
+}
+
+// Comment
+func NewPasswordBroker(connection string, provider UserProvider) *PasswordBroker {
+	return &PasswordBroker{
+		connection: connection,
+		provider:   provider,
+		expire:     PASSWORD_RESET_EXPIRE,
+		throttle:   PASSWORD_RESET_THROTTLE,
+		mailer:     DefaultMailer,
+		view:       DefaultView(),
+		url: func(token string, email string) string {
+			return helper.Url(PASSWORD_RESET_PATH, token) + "?" + url.Values{EMAIL_KEY: {email}}.Encode()
+		},
+	}
+}
+
+// Comment
+func (ctx *PasswordBroker) Expire(expire time.Duration) *PasswordBroker {
+	ctx.expire = expire
+
+	return ctx
+}
+
+// Comment
+func (ctx *PasswordBroker) Throttle(throttle time.Duration) *PasswordBroker {
+	ctx.throttle = throttle
+
+	return ctx
+}
+
+// Comment
+func (ctx *PasswordBroker) Mailer(mailer Mailer) *PasswordBroker {
+	ctx.mailer = mailer
+
+	return ctx
+}
+
+// Comment
+func (ctx *PasswordBroker) View(view *http.View) *PasswordBroker {
+	ctx.view = view
+
+	return ctx
+}
+
+// Comment
+func (ctx *PasswordBroker) Url(url ResetUrl) *PasswordBroker {
+	ctx.url = url
+
+	return ctx
+}
+
+// Comment
+func (ctx *PasswordBroker) database() (orm.Database, error) {
+	db := orm.DB.Database(ctx.connection)
+
+	if db == nil {
+		return nil, fmt.Errorf("database connection %s does not exists", ctx.connection)
+	}
+
+	return db, nil
+}
+
+// Comment
+func (ctx *PasswordBroker) Migrate() error {
+	db, err := ctx.database()
+
+	if err != nil {
+		return err
+	}
+
+	return db.Migration().Migrate(orm.Models{PasswordResetRecord{}})
+}
+
+// Comment
+func (ctx *PasswordBroker) where(email string) []interface{} {
+	return []interface{}{&orm.Where{Key: "email", Operator: orm.EQUALS, Value: email}}
+}
+
+// Comment
+func (ctx *PasswordBroker) record(email string) (orm.Result, error) {
+	db, err := ctx.database()
+
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := db.Query(&orm.Statement{Table: PASSWORD_RESET_TABLE, Where: ctx.where(email), Limit: 1})
+
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+
+	return results[0], nil
+}
+
+// Comment
+func (ctx *PasswordBroker) CreateToken(user CanResetPassword) (string, error) {
+	db, err := ctx.database()
+
+	if err != nil {
+		return "", err
+	}
+
+	record, err := ctx.record(user.AuthEmail())
+
+	if err != nil {
+		return "", err
+	}
+
+	if record != nil && time.Since(time.Unix(cast.ToInt64(record["created_at"]), 0)) < ctx.throttle {
+		return "", ErrResetThrottled
+	}
+
+	if err := db.Delete(&orm.Statement{Table: PASSWORD_RESET_TABLE, Where: ctx.where(user.AuthEmail())}); err != nil {
+		return "", err
+	}
+
+	token, hashed := NewToken()
+
+	_, err = db.Insert(&orm.Statement{
+		Table:      PASSWORD_RESET_TABLE,
+		PrimaryKey: "id",
+		Values: orm.Values{
+			"email":      user.AuthEmail(),
+			"token":      hashed,
+			"created_at": time.Now().Unix(),
+		},
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Comment
+func (ctx *PasswordBroker) TokenExists(user CanResetPassword, token string) bool {
+	record, err := ctx.record(user.AuthEmail())
+
+	if err != nil || record == nil {
+		return false
+	}
+
+	if time.Since(time.Unix(cast.ToInt64(record["created_at"]), 0)) > ctx.expire {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(cast.ToString(record["token"])), []byte(HashToken(token))) == 1
+}
+
+// Comment
+func (ctx *PasswordBroker) DeleteToken(user CanResetPassword) error {
+	db, err := ctx.database()
+
+	if err != nil {
+		return err
+	}
+
+	return db.Delete(&orm.Statement{Table: PASSWORD_RESET_TABLE, Where: ctx.where(user.AuthEmail())})
+}
+
+// Comment
+func (ctx *PasswordBroker) consume(user CanResetPassword, token string) (bool, error) {
+	// The delete only matches a valid token, so concurrent resets can not use the same token.
+	affected, err := database.Delete(ctx.connection, PASSWORD_RESET_TABLE,
+		&orm.Where{Key: "email", Operator: orm.EQUALS, Value: user.AuthEmail()},
+		&orm.Where{Key: "token", Operator: orm.EQUALS, Value: HashToken(token)},
+		&orm.Where{Key: "created_at", Operator: orm.GREATER_THEN_EQUALS, Value: time.Now().Add(-ctx.expire).Unix()},
+	)
+
+	return err == nil && affected == 1, err
+}
+
+// Comment
+func (ctx *PasswordBroker) user(credentials Credentials) (CanResetPassword, error) {
+	identifiers := Credentials{}
+
+	for k, v := range credentials {
+		if k != PASSWORD_KEY && k != TOKEN_KEY && k != "password_confirmation" {
+			identifiers[k] = v
+		}
+	}
+
+	user, err := ctx.provider.RetrieveByCredentials(identifiers)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	resettable, ok := user.(CanResetPassword)
+
+	if !ok {
+		return nil, ErrCannotResetPasword
+	}
+
+	return resettable, nil
+}
+
+// Comment
+func (ctx *PasswordBroker) SendResetLink(req *http.Request, credentials Credentials) error {
+	user, err := ctx.user(credentials)
+
+	if err != nil {
+		return err
+	}
+
+	token, err := ctx.CreateToken(user)
+
+	if err != nil {
+		return err
+	}
+
+	return notify(req, ctx.view, ctx.mailer, user.AuthEmail(), PASSWORD_RESET_SUBJECT, PASSWORD_RESET_VIEW, http.ViewData{
+		"link":   ctx.url(token, user.AuthEmail()),
+		"expire": int(ctx.expire.Minutes()),
+	})
+}
+
+// Comment
+func (ctx *PasswordBroker) Reset(credentials Credentials, reset func(user Authenticatable, password string) error) error {
+	user, err := ctx.user(credentials)
+
+	if err != nil {
+		return err
+	}
+
+	consumed, err := ctx.consume(user, credentials[TOKEN_KEY])
+
+	if err != nil {
+		return err
+	}
+
+	if !consumed {
+		return ErrInvalidResetToken
+	}
+
+	return reset(user, credentials[PASSWORD_KEY])
+}
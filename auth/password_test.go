@@ -0,0 +1,309 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/encryption/hash"
+	"github.com/lucas11776-golang/http/types"
+	"github.com/lucas11776-golang/orm"
+	"github.com/lucas11776-golang/orm/databases/sqlite"
+	"github.com/spf13/cast"
+)
+
+type Member struct {
+	ID       int64
+	Email    string
+	Verified bool
+}
+
+// Comment
+func (ctx User) AuthEmail() string {
+	return ctx.Email
+}
+
+// Comment
+func (ctx *Member) AuthIdentifier() string {
+	return cast.ToString(ctx.ID)
+}
+
+// Comment
+func (ctx *Member) AuthPassword() string {
+	return ""
+}
+
+// Comment
+func (ctx *Member) AuthEmail() string {
+	return ctx.Email
+}
+
+// Comment
+func (ctx *Member) HasVerifiedEmail() bool {
+	return ctx.Verified
+}
+
+// Comment
+func (ctx *Member) MarkEmailAsVerified() error {
+	ctx.Verified = true
+
+	return nil
+}
+
+type mailbox struct {
+	to      string
+	subject string
+	body    string
+}
+
+// Comment
+func (ctx *mailbox) mailer(to string, subject string, body string) error {
+	ctx.to, ctx.subject, ctx.body = to, subject, body
+
+	return nil
+}
+
+// Comment
+func (ctx *mailbox) link(t *testing.T) *url.URL {
+	start := strings.Index(ctx.body, `href="`)
+
+	if start == -1 {
+		t.Fatalf("Expected mail body to contain link but got (%s)", ctx.body)
+	}
+
+	link := ctx.body[start+len(`href="`):]
+	link = strings.ReplaceAll(link[:strings.Index(link, `"`)], "&amp;", "&")
+
+	u, err := url.Parse(link)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u
+}
+
+func TestPasswordReset(t *testing.T) {
+	if orm.DB.Database("auth") == nil {
+		orm.DB.Add("auth", sqlite.Connect(":memory:"))
+
+		if err := orm.DB.Database("auth").Migration().Migrate(orm.Models{User{}}); err != nil {
+			t.Fatalf("Something went wrong when trying to migrate users table: %v", err)
+		}
+	}
+
+	password, _ := hash.Make("secret")
+
+	if _, err := orm.Model(User{}).Insert(orm.Values{"email": "reset@doe.com", "password": password}); err != nil {
+		t.Fatalf("Something went wrong when trying to insert user: %v", err)
+	}
+
+	mail := &mailbox{}
+	broker := NewPasswordBroker("auth", NewOrmUserProvider(User{})).Mailer(mail.mailer)
+
+	if err := broker.Migrate(); err != nil {
+		t.Fatalf("Something went wrong when trying to migrate password reset table: %v", err)
+	}
+
+	req, err := http.NewRequest(http.METHOD_POST, "http://app.test/password/email", "HTTP/1.1", types.Headers{}, strings.NewReader(""))
+
+	if err != nil {
+		t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+	}
+
+	t.Run("TestSendResetLink", func(t *testing.T) {
+		if err := broker.SendResetLink(req, Credentials{EMAIL_KEY: "unknown@doe.com"}); err != ErrUserNotFound {
+			t.Fatalf("Expected error to be (%v) but got (%v)", ErrUserNotFound, err)
+		}
+
+		if err := broker.SendResetLink(req, Credentials{EMAIL_KEY: "reset@doe.com"}); err != nil {
+			t.Fatalf("Something went wrong when trying to send reset link: %v", err)
+		}
+
+		if mail.to != "reset@doe.com" || mail.subject != PASSWORD_RESET_SUBJECT {
+			t.Fatalf("Expected mail to be sent to (%s) but got (%s)", "reset@doe.com", mail.to)
+		}
+
+		if err := broker.SendResetLink(req, Credentials{EMAIL_KEY: "reset@doe.com"}); err != ErrResetThrottled {
+			t.Fatalf("Expected error to be (%v) but got (%v)", ErrResetThrottled, err)
+		}
+	})
+
+	t.Run("TestReset", func(t *testing.T) {
+		link := mail.link(t)
+		token := link.Path[strings.LastIndex(link.Path, "/")+1:]
+
+		if link.Query().Get(EMAIL_KEY) != "reset@doe.com" {
+			t.Fatalf("Expected reset link email to be (%s) but got (%s)", "reset@doe.com", link.Query().Get(EMAIL_KEY))
+		}
+
+		reset := func(user Authenticatable, password string) error {
+			hashed, err := hash.Make(password)
+
+			if err != nil {
+				return err
+			}
+
+			return orm.Model(User{}).Where("id", orm.EQUALS, user.AuthIdentifier()).Update(orm.Values{"password": hashed})
+		}
+
+		credentials := Credentials{EMAIL_KEY: "reset@doe.com", TOKEN_KEY: "invalid", PASSWORD_KEY: "new-secret"}
+
+		if err := broker.Reset(credentials, reset); err != ErrInvalidResetToken {
+			t.Fatalf("Expected error to be (%v) but got (%v)", ErrInvalidResetToken, err)
+		}
+
+		credentials[TOKEN_KEY] = token
+
+		if err := broker.Reset(credentials, reset); err != nil {
+			t.Fatalf("Something went wrong when trying to reset password: %v", err)
+		}
+
+		user, _ := orm.Model(User{}).Where("email", orm.EQUALS, "reset@doe.com").First()
+
+		if !hash.Check("new-secret", user.Password) {
+			t.Fatalf("Expected password to be reset")
+		}
+
+		if err := broker.Reset(credentials, reset); err != ErrInvalidResetToken {
+			t.Fatalf("Expected used token error to be (%v) but got (%v)", ErrInvalidResetToken, err)
+		}
+	})
+
+	t.Run("TestExpiredToken", func(t *testing.T) {
+		user := User{Email: "reset@doe.com"}
+		token, err := broker.Throttle(0).CreateToken(user)
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create token: %v", err)
+		}
+
+		if !broker.TokenExists(user, token) {
+			t.Fatalf("Expected token to exist")
+		}
+
+		if broker.Expire(-time.Minute).TokenExists(user, token) {
+			t.Fatalf("Expected expired token to be invalid")
+		}
+	})
+
+	t.Run("TestConcurrentReset", func(t *testing.T) {
+		user := User{Email: "reset@doe.com"}
+		token, err := broker.Expire(time.Hour).Throttle(0).CreateToken(user)
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create token: %v", err)
+		}
+
+		credentials := Credentials{EMAIL_KEY: "reset@doe.com", TOKEN_KEY: token, PASSWORD_KEY: "concurrent-secret"}
+		resets := atomic.Int64{}
+		wg := sync.WaitGroup{}
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				broker.Reset(credentials, func(user Authenticatable, password string) error {
+					resets.Add(1)
+
+					return nil
+				})
+			}()
+		}
+
+		wg.Wait()
+
+		if resets.Load() != 1 {
+			t.Fatalf("Expected resets to be (%d) but got (%d)", 1, resets.Load())
+		}
+	})
+}
+
+func TestEmailVerification(t *testing.T) {
+	server := http.Server("127.0.0.1", 0)
+	mail := &mailbox{}
+	verifier := NewEmailVerifier().Mailer(mail.mailer)
+
+	Register("verification", TokenGuard, NewOrmUserProvider(User{}))
+
+	request := func(t *testing.T, to string, user *Member, headers types.Headers) *http.Request {
+		u, err := url.Parse(to)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest(http.METHOD_GET, u.RequestURI(), "HTTP/1.1", headers, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		req.Server = server
+		req.Parameters = http.Parameters{}
+
+		if segments := strings.Split(strings.Trim(u.Path, "/"), "/"); len(segments) == 4 {
+			req.Parameters["id"], req.Parameters["hash"] = segments[2], segments[3]
+		}
+
+		if user != nil {
+			Auth(req).ShouldUse("verification").Login(user)
+		}
+
+		return req
+	}
+
+	t.Run("TestVerify", func(t *testing.T) {
+		member := &Member{ID: 1, Email: "member@doe.com"}
+
+		if err := verifier.Send(request(t, "/email/resend", member, types.Headers{}), member); err != nil {
+			t.Fatalf("Something went wrong when trying to send verification email: %v", err)
+		}
+
+		link := mail.link(t)
+
+		if err := verifier.Verify(request(t, link.String(), &Member{ID: 2, Email: "member@doe.com"}, types.Headers{})); err != ErrInvalidVerificationLink {
+			t.Fatalf("Expected error to be (%v) but got (%v)", ErrInvalidVerificationLink, err)
+		}
+
+		if err := verifier.Verify(request(t, strings.Replace(link.String(), "/1/", "/2/", 1), member, types.Headers{})); err != ErrInvalidVerificationLink {
+			t.Fatalf("Expected tampered link error to be (%v) but got (%v)", ErrInvalidVerificationLink, err)
+		}
+
+		if err := verifier.Verify(request(t, link.String(), member, types.Headers{})); err != nil {
+			t.Fatalf("Something went wrong when trying to verify email: %v", err)
+		}
+
+		if !member.HasVerifiedEmail() {
+			t.Fatalf("Expected member email to be verified")
+		}
+	})
+
+	t.Run("TestVerifiedMiddleware", func(t *testing.T) {
+		handle := func(req *http.Request) *http.Response {
+			return Verified("email/verify-notice")(req, req.Response, func() *http.Response {
+				return req.Response.Html("<h1>Dashboard</h1>")
+			})
+		}
+
+		if res := handle(request(t, "/dashboard", &Member{ID: 1, Verified: true}, types.Headers{})); res.StatusCode != int(http.HTTP_RESPONSE_OK) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_OK, res.StatusCode)
+		}
+
+		res := handle(request(t, "/dashboard", &Member{ID: 1}, types.Headers{}))
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_TEMPORARY_REDIRECT) || res.Bag.Redirect.To != "email/verify-notice" {
+			t.Fatalf("Expected redirect to (%s) but got (%d, %s)", "email/verify-notice", res.StatusCode, res.Bag.Redirect.To)
+		}
+
+		if res := handle(request(t, "/dashboard", &Member{ID: 1}, types.Headers{"accept": "application/json"})); res.StatusCode != int(http.HTTP_RESPONSE_FORBIDDEN) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_FORBIDDEN, res.StatusCode)
+		}
+	})
+}
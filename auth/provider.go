@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/lucas11776-golang/http/encryption/crypt"
+	"github.com/lucas11776-golang/orm"
+)
+
+const (
+	IDENTIFIER_COLUMN = "id"
+	TOKEN_COLUMN      = "api_token"
+	TOKEN_SIZE        = 40
+)
+
+type OrmUserProvider[T any] struct {
+	model      T
+	identifier string
+	token      string
+}
+
+// Comment
+func NewOrmUserProvider[T any](model T) *OrmUserProvider[T] {
+	return &OrmUserProvider[T]{
+		model:      model,
+		identifier: IDENTIFIER_COLUMN,
+		token:      TOKEN_COLUMN,
+	}
+}
+
+// Comment
+func NewToken() (string, string) {
+	token := base64.RawURLEncoding.EncodeToString(crypt.RandomBytes(TOKEN_SIZE))
+
+	return token, HashToken(token)
+}
+
+// Comment
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// Comment
+func (ctx *OrmUserProvider[T]) Identifier(column string) *OrmUserProvider[T] {
+	ctx.identifier = column
+
+	return ctx
+}
+
+// Comment
+func (ctx *OrmUserProvider[T]) Token(column string) *OrmUserProvider[T] {
+	ctx.token = column
+
+	return ctx
+}
+
+// Comment
+func (ctx *OrmUserProvider[T]) user(model *T, err error) (Authenticatable, error) {
+	if err != nil || model == nil {
+		return nil, err
+	}
+
+	if user, ok := any(model).(Authenticatable); ok {
+		return user, nil
+	}
+
+	if user, ok := any(*model).(Authenticatable); ok {
+		return user, nil
+	}
+
+	return nil, fmt.Errorf("model %T does not implement auth.Authenticatable", ctx.model)
+}
+
+// Comment
+func (ctx *OrmUserProvider[T]) RetrieveById(id string) (Authenticatable, error) {
+	return ctx.user(orm.Model(ctx.model).Where(ctx.identifier, orm.EQUALS, id).First())
+}
+
+// Comment
+func (ctx *OrmUserProvider[T]) RetrieveByToken(token string) (Authenticatable, error) {
+	return ctx.user(orm.Model(ctx.model).Where(ctx.token, orm.EQUALS, HashToken(token)).First())
+}
+
+// Comment
+func (ctx *OrmUserProvider[T]) RetrieveByCredentials(credentials Credentials) (Authenticatable, error) {
+	query := orm.Model(ctx.model)
+	empty := true
+
+	for key, value := range credentials {
+		if key == PASSWORD_KEY {
+			continue
+		}
+
+		query, empty = query.Where(key, orm.EQUALS, value), false
+	}
+
+	if empty {
+		return nil, nil
+	}
+
+	return ctx.user(query.First())
+}
+
+// Comment
+func (ctx *OrmUserProvider[T]) ValidateCredentials(user Authenticatable, credentials Credentials) bool {
+	return ValidatePassword(user, credentials)
+}
@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/encryption/totp"
+)
+
+const (
+	TWO_FACTOR_PENDING_KEY    = "two_factor_pending"
+	TWO_FACTOR_CHALLENGE_PATH = "two-factor-challenge"
+	RECOVERY_CODES            = 8
+	CODE_KEY                  = "code"
+	RECOVERY_CODE_KEY         = "recovery_code"
+)
+
+const TwoFactorRequiredMessage = "Two factor authentication is required."
+
+var (
+	ErrInvalidTwoFactorCode = errors.New("the provided two factor authentication code was invalid")
+	ErrTwoFactorNotEnabled  = errors.New("two factor authentication is not enabled")
+)
+
+type TwoFactorAuthenticatable interface {
+	Authenticatable
+	TwoFactorSecret() string
+	TwoFactorRecoveryCodes() []string
+	ReplaceRecoveryCodes(codes []string) error
+}
+
+type TwoFactorStore interface {
+	// Use records the time step as used and reports false when the same or a later step was used.
+	Use(user string, step int64, ttl time.Duration) (bool, error)
+	// Redeem records the hashed recovery code as used and reports false when it was already used.
+	Redeem(user string, code string) (bool, error)
+}
+
+type MemoryTwoFactorStore struct {
+	mutex    sync.Mutex
+	steps    map[string]memoryStep
+	redeemed map[string]struct{}
+}
+
+type memoryStep struct {
+	step    int64
+	expires time.Time
+}
+
+type TwoFactor struct {
+	options totp.Options
+	store   TwoFactorStore
+}
+
+var twoFactorStore = NewMemoryTwoFactorStore()
+
+// Comment
+func NewTwoFactor(issuer string) *TwoFactor {
+	return &TwoFactor{options: totp.Options{Issuer: issuer}}
+}
+
+// Comment
+func (ctx *TwoFactor) Options(options totp.Options) *TwoFactor {
+	ctx.options = options
+
+	return ctx
+}
+
+// Comment
+func (ctx *TwoFactor) Store(store TwoFactorStore) *TwoFactor {
+	ctx.store = store
+
+	return ctx
+}
+
+// Comment
+func (ctx *TwoFactor) records() TwoFactorStore {
+	if ctx.store == nil {
+		return twoFactorStore
+	}
+
+	return ctx.store
+}
+
+// Comment
+func NewMemoryTwoFactorStore() *MemoryTwoFactorStore {
+	return &MemoryTwoFactorStore{steps: map[string]memoryStep{}, redeemed: map[string]struct{}{}}
+}
+
+// Comment
+func (ctx *MemoryTwoFactorStore) Use(user string, step int64, ttl time.Duration) (bool, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	now := time.Now()
+
+	if last, ok := ctx.steps[user]; ok && last.expires.After(now) && step <= last.step {
+		return false, nil
+	}
+
+	ctx.steps[user] = memoryStep{step: step, expires: now.Add(ttl)}
+
+	return true, nil
+}
+
+// Comment
+func (ctx *MemoryTwoFactorStore) Redeem(user string, code string) (bool, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	key := user + ":" + code
+
+	if _, ok := ctx.redeemed[key]; ok {
+		return false, nil
+	}
+
+	ctx.redeemed[key] = struct{}{}
+
+	return true, nil
+}
+
+// Comment
+func (ctx *TwoFactor) Secret() (string, error) {
+	return totp.GenerateSecret()
+}
+
+// Comment
+func (ctx *TwoFactor) Uri(account string, secret string) string {
+	return totp.Uri(account, secret, ctx.options)
+}
+
+// Comment
+func (ctx *TwoFactor) QrCode(account string, secret string) ([]byte, error) {
+	return totp.QrCode(account, secret, ctx.options)
+}
+
+// Comment
+func RecoveryCodes(count int) (plain []string, hashed []string, err error) {
+	for i := 0; i < count; i++ {
+		raw := make([]byte, 5)
+
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		code := hex.EncodeToString(raw)
+		code = code[:5] + "-" + code[5:]
+
+		plain = append(plain, code)
+		hashed = append(hashed, HashToken(code))
+	}
+
+	return plain, hashed, nil
+}
+
+// Comment
+func enabled(user Authenticatable) (TwoFactorAuthenticatable, bool) {
+	if user, ok := user.(TwoFactorAuthenticatable); ok && user != nil && user.TwoFactorSecret() != "" {
+		return user, true
+	}
+
+	return nil, false
+}
+
+// Comment
+func (ctx *TwoFactor) Verify(user TwoFactorAuthenticatable, code string) bool {
+	step, ok := totp.Validate(user.TwoFactorSecret(), strings.ReplaceAll(code, " ", ""), time.Now(), ctx.options)
+
+	if !ok {
+		return false
+	}
+
+	// A code can only be used once, codes from the same or an earlier time step are replays.
+	used, err := ctx.records().Use(user.AuthIdentifier(), step, ctx.options.Window())
+
+	return err == nil && used
+}
+
+// Comment
+func (ctx *TwoFactor) Recover(user TwoFactorAuthenticatable, code string) bool {
+	hashed := []byte(HashToken(strings.TrimSpace(code)))
+	codes := user.TwoFactorRecoveryCodes()
+
+	for i, recovery := range codes {
+		if subtle.ConstantTimeCompare([]byte(recovery), hashed) != 1 {
+			continue
+		}
+
+		// Concurrent requests read the same codes, only the request that redeems the code may replace them.
+		if redeemed, err := ctx.records().Redeem(user.AuthIdentifier(), recovery); err != nil || !redeemed {
+			return false
+		}
+
+		remaining := append(append([]string{}, codes[:i]...), codes[i+1:]...)
+
+		return user.ReplaceRecoveryCodes(remaining) == nil
+	}
+
+	return false
+}
+
+// Comment
+func (ctx *TwoFactor) Challenge(req *http.Request, credentials Credentials) error {
+	user, ok := enabled(TwoFactorUser(req))
+
+	if !ok {
+		return ErrTwoFactorNotEnabled
+	}
+
+	passed := false
+
+	if code := credentials[CODE_KEY]; code != "" {
+		passed = ctx.Verify(user, code)
+	} else if code := credentials[RECOVERY_CODE_KEY]; code != "" {
+		passed = ctx.Recover(user, code)
+	}
+
+	if !passed {
+		return ErrInvalidTwoFactorCode
+	}
+
+	if req.Session != nil {
+		req.Session.Regenerate().Remove(TWO_FACTOR_PENDING_KEY)
+	}
+
+	if guard, ok := Auth(req).Guard().(*sessionGuard); ok {
+		guard.user, guard.resolved = user, true
+	}
+
+	return nil
+}
+
+// Comment
+func TwoFactorUser(req *http.Request) Authenticatable {
+	if guard, ok := Auth(req).Guard().(*sessionGuard); ok {
+		return guard.pending()
+	}
+
+	return nil
+}
+
+// Comment
+func TwoFactorPending(req *http.Request) bool {
+	if req.Session == nil || req.Session.User() == "" {
+		return false
+	}
+
+	return req.Session.Get(TWO_FACTOR_PENDING_KEY) == req.Session.User()
+}
+
+// Comment
+func markTwoFactorPending(req *http.Request, user Authenticatable) {
+	if req.Session == nil {
+		return
+	}
+
+	if _, ok := enabled(user); ok {
+		req.Session.Set(TWO_FACTOR_PENDING_KEY, user.AuthIdentifier())
+
+		return
+	}
+
+	req.Session.Remove(TWO_FACTOR_PENDING_KEY)
+}
+
+// Comment
+func RequireTwoFactor(redirect ...string) http.Middleware {
+	to := TWO_FACTOR_CHALLENGE_PATH
+
+	if len(redirect) != 0 {
+		to = redirect[0]
+	}
+
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		if !TwoFactorPending(req) {
+			return next()
+		}
+
+		if strings.ToLower(req.ContentType()) == "application/json" || req.WantsJson() {
+			return res.Error(http.Forbidden(TwoFactorRequiredMessage))
+		}
+
+		return res.Redirect(to)
+	}
+}
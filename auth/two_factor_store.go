@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/lucas11776-golang/http/utils/database"
+	"github.com/lucas11776-golang/orm"
+)
+
+const (
+	TWO_FACTOR_TABLE          = "two_factor_steps"
+	TWO_FACTOR_RECOVERY_TABLE = "two_factor_recovery_codes"
+)
+
+type TwoFactorStepRecord struct {
+	Table     string `table:"two_factor_steps"`
+	ID        int64  `column:"id" type:"primary_key"`
+	UserId    string `column:"user_id" type:"string"`
+	Step      int64  `column:"step" type:"integer"`
+	ExpiresAt int64  `column:"expires_at" type:"integer"`
+}
+
+type TwoFactorRecoveryRecord struct {
+	Table  string `table:"two_factor_recovery_codes"`
+	ID     int64  `column:"id" type:"primary_key"`
+	UserId string `column:"user_id" type:"string"`
+	Code   string `column:"code" type:"string"`
+}
+
+type DatabaseTwoFactorStore struct {
+	connection string
+}
+
+// Comment
+func NewDatabaseTwoFactorStore(connection string) *DatabaseTwoFactorStore {
+	return &DatabaseTwoFactorStore{connection: connection}
+}
+
+// Comment
+func (ctx *DatabaseTwoFactorStore) Migrate() error {
+	db, err := database.Connection(ctx.connection)
+
+	if err != nil {
+		return err
+	}
+
+	if err := orm.DB.Database(ctx.connection).Migration().Migrate(orm.Models{TwoFactorStepRecord{}, TwoFactorRecoveryRecord{}}); err != nil {
+		return err
+	}
+
+	if err := database.Unique(db, TWO_FACTOR_TABLE, "user_id"); err != nil {
+		return err
+	}
+
+	return database.Unique(db, TWO_FACTOR_RECOVERY_TABLE, "user_id", "code")
+}
+
+// Comment
+func (ctx *DatabaseTwoFactorStore) Use(user string, step int64, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	values := orm.Values{"user_id": user, "step": step, "expires_at": now.Add(ttl).Unix()}
+
+	inserted, err := database.Insert(ctx.connection, TWO_FACTOR_TABLE, values, "user_id")
+
+	if err != nil || inserted {
+		return inserted, err
+	}
+
+	// Every update is conditional so concurrent requests can not use the same step.
+	affected, err := database.Update(ctx.connection, TWO_FACTOR_TABLE, orm.Values{"step": step, "expires_at": values["expires_at"]},
+		&orm.Where{Key: "user_id", Operator: orm.EQUALS, Value: user},
+		&orm.Where{Key: "step", Operator: orm.LESS_THEN, Value: step},
+	)
+
+	if err != nil || affected == 1 {
+		return affected == 1, err
+	}
+
+	affected, err = database.Update(ctx.connection, TWO_FACTOR_TABLE, orm.Values{"step": step, "expires_at": values["expires_at"]},
+		&orm.Where{Key: "user_id", Operator: orm.EQUALS, Value: user},
+		&orm.Where{Key: "expires_at", Operator: orm.LESS_THEN_EQUALS, Value: now.Unix()},
+	)
+
+	return err == nil && affected == 1, err
+}
+
+// Comment
+func (ctx *DatabaseTwoFactorStore) Redeem(user string, code string) (bool, error) {
+	// The unique index rejects the second insert of a code, so it is redeemed once across processes.
+	return database.Insert(ctx.connection, TWO_FACTOR_RECOVERY_TABLE, orm.Values{"user_id": user, "code": code}, "user_id", "code")
+}
@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/cache"
+	"github.com/lucas11776-golang/http/encryption/totp"
+	"github.com/lucas11776-golang/http/types"
+	str "github.com/lucas11776-golang/http/utils/strings"
+	"github.com/lucas11776-golang/orm"
+	"github.com/lucas11776-golang/orm/databases/sqlite"
+)
+
+type Admin struct {
+	ID       string
+	Secret   string
+	Recovery []string
+}
+
+// Comment
+func (ctx *Admin) AuthIdentifier() string {
+	return ctx.ID
+}
+
+// Comment
+func (ctx *Admin) AuthPassword() string {
+	return ""
+}
+
+// Comment
+func (ctx *Admin) TwoFactorSecret() string {
+	return ctx.Secret
+}
+
+// Comment
+func (ctx *Admin) TwoFactorRecoveryCodes() []string {
+	return ctx.Recovery
+}
+
+// Comment
+func (ctx *Admin) ReplaceRecoveryCodes(codes []string) error {
+	ctx.Recovery = codes
+
+	return nil
+}
+
+type admins map[string]*Admin
+
+// Comment
+func (ctx admins) RetrieveById(id string) (Authenticatable, error) {
+	if admin, ok := ctx[id]; ok {
+		return admin, nil
+	}
+
+	return nil, nil
+}
+
+// Comment
+func (ctx admins) RetrieveByToken(token string) (Authenticatable, error) {
+	return nil, nil
+}
+
+// Comment
+func (ctx admins) RetrieveByCredentials(credentials Credentials) (Authenticatable, error) {
+	return ctx.RetrieveById(credentials["id"])
+}
+
+// Comment
+func (ctx admins) ValidateCredentials(user Authenticatable, credentials Credentials) bool {
+	return true
+}
+
+func TestTwoFactor(t *testing.T) {
+	secret, _ := totp.GenerateSecret()
+	plain, hashed, err := RecoveryCodes(RECOVERY_CODES)
+
+	if err != nil {
+		t.Fatalf("Something went wrong when trying to generate recovery codes: %v", err)
+	}
+
+	admin := &Admin{ID: "1", Secret: secret, Recovery: hashed}
+	provider := admins{"1": admin, "2": &Admin{ID: "2"}}
+	twoFactor := NewTwoFactor("Acme").Store(NewMemoryTwoFactorStore())
+	sessions := http.InitSession("session", []byte(str.Random(10)), http.NewMemorySessionStore())
+
+	Register("two-factor", SessionGuard, provider)
+
+	request := func(t *testing.T, headers types.Headers) *http.Request {
+		req, err := http.NewRequest(http.METHOD_GET, "http://app.test/admin", "HTTP/1.1", headers, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		req.Session = sessions.Session(req)
+		req.Response.Session = req.Session
+
+		Auth(req).ShouldUse("two-factor")
+
+		return req
+	}
+
+	handle := func(req *http.Request) *http.Response {
+		return RequireTwoFactor()(req, req.Response, func() *http.Response {
+			return req.Response.Html("<h1>Admin</h1>")
+		})
+	}
+
+	t.Run("TestRecoveryCodes", func(t *testing.T) {
+		if len(plain) != RECOVERY_CODES || plain[0] == plain[1] || hashed[0] != HashToken(plain[0]) {
+			t.Fatalf("Expected (%d) unique hashed recovery codes", RECOVERY_CODES)
+		}
+	})
+
+	t.Run("TestPending", func(t *testing.T) {
+		req := request(t, types.Headers{})
+
+		if !Auth(req).Attempt(Credentials{"id": "1"}) || !TwoFactorPending(req) {
+			t.Fatalf("Expected two factor challenge to be pending after login")
+		}
+
+		if Auth(req).Check() || Auth(req).User() != nil || TwoFactorUser(req) != admin {
+			t.Fatalf("Expected user to be a guest until the two factor challenge is passed")
+		}
+
+		next := request(t, types.Headers{})
+		next.Session = req.Session
+
+		if Auth(next).Check() || Auth(next).Id() != "" {
+			t.Fatalf("Expected pending session to be a guest on the next request")
+		}
+
+		res := handle(req)
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_TEMPORARY_REDIRECT) || res.Bag.Redirect.To != TWO_FACTOR_CHALLENGE_PATH {
+			t.Fatalf("Expected redirect to (%s) but got (%d, %s)", TWO_FACTOR_CHALLENGE_PATH, res.StatusCode, res.Bag.Redirect.To)
+		}
+
+		req = request(t, types.Headers{"accept": "application/json"})
+		Auth(req).Login(admin)
+
+		if res := handle(req); res.StatusCode != int(http.HTTP_RESPONSE_FORBIDDEN) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_FORBIDDEN, res.StatusCode)
+		}
+
+		req = request(t, types.Headers{})
+		Auth(req).Login(provider["2"])
+
+		if TwoFactorPending(req) || handle(req).StatusCode != int(http.HTTP_RESPONSE_OK) {
+			t.Fatalf("Expected user without two factor to pass")
+		}
+	})
+
+	t.Run("TestChallenge", func(t *testing.T) {
+		req := request(t, types.Headers{})
+		Auth(req).Login(admin)
+
+		if err := twoFactor.Challenge(req, Credentials{CODE_KEY: "000000"}); err != ErrInvalidTwoFactorCode {
+			t.Fatalf("Expected error to be (%v) but got (%v)", ErrInvalidTwoFactorCode, err)
+		}
+
+		code, _ := totp.GenerateCode(secret, time.Now(), totp.Options{})
+
+		if err := twoFactor.Challenge(req, Credentials{CODE_KEY: code}); err != nil {
+			t.Fatalf("Something went wrong when trying to pass challenge: %v", err)
+		}
+
+		if TwoFactorPending(req) || handle(req).StatusCode != int(http.HTTP_RESPONSE_OK) || !Auth(req).Check() || TwoFactorUser(req) != nil {
+			t.Fatalf("Expected two factor challenge to be passed")
+		}
+
+		if twoFactor.Verify(admin, code) {
+			t.Fatalf("Expected replayed code to be rejected")
+		}
+
+		previous, _ := totp.GenerateCode(secret, time.Now().Add(-totp.PERIOD), totp.Options{})
+
+		if twoFactor.Verify(admin, previous) {
+			t.Fatalf("Expected code from an earlier step to be rejected")
+		}
+	})
+
+	t.Run("TestRecover", func(t *testing.T) {
+		req := request(t, types.Headers{})
+		Auth(req).Login(admin)
+
+		if err := twoFactor.Challenge(req, Credentials{RECOVERY_CODE_KEY: plain[3]}); err != nil {
+			t.Fatalf("Something went wrong when trying to recover: %v", err)
+		}
+
+		if len(admin.Recovery) != RECOVERY_CODES-1 || TwoFactorPending(req) {
+			t.Fatalf("Expected recovery code to be consumed")
+		}
+
+		if twoFactor.Recover(admin, plain[3]) {
+			t.Fatalf("Expected used recovery code to be rejected")
+		}
+
+		if err := twoFactor.Challenge(request(t, types.Headers{}), Credentials{CODE_KEY: "123456"}); err != ErrTwoFactorNotEnabled {
+			t.Fatalf("Expected error to be (%v) but got (%v)", ErrTwoFactorNotEnabled, err)
+		}
+	})
+
+	t.Run("TestConcurrentReplay", func(t *testing.T) {
+		orm.DB.Add("two-factor", sqlite.Connect(":memory:"))
+
+		store := NewDatabaseTwoFactorStore("two-factor")
+
+		if err := store.Migrate(); err != nil {
+			t.Fatalf("Something went wrong when trying to migrate two factor store: %v", err)
+		}
+
+		for _, twoFactor := range []*TwoFactor{NewTwoFactor("Acme").Store(NewMemoryTwoFactorStore()), NewTwoFactor("Acme").Store(store)} {
+			user := &Admin{ID: str.Random(10), Secret: secret}
+			code, _ := totp.GenerateCode(secret, time.Now(), totp.Options{})
+			passed := make(chan bool, 10)
+
+			for i := 0; i < cap(passed); i++ {
+				go func() { passed <- twoFactor.Verify(user, code) }()
+			}
+
+			count := 0
+
+			for i := 0; i < cap(passed); i++ {
+				if <-passed {
+					count++
+				}
+			}
+
+			if count != 1 {
+				t.Fatalf("Expected code to be accepted (%d) time but got (%d)", 1, count)
+			}
+
+			// A flushed response cache must not allow used codes again.
+			cache.Flush()
+
+			if twoFactor.Verify(user, code) {
+				t.Fatalf("Expected replayed code to be rejected")
+			}
+
+			// Every request loads its own copy of the user, so the codes are only redeemed once by the store.
+			plain, codes, _ := RecoveryCodes(1)
+			redeemed := make(chan bool, 10)
+
+			for i := 0; i < cap(redeemed); i++ {
+				go func() { redeemed <- twoFactor.Recover(&Admin{ID: user.ID, Secret: secret, Recovery: codes}, plain[0]) }()
+			}
+
+			count = 0
+
+			for i := 0; i < cap(redeemed); i++ {
+				if <-redeemed {
+					count++
+				}
+			}
+
+			if count != 1 {
+				t.Fatalf("Expected recovery code to be accepted (%d) time but got (%d)", 1, count)
+			}
+		}
+	})
+
+	t.Run("TestConfiguredSkew", func(t *testing.T) {
+		store := NewMemoryTwoFactorStore()
+		options := totp.Options{Skew: 3}
+		twoFactor := NewTwoFactor("Acme").Options(options).Store(store)
+		user := &Admin{ID: str.Random(10), Secret: secret}
+		code, _ := totp.GenerateCode(secret, time.Now().Add(-totp.PERIOD*3), options)
+
+		if !twoFactor.Verify(user, code) {
+			t.Fatalf("Expected code inside the configured skew to be accepted")
+		}
+
+		// The step must be remembered for as long as the configured skew still accepts its code.
+		if ttl := time.Until(store.steps[user.ID].expires); ttl < totp.PERIOD*7 {
+			t.Fatalf("Expected used step to be kept for (%s) but got (%s)", totp.PERIOD*7, ttl)
+		}
+
+		if twoFactor.Verify(user, code) {
+			t.Fatalf("Expected replayed code to be rejected")
+		}
+	})
+}
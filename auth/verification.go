@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+)
+
+const (
+	EMAIL_VERIFICATION_PATH    = "email/verify"
+	EMAIL_VERIFICATION_VIEW    = "verify-email"
+	EMAIL_VERIFICATION_SUBJECT = "Verify Email Address"
+	EMAIL_VERIFICATION_EXPIRE  = time.Hour
+)
+
+const UnverifiedEmailMessage = "Your email address is not verified."
+
+var (
+	ErrInvalidVerificationLink = errors.New("this email verification link is invalid")
+	ErrCannotVerifyEmail       = errors.New("user can not verify email")
+)
+
+type MustVerifyEmail interface {
+	Authenticatable
+	AuthEmail() string
+	HasVerifiedEmail() bool
+	MarkEmailAsVerified() error
+}
+
+type EmailVerifier struct {
+	expire time.Duration
+	mailer Mailer
+	view   *http.View
+}
+
+// Comment
+func NewEmailVerifier() *EmailVerifier {
+	return &EmailVerifier{
+		expire: EMAIL_VERIFICATION_EXPIRE,
+		mailer: DefaultMailer,
+		view:   DefaultView(),
+	}
+}
+
+// Comment
+func (ctx *EmailVerifier) Expire(expire time.Duration) *EmailVerifier {
+	ctx.expire = expire
+
+	return ctx
+}
+
+// Comment
+func (ctx *EmailVerifier) Mailer(mailer Mailer) *EmailVerifier {
+	ctx.mailer = mailer
+
+	return ctx
+}
+
+// Comment
+func (ctx *EmailVerifier) View(view *http.View) *EmailVerifier {
+	ctx.view = view
+
+	return ctx
+}
+
+// Comment
+func emailHash(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(email)))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Comment
+func (ctx *EmailVerifier) VerificationUrl(server *http.HTTP, user MustVerifyEmail) string {
+	return server.TemporarySignedUrl(strings.Join([]string{EMAIL_VERIFICATION_PATH, user.AuthIdentifier(), emailHash(user.AuthEmail())}, "/"), ctx.expire)
+}
+
+// Comment
+func (ctx *EmailVerifier) Send(req *http.Request, user MustVerifyEmail) error {
+	return notify(req, ctx.view, ctx.mailer, user.AuthEmail(), EMAIL_VERIFICATION_SUBJECT, EMAIL_VERIFICATION_VIEW, http.ViewData{
+		"link": ctx.VerificationUrl(req.Server, user),
+	})
+}
+
+// Comment
+func (ctx *EmailVerifier) Verify(req *http.Request) error {
+	if !req.HasValidSignature() {
+		return ErrInvalidVerificationLink
+	}
+
+	user, ok := Auth(req).User().(MustVerifyEmail)
+
+	if !ok || user == nil {
+		return ErrCannotVerifyEmail
+	}
+
+	if req.Parameters.Get("id") != user.AuthIdentifier() {
+		return ErrInvalidVerificationLink
+	}
+
+	if subtle.ConstantTimeCompare([]byte(req.Parameters.Get("hash")), []byte(emailHash(user.AuthEmail()))) != 1 {
+		return ErrInvalidVerificationLink
+	}
+
+	if user.HasVerifiedEmail() {
+		return nil
+	}
+
+	return user.MarkEmailAsVerified()
+}
+
+// Comment
+func Verified(redirect string) http.Middleware {
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		if user, ok := Auth(req).User().(MustVerifyEmail); ok && user != nil && user.HasVerifiedEmail() {
+			return next()
+		}
+
+		if strings.ToLower(req.ContentType()) == "application/json" || req.WantsJson() {
+			return res.Error(http.Forbidden(UnverifiedEmailMessage))
+		}
+
+		return res.Redirect(redirect)
+	}
+}
@@ -0,0 +1,244 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucas11776-golang/http/cache"
+	h "github.com/lucas11776-golang/http/utils/headers"
+)
+
+type CacheControl string
+
+const (
+	CACHE_PUBLIC  CacheControl = "public"
+	CACHE_PRIVATE CacheControl = "private"
+)
+
+const REQUEST_PRIVATE_KEY = "cache.private"
+
+type cachedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+	Public bool        `json:"public"`
+}
+
+// Comment
+func (ctx *Request) Private() *Request {
+	return ctx.Set(REQUEST_PRIVATE_KEY, true)
+}
+
+// Comment
+func isPrivate(req *Request) bool {
+	if req.Header.Get("Authorization") != "" || req.Get(REQUEST_PRIVATE_KEY) == true {
+		return true
+	}
+
+	return req.Session != nil && req.Session.User() != ""
+}
+
+// Comment
+func isPublic(res *Response) bool {
+	for _, directive := range strings.Split(res.Header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), string(CACHE_PUBLIC)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Comment
+func (ctx *Response) Cache(maxAge int, control CacheControl) *Response {
+	return ctx.SetHeader("cache-control", strings.Join([]string{string(control), "max-age=" + strconv.Itoa(maxAge)}, ", "))
+}
+
+// Comment
+func (ctx *Response) ETag(tag string) *Response {
+	if !strings.HasPrefix(tag, `"`) {
+		tag = strconv.Quote(tag)
+	}
+
+	return ctx.SetHeader("etag", tag)
+}
+
+// Comment
+func (ctx *Response) WeakETag(tag string) *Response {
+	return ctx.ETag(tag).SetHeader("etag", "W/"+ctx.GetHeader("etag"))
+}
+
+// Comment
+func isCacheable(method string) bool {
+	switch Method(strings.ToUpper(method)) {
+	case METHOD_GET, METHOD_HEAD:
+		return true
+	default:
+		return false
+	}
+}
+
+// Comment
+func notModified(req *Request, res *Response) *Response {
+	if res == nil || res.StatusCode != int(HTTP_RESPONSE_OK) || !isCacheable(req.Method) {
+		return res
+	}
+
+	if !h.MatchETag(req.Header.Get("If-None-Match"), res.GetHeader("etag")) {
+		return res
+	}
+
+	return res.SetStatus(HTTP_RESPONSE_NOT_MODIFIED).SetBody([]byte{})
+}
+
+// Comment
+func responseCacheKey(req *Request, vary []string) string {
+	key := []string{"response", strings.ToUpper(req.Method), strings.ToLower(req.Host), req.URL.Path, req.URL.Query().Encode()}
+
+	for _, header := range vary {
+		key = append(key, strings.Join([]string{strings.ToLower(header), req.Header.Get(header)}, "="))
+	}
+
+	return strings.Join(key, "|")
+}
+
+// Comment
+func responseVaryKey(req *Request) string {
+	return strings.Join([]string{"vary", responseCacheKey(req, nil)}, "|")
+}
+
+// Comment
+func responseVary(res *Response) []string {
+	vary := []string{}
+
+	for _, value := range res.Header.Values("Vary") {
+		for _, header := range strings.Split(value, ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				vary = append(vary, header)
+			}
+		}
+	}
+
+	return vary
+}
+
+// Comment
+func cachedResponseHit(req *Request, res *Response) *Response {
+	vary, ok := cache.Get(responseVaryKey(req))
+
+	if !ok {
+		return nil
+	}
+
+	headers := []string{}
+
+	if len(vary) != 0 {
+		headers = strings.Split(string(vary), ",")
+	}
+
+	data, ok := cache.Get(responseCacheKey(req, headers))
+
+	if !ok {
+		return nil
+	}
+
+	var cached cachedResponse
+
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil
+	}
+
+	// Authenticated visitors only share responses that were explicitly marked public.
+	if !cached.Public && isPrivate(req) {
+		return nil
+	}
+
+	for k, v := range cached.Header {
+		res.Header[k] = v
+	}
+
+	return res.SetStatus(Status(cached.Status)).SetBody(cached.Body)
+}
+
+// Comment
+func storeCachedResponse(req *Request, res *Response, ttl time.Duration, tags []string) {
+	if res.StatusCode != int(HTTP_RESPONSE_OK) || res.Header.Get("Set-Cookie") != "" {
+		return
+	}
+
+	// A cached csp nonce would be replayed to every visitor and match the policy of none of them.
+	if _, ok := req.Get(REQUEST_CSP_NONCE_KEY).(string); ok {
+		return
+	}
+
+	public := isPublic(res)
+
+	// The session cookie is only written after the response left the pipeline, so requests of signed in
+	// users, requests with credentials and routes that checked authentication are never shared unless public.
+	if !public && isPrivate(req) {
+		return
+	}
+
+	control := strings.ToLower(res.Header.Get("Cache-Control"))
+
+	if strings.Contains(control, "no-store") || strings.Contains(control, string(CACHE_PRIVATE)) {
+		return
+	}
+
+	vary := responseVary(res)
+
+	for _, header := range vary {
+		if header == "*" {
+			return
+		}
+	}
+
+	body, err := io.ReadAll(res.Body)
+
+	res.SetBody(body)
+
+	if err != nil {
+		return
+	}
+
+	data, err := json.Marshal(cachedResponse{
+		Status: res.StatusCode,
+		Header: res.Header.Clone(),
+		Body:   body,
+		Public: public,
+	})
+
+	if err != nil {
+		return
+	}
+
+	cache.Set(responseVaryKey(req), []byte(strings.Join(vary, ",")), ttl, tags...)
+	cache.Set(responseCacheKey(req, vary), data, ttl, tags...)
+}
+
+// Comment
+func ResponseCache(ttl time.Duration, tags ...string) Middleware {
+	return func(req *Request, res *Response, next Next) *Response {
+		if !isCacheable(req.Method) {
+			return next()
+		}
+
+		if cached := cachedResponseHit(req, res); cached != nil {
+			return cached.SetHeader("x-cache", "HIT")
+		}
+
+		r := next()
+
+		if r == nil {
+			return r
+		}
+
+		storeCachedResponse(req, r, ttl, tags)
+
+		return r.SetHeader("x-cache", "MISS")
+	}
+}
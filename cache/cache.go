@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type Store interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration, tags ...string) error
+	Delete(key string) error
+	Forget(tags ...string) error
+	Flush() error
+}
+
+var (
+	mutex sync.RWMutex
+	store Store = NewMemoryStore()
+)
+
+// Comment
+func Use(s Store) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	store = s
+}
+
+// Comment
+func Default() Store {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return store
+}
+
+// Comment
+func Get(key string) ([]byte, bool) {
+	return Default().Get(key)
+}
+
+// Comment
+func Set(key string, value []byte, ttl time.Duration, tags ...string) error {
+	return Default().Set(key, value, ttl, tags...)
+}
+
+// Comment
+func Delete(key string) error {
+	return Default().Delete(key)
+}
+
+// Comment
+func Forget(tags ...string) error {
+	return Default().Forget(tags...)
+}
+
+// Comment
+func Flush() error {
+	return Default().Flush()
+}
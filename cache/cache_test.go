@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("TestSetGetDelete", func(t *testing.T) {
+		store := NewMemoryStore()
+
+		store.Set("user:1", []byte("jeo@doe.com"), 0)
+
+		value, ok := store.Get("user:1")
+
+		if !ok || string(value) != "jeo@doe.com" {
+			t.Fatalf("Expected cache value to be (%s) but got (%s)", "jeo@doe.com", string(value))
+		}
+
+		store.Delete("user:1")
+
+		if _, ok := store.Get("user:1"); ok {
+			t.Fatalf("Expected deleted cache value to be missing")
+		}
+	})
+
+	t.Run("TestExpire", func(t *testing.T) {
+		store := NewMemoryStore()
+
+		store.Set("user:1", []byte("jeo@doe.com"), time.Millisecond*10)
+
+		time.Sleep(time.Millisecond * 20)
+
+		if _, ok := store.Get("user:1"); ok {
+			t.Fatalf("Expected expired cache value to be missing")
+		}
+	})
+
+	t.Run("TestForgetTags", func(t *testing.T) {
+		store := NewMemoryStore()
+
+		store.Set("products:1", []byte("keyboard"), 0, "products")
+		store.Set("products:2", []byte("mouse"), 0, "products", "featured")
+		store.Set("users:1", []byte("jeo@doe.com"), 0, "users")
+
+		store.Forget("products")
+
+		if _, ok := store.Get("products:1"); ok {
+			t.Fatalf("Expected tagged cache value to be forgotten")
+		}
+
+		if _, ok := store.Get("products:2"); ok {
+			t.Fatalf("Expected tagged cache value to be forgotten")
+		}
+
+		if _, ok := store.Get("users:1"); !ok {
+			t.Fatalf("Expected untagged cache value to remain")
+		}
+	})
+
+	t.Run("TestDefaultStore", func(t *testing.T) {
+		store := NewMemoryStore()
+
+		Use(store)
+
+		Set("products:1", []byte("keyboard"), 0, "products")
+
+		if _, ok := store.Get("products:1"); !ok {
+			t.Fatalf("Expected value to be stored in default store")
+		}
+
+		Forget("products")
+
+		if _, ok := Get("products:1"); ok {
+			t.Fatalf("Expected tagged cache value to be forgotten")
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type item struct {
+	value   []byte
+	tags    []string
+	expires time.Time
+}
+
+type MemoryStore struct {
+	mutex sync.Mutex
+	items map[string]*item
+	tags  map[string]map[string]struct{}
+}
+
+// Comment
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		items: make(map[string]*item),
+		tags:  make(map[string]map[string]struct{}),
+	}
+}
+
+// Comment
+func (ctx *item) expired() bool {
+	return !ctx.expires.IsZero() && time.Now().After(ctx.expires)
+}
+
+// Comment
+func (ctx *MemoryStore) Get(key string) ([]byte, bool) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	item, ok := ctx.items[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	if item.expired() {
+		ctx.delete(key)
+
+		return nil, false
+	}
+
+	return item.value, true
+}
+
+// Comment
+func (ctx *MemoryStore) Set(key string, value []byte, ttl time.Duration, tags ...string) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.delete(key)
+
+	item := &item{value: value, tags: tags}
+
+	if ttl > 0 {
+		item.expires = time.Now().Add(ttl)
+	}
+
+	ctx.items[key] = item
+
+	for _, tag := range tags {
+		if _, ok := ctx.tags[tag]; !ok {
+			ctx.tags[tag] = make(map[string]struct{})
+		}
+
+		ctx.tags[tag][key] = struct{}{}
+	}
+
+	return nil
+}
+
+// Comment
+func (ctx *MemoryStore) delete(key string) {
+	item, ok := ctx.items[key]
+
+	if !ok {
+		return
+	}
+
+	for _, tag := range item.tags {
+		delete(ctx.tags[tag], key)
+
+		if len(ctx.tags[tag]) == 0 {
+			delete(ctx.tags, tag)
+		}
+	}
+
+	delete(ctx.items, key)
+}
+
+// Comment
+func (ctx *MemoryStore) Delete(key string) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.delete(key)
+
+	return nil
+}
+
+// Comment
+func (ctx *MemoryStore) Forget(tags ...string) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	for _, tag := range tags {
+		for key := range ctx.tags[tag] {
+			ctx.delete(key)
+		}
+	}
+
+	return nil
+}
+
+// Comment
+func (ctx *MemoryStore) Flush() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.items = make(map[string]*item)
+	ctx.tags = make(map[string]map[string]struct{})
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package http
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucas11776-golang/http/cache"
+	"github.com/lucas11776-golang/http/types"
+)
+
+func TestCache(t *testing.T) {
+	request := func(t *testing.T, server *HTTP, path string, headers types.Headers) *Response {
+		req, err := NewRequest("GET", path, "HTTP/1.1", headers, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		return server.HandleRequest(server.NewRequest(req.Request, nil))
+	}
+
+	body := func(res *Response) string {
+		data, _ := io.ReadAll(res.Body)
+
+		return string(data)
+	}
+
+	t.Run("TestCacheControl", func(t *testing.T) {
+		res := InitResponse().Cache(3600, CACHE_PUBLIC)
+
+		if res.GetHeader("cache-control") != "public, max-age=3600" {
+			t.Fatalf("Expected cache-control to be (%s) but got (%s)", "public, max-age=3600", res.GetHeader("cache-control"))
+		}
+
+		res.Cache(60, CACHE_PRIVATE)
+
+		if res.GetHeader("cache-control") != "private, max-age=60" {
+			t.Fatalf("Expected cache-control to be (%s) but got (%s)", "private, max-age=60", res.GetHeader("cache-control"))
+		}
+	})
+
+	t.Run("TestETag", func(t *testing.T) {
+		if etag := InitResponse().ETag("v1").GetHeader("etag"); etag != `"v1"` {
+			t.Fatalf("Expected etag to be (%s) but got (%s)", `"v1"`, etag)
+		}
+
+		if etag := InitResponse().WeakETag("v1").GetHeader("etag"); etag != `W/"v1"` {
+			t.Fatalf("Expected etag to be (%s) but got (%s)", `W/"v1"`, etag)
+		}
+	})
+
+	t.Run("TestNotModified", func(t *testing.T) {
+		server := Server("127.0.0.1", 0)
+
+		server.Route().Get("products/1", func(req *Request, res *Response) *Response {
+			return res.ETag("product-1-v2").Json(map[string]string{"name": "keyboard"})
+		})
+
+		res := request(t, server, "products/1", types.Headers{"if-none-match": `"product-1-v2"`})
+
+		if res.StatusCode != int(HTTP_RESPONSE_NOT_MODIFIED) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", HTTP_RESPONSE_NOT_MODIFIED, res.StatusCode)
+		}
+
+		if b := body(res); b != "" {
+			t.Fatalf("Expected not modified body to be empty but got (%s)", b)
+		}
+
+		res = request(t, server, "products/1", types.Headers{"if-none-match": `"product-1-v1"`})
+
+		if res.StatusCode != int(HTTP_RESPONSE_OK) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", HTTP_RESPONSE_OK, res.StatusCode)
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestResponseCache", func(t *testing.T) {
+		cache.Use(cache.NewMemoryStore())
+
+		server := Server("127.0.0.1", 0)
+		calls := 0
+
+		server.Route().Get("products", func(req *Request, res *Response) *Response {
+			calls++
+
+			return res.SetHeader("vary", "Accept-Language").
+				Html(strings.Join([]string{req.URL.Query().Get("page"), req.GetHeader("accept-language"), strconv.Itoa(calls)}, ","))
+		}).Middleware(ResponseCache(time.Minute, "products"))
+
+		tests := []struct {
+			path     string
+			language string
+			expected string
+			hit      string
+		}{
+			{"products?page=1", "en", "1,en,1", "MISS"},
+			{"products?page=1", "en", "1,en,1", "HIT"},
+			{"products?page=2", "en", "2,en,2", "MISS"},
+			{"products?page=1", "fr", "1,fr,3", "MISS"},
+			{"products?page=1", "fr", "1,fr,3", "HIT"},
+		}
+
+		for _, test := range tests {
+			res := request(t, server, test.path, types.Headers{"accept-language": test.language})
+
+			if b := body(res); b != test.expected {
+				t.Fatalf("Expected response body for (%s) to be (%s) but got (%s)", test.path, test.expected, b)
+			}
+
+			if res.GetHeader("x-cache") != test.hit {
+				t.Fatalf("Expected x-cache for (%s) to be (%s) but got (%s)", test.path, test.hit, res.GetHeader("x-cache"))
+			}
+		}
+
+		cache.Forget("products")
+
+		if b := body(request(t, server, "products?page=1", types.Headers{"accept-language": "fr"})); b != "1,fr,4" {
+			t.Fatalf("Expected forgotten response body to be (%s) but got (%s)", "1,fr,4", b)
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestResponseCacheHost", func(t *testing.T) {
+		cache.Use(cache.NewMemoryStore())
+
+		server := Server("127.0.0.1", 0)
+
+		server.Route().Subdomain("{account}", func(route *Router) {
+			route.Get("profile", func(req *Request, res *Response) *Response {
+				return res.Html(req.Host)
+			})
+		}, ResponseCache(time.Minute))
+
+		for _, host := range []string{"alice.example.com", "bob.example.com", "alice.example.com"} {
+			req, err := NewRequest("GET", "profile", "HTTP/1.1", types.Headers{}, strings.NewReader(""))
+
+			if err != nil {
+				t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+			}
+
+			req.Host = host
+
+			if b := body(server.HandleRequest(server.NewRequest(req.Request, nil))); b != host {
+				t.Fatalf("Expected response body for (%s) to be (%s) but got (%s)", host, host, b)
+			}
+		}
+
+		server.Close()
+	})
+	t.Run("TestResponseCacheSessions", func(t *testing.T) {
+		cache.Use(cache.NewMemoryStore())
+
+		server := Server("127.0.0.1", 0)
+
+		login := func(req *Request, res *Response, next Next) *Response {
+			if user := req.GetHeader("x-user"); user != "" {
+				req.Session.SetUser(user)
+			}
+
+			return next()
+		}
+
+		authenticated := func(req *Request, res *Response, next Next) *Response {
+			if req.GetHeader("x-user") == "" {
+				return res.SetStatus(HTTP_RESPONSE_UNAUTHORIZED)
+			}
+
+			req.Private()
+
+			return next()
+		}
+
+		server.Route().Get("dashboard", func(req *Request, res *Response) *Response {
+			return res.Html(req.Session.User())
+		}).Middleware(login, ResponseCache(time.Minute))
+
+		server.Route().Get("news", func(req *Request, res *Response) *Response {
+			return res.Cache(60, CACHE_PUBLIC).Html(req.Session.User())
+		}).Middleware(login, ResponseCache(time.Minute))
+
+		server.Route().Get("account", func(req *Request, res *Response) *Response {
+			return res.Html(req.GetHeader("x-user"))
+		}).Middleware(ResponseCache(time.Minute), authenticated)
+
+		tests := []struct {
+			path     string
+			user     string
+			expected string
+			hit      string
+		}{
+			{"dashboard", "1", "1", "MISS"},
+			{"dashboard", "2", "2", "MISS"},
+			{"dashboard", "", "", "MISS"},
+			{"dashboard", "1", "1", "MISS"},
+			{"news", "1", "1", "MISS"},
+			{"news", "2", "1", "HIT"},
+			{"account", "1", "1", "MISS"},
+			{"account", "", "", "MISS"},
+		}
+
+		for _, test := range tests {
+			res := request(t, server, test.path, types.Headers{"x-user": test.user})
+
+			if b := body(res); b != test.expected {
+				t.Fatalf("Expected response body for (%s) of user (%s) to be (%s) but got (%s)", test.path, test.user, test.expected, b)
+			}
+
+			if res.GetHeader("x-cache") != test.hit {
+				t.Fatalf("Expected x-cache for (%s) of user (%s) to be (%s) but got (%s)", test.path, test.user, test.hit, res.GetHeader("x-cache"))
+			}
+		}
+
+		if res := request(t, server, "dashboard", types.Headers{"authorization": "Bearer token"}); res.GetHeader("x-cache") != "MISS" {
+			t.Fatalf("Expected x-cache with authorization header to be (%s) but got (%s)", "MISS", res.GetHeader("x-cache"))
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestResponseCacheCspNonce", func(t *testing.T) {
+		cache.Use(cache.NewMemoryStore())
+
+		server := Server("127.0.0.1", 0)
+
+		server.Route().Get("checkout", func(req *Request, res *Response) *Response {
+			return res.Html(req.CspNonce())
+		}).Middleware(ResponseCache(time.Minute))
+
+		first := request(t, server, "checkout", types.Headers{})
+		second := request(t, server, "checkout", types.Headers{})
+
+		if second.GetHeader("x-cache") != "MISS" {
+			t.Fatalf("Expected x-cache to be (%s) but got (%s)", "MISS", second.GetHeader("x-cache"))
+		}
+
+		if body(first) == body(second) {
+			t.Fatalf("Expected response with csp nonce to not be cached")
+		}
+
+		server.Close()
+	})
+}
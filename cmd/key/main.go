@@ -0,0 +1,12 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lucas11776-golang/http/encryption/key"
+)
+
+// Generates a new application key e.g. go run github.com/lucas11776-golang/http/cmd/key >> .env
+func main() {
+	fmt.Printf("%s=%s\n", key.APP_KEY, key.Generate())
+}
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/lucas11776-golang/http/encryption/crypt"
+	"github.com/lucas11776-golang/http/encryption/key"
+)
+
+type SameSite int
+
+const (
+	COOKIE_SAME_SITE_DEFAULT SameSite = SameSite(http.SameSiteDefaultMode)
+	COOKIE_SAME_SITE_LAX     SameSite = SameSite(http.SameSiteLaxMode)
+	COOKIE_SAME_SITE_STRICT  SameSite = SameSite(http.SameSiteStrictMode)
+	COOKIE_SAME_SITE_NONE    SameSite = SameSite(http.SameSiteNoneMode)
+)
+
+type Cookie struct {
+	Name        string
+	Value       string
+	Path        string
+	Domain      string
+	Expires     time.Time
+	MaxAge      int
+	Secure      bool
+	HttpOnly    bool
+	SameSite    SameSite
+	Partitioned bool
+}
+
+// Comment
+func NewCookie(name string, value string) *Cookie {
+	return &Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: COOKIE_SAME_SITE_LAX,
+	}
+}
+
+// Comment
+func (ctx *Cookie) String() string {
+	cookie := &http.Cookie{
+		Name:        ctx.Name,
+		Value:       ctx.Value,
+		Path:        ctx.Path,
+		Domain:      ctx.Domain,
+		Expires:     ctx.Expires,
+		MaxAge:      ctx.MaxAge,
+		Secure:      ctx.Secure,
+		HttpOnly:    ctx.HttpOnly,
+		SameSite:    http.SameSite(ctx.SameSite),
+		Partitioned: ctx.Partitioned,
+	}
+
+	return cookie.String()
+}
+
+// Comment
+func (ctx *HTTP) SetKey(secret []byte) *HTTP {
+	return ctx.SetKeyring(key.NewKeyring(secret))
+}
+
+// Comment
+func (ctx *HTTP) SetKeyring(keyring *key.Keyring) *HTTP {
+	ctx.keyring = keyring
+
+	if sessions, ok := ctx.Get("session").(*Sessions); ok && sessions.keyring {
+		sessions.store.Codecs = securecookie.CodecsFromPairs(keyring.Pairs()...)
+	}
+
+	return ctx
+}
+
+// Comment
+func (ctx *HTTP) Key() []byte {
+	if ctx.keyring == nil {
+		return nil
+	}
+
+	return ctx.keyring.Current()
+}
+
+// Comment
+func (ctx *HTTP) Keyring() *key.Keyring {
+	return ctx.keyring
+}
+
+// Comment
+func cookieKeyring(req *Request) *key.Keyring {
+	if req == nil || req.Server == nil {
+		return nil
+	}
+
+	return req.Server.Keyring()
+}
+
+// Comment
+func (ctx *Request) Cookie(name string) string {
+	cookie, err := ctx.Request.Cookie(name)
+
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
+// Comment
+func (ctx *Request) SignedCookie(name string) string {
+	value := ctx.Cookie(name)
+	index := strings.LastIndex(value, ".")
+	keyring := cookieKeyring(ctx)
+
+	if index == -1 || keyring == nil {
+		return ""
+	}
+
+	for _, k := range keyring.SigningKeys() {
+		if crypt.ValidSignature(k, strings.Join([]string{name, value[:index]}, "|"), value[index+1:]) {
+			return value[:index]
+		}
+	}
+
+	return ""
+}
+
+// Comment
+func (ctx *Request) EncryptedCookie(name string) string {
+	keyring := cookieKeyring(ctx)
+
+	if keyring == nil {
+		return ""
+	}
+
+	for _, k := range keyring.EncryptionKeys() {
+		if value, err := crypt.Decrypt(k, ctx.Cookie(name), name); err == nil {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// Comment
+func (ctx *Response) SetCookie(cookie *Cookie) *Response {
+	ctx.Header.Add("Set-Cookie", cookie.String())
+
+	return ctx
+}
+
+// Comment
+func (ctx *Response) SetSignedCookie(cookie *Cookie) *Response {
+	keyring := cookieKeyring(ctx.Request)
+
+	if keyring == nil {
+		return ctx
+	}
+
+	signed := *cookie
+
+	signed.Value = strings.Join([]string{
+		cookie.Value,
+		crypt.Signature(keyring.SigningKey(), strings.Join([]string{cookie.Name, cookie.Value}, "|")),
+	}, ".")
+
+	return ctx.SetCookie(&signed)
+}
+
+// Comment
+func (ctx *Response) SetEncryptedCookie(cookie *Cookie) *Response {
+	keyring := cookieKeyring(ctx.Request)
+
+	if keyring == nil {
+		return ctx
+	}
+
+	value, err := crypt.Encrypt(keyring.EncryptionKey(), cookie.Value, cookie.Name)
+
+	if err != nil {
+		return ctx
+	}
+
+	encrypted := *cookie
+
+	encrypted.Value = value
+
+	return ctx.SetCookie(&encrypted)
+}
+
+// Comment
+func (ctx *Response) ForgetCookie(name string) *Response {
+	return ctx.SetCookie(&Cookie{
+		Name:    name,
+		Path:    "/",
+		MaxAge:  -1,
+		Expires: time.Unix(0, 0),
+	})
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucas11776-golang/http/encryption/key"
+	"github.com/lucas11776-golang/http/types"
+)
+
+func TestCookie(t *testing.T) {
+	server := Server("127.0.0.1", 0)
+
+	request := func(t *testing.T, cookie string) *Request {
+		req, err := NewRequest("GET", "/", "HTTP/1.1", types.Headers{"cookie": cookie}, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		req.Server = server
+		req.Response.Request = req
+
+		return req
+	}
+
+	setCookie := func(t *testing.T, res *Response, name string) *http.Cookie {
+		for _, line := range res.Header.Values("Set-Cookie") {
+			cookie, err := http.ParseSetCookie(line)
+
+			if err != nil {
+				t.Fatalf("Failed to parse set-cookie: %v", err)
+			}
+
+			if cookie.Name == name {
+				return cookie
+			}
+		}
+
+		t.Fatalf("Expected response to set cookie (%s)", name)
+
+		return nil
+	}
+
+	t.Run("TestRequestCookie", func(t *testing.T) {
+		req := request(t, "theme=dark; locale=en")
+
+		if req.Cookie("theme") != "dark" {
+			t.Fatalf("Expected cookie theme to be (%s) but got (%s)", "dark", req.Cookie("theme"))
+		}
+
+		if req.Cookie("missing") != "" {
+			t.Fatalf("Expected missing cookie to be empty but got (%s)", req.Cookie("missing"))
+		}
+	})
+
+	t.Run("TestSetCookie", func(t *testing.T) {
+		expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		res := InitResponse()
+
+		res.SetCookie(&Cookie{
+			Name:        "theme",
+			Value:       "dark",
+			Path:        "/",
+			Domain:      "example.com",
+			Expires:     expires,
+			MaxAge:      3600,
+			Secure:      true,
+			HttpOnly:    true,
+			SameSite:    COOKIE_SAME_SITE_NONE,
+			Partitioned: true,
+		}).SetCookie(NewCookie("locale", "en"))
+
+		if len(res.Header.Values("Set-Cookie")) != 2 {
+			t.Fatalf("Expected response to have (%d) set-cookie headers but got (%d)", 2, len(res.Header.Values("Set-Cookie")))
+		}
+
+		cookie := setCookie(t, res, "theme")
+
+		if cookie.Value != "dark" || cookie.Domain != "example.com" || cookie.MaxAge != 3600 {
+			t.Fatalf("Expected cookie attributes to be set but got (%s)", cookie.String())
+		}
+
+		if !cookie.Secure || !cookie.HttpOnly || !cookie.Partitioned || cookie.SameSite != http.SameSiteNoneMode {
+			t.Fatalf("Expected cookie flags to be set but got (%s)", cookie.String())
+		}
+
+		if !cookie.Expires.Equal(expires) {
+			t.Fatalf("Expected cookie expires to be (%s) but got (%s)", expires, cookie.Expires)
+		}
+
+		if setCookie(t, res, "locale").SameSite != http.SameSiteLaxMode {
+			t.Fatalf("Expected default cookie same site to be lax")
+		}
+	})
+
+	t.Run("TestForgetCookie", func(t *testing.T) {
+		cookie := setCookie(t, InitResponse().ForgetCookie("theme"), "theme")
+
+		if cookie.MaxAge >= 0 {
+			t.Fatalf("Expected forget cookie max age to be negative but got (%d)", cookie.MaxAge)
+		}
+	})
+
+	t.Run("TestSignedCookie", func(t *testing.T) {
+		req := request(t, "")
+
+		cookie := setCookie(t, req.Response.SetSignedCookie(NewCookie("cart", "42")), "cart")
+
+		if req := request(t, "cart="+cookie.Value); req.SignedCookie("cart") != "42" {
+			t.Fatalf("Expected signed cookie to be (%s) but got (%s)", "42", req.SignedCookie("cart"))
+		}
+
+		tampered := strings.Replace(cookie.Value, "42", "43", 1)
+
+		if req := request(t, "cart="+tampered); req.SignedCookie("cart") != "" {
+			t.Fatalf("Expected tampered signed cookie to be empty but got (%s)", req.SignedCookie("cart"))
+		}
+
+		if req := request(t, "basket="+cookie.Value); req.SignedCookie("basket") != "" {
+			t.Fatalf("Expected signed cookie of other name to be empty but got (%s)", req.SignedCookie("basket"))
+		}
+	})
+
+	t.Run("TestEncryptedCookie", func(t *testing.T) {
+		req := request(t, "")
+
+		cookie := setCookie(t, req.Response.SetEncryptedCookie(NewCookie("email", "jeo@doe.com")), "email")
+
+		if strings.Contains(cookie.Value, "jeo") {
+			t.Fatalf("Expected encrypted cookie to not contain plain value but got (%s)", cookie.Value)
+		}
+
+		if req := request(t, "email="+cookie.Value); req.EncryptedCookie("email") != "jeo@doe.com" {
+			t.Fatalf("Expected encrypted cookie to be (%s) but got (%s)", "jeo@doe.com", req.EncryptedCookie("email"))
+		}
+	})
+
+	t.Run("TestKeyRotation", func(t *testing.T) {
+		old := key.Random()
+
+		server.SetKeyring(old)
+
+		req := request(t, "")
+
+		signed := setCookie(t, req.Response.SetSignedCookie(NewCookie("cart", "42")), "cart")
+		encrypted := setCookie(t, req.Response.SetEncryptedCookie(NewCookie("email", "jeo@doe.com")), "email")
+
+		req.Session = server.Get("session").(SessionsManager).Session(req)
+		req.Response.Session = req.Session
+
+		req.Session.Set("user_id", "1").Save()
+
+		session := setCookie(t, req.Response, SESSION_NAME)
+
+		server.SetKeyring(key.NewKeyring(key.Random().Current(), old.Current()))
+
+		req = request(t, strings.Join([]string{"cart=" + signed.Value, "email=" + encrypted.Value, SESSION_NAME + "=" + session.Value}, "; "))
+
+		if req.SignedCookie("cart") != "42" {
+			t.Fatalf("Expected signed cookie of previous key to be (%s) but got (%s)", "42", req.SignedCookie("cart"))
+		}
+
+		if req.EncryptedCookie("email") != "jeo@doe.com" {
+			t.Fatalf("Expected encrypted cookie of previous key to be (%s) but got (%s)", "jeo@doe.com", req.EncryptedCookie("email"))
+		}
+
+		if s := server.Get("session").(SessionsManager).Session(req); s.Get("user_id") != "1" {
+			t.Fatalf("Expected session of previous key to have user id (%s) but got (%s)", "1", s.Get("user_id"))
+		}
+
+		server.SetKeyring(key.Random())
+
+		req = request(t, strings.Join([]string{"cart=" + signed.Value, SESSION_NAME + "=" + session.Value}, "; "))
+
+		if req.SignedCookie("cart") != "" {
+			t.Fatalf("Expected signed cookie of removed key to be empty but got (%s)", req.SignedCookie("cart"))
+		}
+
+		if s := server.Get("session").(SessionsManager).Session(req); s.Get("user_id") != "" {
+			t.Fatalf("Expected session of removed key to be empty but got (%s)", s.Get("user_id"))
+		}
+	})
+
+	server.Close()
+}
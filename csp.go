@@ -0,0 +1,36 @@
+package http
+
+import (
+	"encoding/base64"
+
+	"github.com/lucas11776-golang/http/encryption/crypt"
+)
+
+const (
+	REQUEST_CSP_NONCE_KEY = "csp.nonce"
+	CSP_NONCE_SIZE        = 16
+)
+
+// Comment
+func (ctx *Request) CspNonce() string {
+	if nonce, ok := ctx.Get(REQUEST_CSP_NONCE_KEY).(string); ok {
+		return nonce
+	}
+
+	nonce := base64.StdEncoding.EncodeToString(crypt.RandomBytes(CSP_NONCE_SIZE))
+
+	ctx.Set(REQUEST_CSP_NONCE_KEY, nonce)
+
+	return nonce
+}
+
+// Comment
+func RequestCspNonce(req *Request) func() string {
+	return func() string {
+		if req == nil {
+			return ""
+		}
+
+		return req.CspNonce()
+	}
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"html"
+	"strings"
+
+	"github.com/lucas11776-golang/http/encryption/crypt"
+	"github.com/open2b/scriggo/native"
+)
+
+const (
+	CSRF_HEADER_NAME = "X-CSRF-TOKEN"
+	XSRF_HEADER_NAME = "X-XSRF-TOKEN"
+	XSRF_COOKIE_NAME = "XSRF-TOKEN"
+)
+
+// Comment
+func MaskCsrfToken(token string) string {
+	if token == "" {
+		return ""
+	}
+
+	otp := crypt.RandomBytes(len(token))
+	masked := make([]byte, len(token))
+
+	for i := range masked {
+		masked[i] = otp[i] ^ token[i]
+	}
+
+	return base64.RawURLEncoding.EncodeToString(append(otp, masked...))
+}
+
+// Comment
+func UnmaskCsrfToken(masked string) string {
+	data, err := base64.RawURLEncoding.DecodeString(masked)
+
+	if err != nil || len(data) == 0 || len(data)%2 != 0 {
+		return ""
+	}
+
+	otp, token := data[:len(data)/2], data[len(data)/2:]
+
+	for i := range token {
+		token[i] ^= otp[i]
+	}
+
+	return string(token)
+}
+
+// Comment
+func ValidCsrfToken(expected string, token string) bool {
+	if expected == "" || token == "" {
+		return false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1 {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(UnmaskCsrfToken(token))) == 1
+}
+
+// Comment
+func SessionCsrfField(req *Request) func() native.HTML {
+	return func() native.HTML {
+		return native.HTML(strings.Join([]string{
+			`<input type="hidden" name="`, CSRF_INPUT_NAME, `" value="`, html.EscapeString(MaskCsrfToken(req.Session.CsrfToken())), `">`,
+		}, ""))
+	}
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lucas11776-golang/http/types"
+	str "github.com/lucas11776-golang/http/utils/strings"
+	"github.com/open2b/scriggo"
+)
+
+func TestCsrf(t *testing.T) {
+	t.Run("TestMaskCsrfToken", func(t *testing.T) {
+		token := str.Random(50)
+		masked := MaskCsrfToken(token)
+
+		if masked == token || masked == MaskCsrfToken(token) {
+			t.Fatalf("Expected masked token to be random on every mask")
+		}
+
+		if UnmaskCsrfToken(masked) != token {
+			t.Fatalf("Expected unmasked token to be (%s) but got (%s)", token, UnmaskCsrfToken(masked))
+		}
+
+		if !ValidCsrfToken(token, masked) || !ValidCsrfToken(token, token) {
+			t.Fatalf("Expected masked and raw tokens to be valid")
+		}
+
+		if ValidCsrfToken(token, MaskCsrfToken(str.Random(50))) || ValidCsrfToken("", "") {
+			t.Fatalf("Expected other tokens to be invalid")
+		}
+	})
+
+	t.Run("TestCsrfField", func(t *testing.T) {
+		req, err := NewRequest("GET", "/", "HTTP/1.1", make(types.Headers), bytes.NewReader([]byte{}))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		req.Session = InitSession("session", []byte(str.Random(10))).Session(req)
+
+		view := NewView(&viewReaderTest{
+			Files: scriggo.Files{"form.html": []byte(`<form>{{ csrf_field() }}</form>`)},
+		}, "html")
+
+		data, err := view.Read("form", ViewData{}, req)
+
+		if err != nil {
+			t.Fatalf("Failed to parse view: %s", err.Error())
+		}
+
+		prefix := `<form><input type="hidden" name="` + CSRF_INPUT_NAME + `" value="`
+
+		if !strings.HasPrefix(string(data), prefix) {
+			t.Fatalf("Expected view to render csrf field but got (%s)", string(data))
+		}
+
+		value := strings.TrimSuffix(strings.TrimPrefix(string(data), prefix), `"></form>`)
+
+		if !ValidCsrfToken(req.Session.CsrfToken(), value) {
+			t.Fatalf("Expected csrf field to hold masked session token but got (%s)", value)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+var (
+	ErrInvalidPayload = errors.New("invalid encrypted payload")
+)
+
+// Comment
+func RandomBytes(size int) []byte {
+	bytes := make([]byte, size)
+
+	if _, err := rand.Read(bytes); err != nil {
+		panic(err)
+	}
+
+	return bytes
+}
+
+// Comment
+func Signature(key []byte, data string) string {
+	mac := hmac.New(sha256.New, key)
+
+	mac.Write([]byte(data))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Comment
+func ValidSignature(key []byte, data string, signature string) bool {
+	return hmac.Equal([]byte(Signature(key, data)), []byte(signature))
+}
+
+// Comment
+func aead(key []byte) (cipher.AEAD, error) {
+	hash := sha256.Sum256(key)
+
+	block, err := aes.NewCipher(hash[:])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Comment
+func Encrypt(key []byte, data string, additional string) (string, error) {
+	gcm, err := aead(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	nonce := RandomBytes(gcm.NonceSize())
+
+	return base64.RawURLEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(data), []byte(additional))), nil
+}
+
+// Comment
+func Decrypt(key []byte, data string, additional string) (string, error) {
+	gcm, err := aead(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(data)
+
+	if err != nil || len(payload) < gcm.NonceSize() {
+		return "", ErrInvalidPayload
+	}
+
+	plain, err := gcm.Open(nil, payload[:gcm.NonceSize()], payload[gcm.NonceSize():], []byte(additional))
+
+	if err != nil {
+		return "", ErrInvalidPayload
+	}
+
+	return string(plain), nil
+}
@@ -0,0 +1,51 @@
+package crypt
+
+import (
+	"testing"
+)
+
+func TestCrypt(t *testing.T) {
+	key := RandomBytes(32)
+
+	t.Run("TestSignature", func(t *testing.T) {
+		signature := Signature(key, "user_id=1")
+
+		if !ValidSignature(key, "user_id=1", signature) {
+			t.Fatalf("Expected signature to be valid")
+		}
+
+		if ValidSignature(key, "user_id=2", signature) {
+			t.Fatalf("Expected signature of tampered data to be invalid")
+		}
+
+		if ValidSignature(RandomBytes(32), "user_id=1", signature) {
+			t.Fatalf("Expected signature of other key to be invalid")
+		}
+	})
+
+	t.Run("TestEncryptDecrypt", func(t *testing.T) {
+		encrypted, err := Encrypt(key, "jeo@doe.com", "email")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decrypted, err := Decrypt(key, encrypted, "email")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decrypted != "jeo@doe.com" {
+			t.Fatalf("Expected decrypted value to be (%s) but got (%s)", "jeo@doe.com", decrypted)
+		}
+
+		if _, err := Decrypt(key, encrypted, "username"); err == nil {
+			t.Fatalf("Expected decrypt with other additional data to fail")
+		}
+
+		if _, err := Decrypt(RandomBytes(32), encrypted, "email"); err == nil {
+			t.Fatalf("Expected decrypt with other key to fail")
+		}
+	})
+}
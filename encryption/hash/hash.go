@@ -0,0 +1,145 @@
+package hash
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lucas11776-golang/http/encryption/crypt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type Driver string
+
+const (
+	BCRYPT   Driver = "bcrypt"
+	ARGON2ID Driver = "argon2id"
+)
+
+const (
+	BCRYPT_COST      = bcrypt.DefaultCost
+	ARGON2ID_TIME    = 2
+	ARGON2ID_MEMORY  = 64 * 1024
+	ARGON2ID_THREADS = 2
+	ARGON2ID_KEY_LEN = 32
+	ARGON2ID_SALT    = 16
+)
+
+var (
+	ErrUnsupportedHash = errors.New("unsupported password hash")
+)
+
+// The driver is the default of the process, use Driver.Make to hash with another driver.
+var (
+	driver      = BCRYPT
+	driverMutex sync.RWMutex
+)
+
+// Comment
+func Use(d Driver) {
+	driverMutex.Lock()
+	defer driverMutex.Unlock()
+
+	driver = d
+}
+
+// Comment
+func Make(password string) (string, error) {
+	driverMutex.RLock()
+	d := driver
+	driverMutex.RUnlock()
+
+	return d.Make(password)
+}
+
+// Comment
+func (ctx Driver) Make(password string) (string, error) {
+	switch ctx {
+	case ARGON2ID:
+		return Argon2id(password)
+
+	default:
+		return Bcrypt(password)
+	}
+}
+
+// Comment
+func Bcrypt(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), BCRYPT_COST)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(hashed), nil
+}
+
+// Comment
+func Argon2id(password string) (string, error) {
+	salt := crypt.RandomBytes(ARGON2ID_SALT)
+	key := argon2.IDKey([]byte(password), salt, ARGON2ID_TIME, ARGON2ID_MEMORY, ARGON2ID_THREADS, ARGON2ID_KEY_LEN)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		ARGON2ID_MEMORY,
+		ARGON2ID_TIME,
+		ARGON2ID_THREADS,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Comment
+func Check(password string, hashed string) bool {
+	switch {
+	case strings.HasPrefix(hashed, "$argon2id$"):
+		return checkArgon2id(password, hashed)
+
+	case strings.HasPrefix(hashed, "$2a$"), strings.HasPrefix(hashed, "$2b$"), strings.HasPrefix(hashed, "$2y$"):
+		return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password)) == nil
+
+	default:
+		return false
+	}
+}
+
+// Comment
+func checkArgon2id(password string, hashed string) bool {
+	parts := strings.Split(hashed, "$")
+
+	if len(parts) != 6 {
+		return false
+	}
+
+	var version int
+	var memory uint32
+	var time uint32
+	var threads uint8
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+
+	if err != nil {
+		return false
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(key, argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))) == 1
+}
@@ -0,0 +1,67 @@
+package hash
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	t.Run("TestBcrypt", func(t *testing.T) {
+		hashed, err := Bcrypt("secret")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(hashed, "$2a$") {
+			t.Fatalf("Expected bcrypt hash but got (%s)", hashed)
+		}
+
+		if !Check("secret", hashed) {
+			t.Fatalf("Expected password to match hash (%s)", hashed)
+		}
+
+		if Check("wrong", hashed) {
+			t.Fatalf("Expected wrong password not to match hash (%s)", hashed)
+		}
+	})
+
+	t.Run("TestArgon2id", func(t *testing.T) {
+		hashed, err := Argon2id("secret")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(hashed, "$argon2id$v=19$") {
+			t.Fatalf("Expected argon2id hash but got (%s)", hashed)
+		}
+
+		if !Check("secret", hashed) {
+			t.Fatalf("Expected password to match hash (%s)", hashed)
+		}
+
+		if Check("wrong", hashed) {
+			t.Fatalf("Expected wrong password not to match hash (%s)", hashed)
+		}
+	})
+
+	t.Run("TestMake", func(t *testing.T) {
+		Use(ARGON2ID)
+		defer Use(BCRYPT)
+
+		hashed, _ := Make("secret")
+
+		if !strings.HasPrefix(hashed, "$argon2id$") || !Check("secret", hashed) {
+			t.Fatalf("Expected argon2id hash but got (%s)", hashed)
+		}
+
+		if Check("secret", "secret") {
+			t.Fatalf("Expected plain text password not to be accepted")
+		}
+
+		if hashed, _ := BCRYPT.Make("secret"); !strings.HasPrefix(hashed, "$2a$") || !Check("secret", hashed) {
+			t.Fatalf("Expected bcrypt hash but got (%s)", hashed)
+		}
+	})
+}
@@ -0,0 +1,355 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	JWKS_PATH              = ".well-known/jwks.json"
+	JWKS_TTL               = time.Hour
+	JWKS_MIN_REFRESH       = time.Second * 30
+	JWKS_MAX_SIZE    int64 = 1024 * 1024
+)
+
+type Jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type Jwks struct {
+	Keys []Jwk `json:"keys"`
+}
+
+type RemoteKeySet struct {
+	source  string
+	ttl     time.Duration
+	client  *http.Client
+	keys    *KeySet
+	expires time.Time
+	fetched time.Time
+	retry   time.Time
+	failure error
+	mutex   sync.Mutex
+	refresh sync.Mutex
+}
+
+// Comment
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Comment
+func decode(data string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(bytes), nil
+}
+
+// Comment
+func (ctx *Key) Jwk() (Jwk, bool) {
+	jwk := Jwk{Use: "sig", Kid: ctx.Id, Alg: ctx.Algorithm}
+
+	switch public := ctx.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encode(public.N.Bytes())
+		jwk.E = encode(big.NewInt(int64(public.E)).Bytes())
+
+	case *ecdsa.PublicKey:
+		jwk.Kty = "EC"
+		jwk.Crv = public.Curve.Params().Name
+		jwk.X = encode(public.X.FillBytes(make([]byte, 32)))
+		jwk.Y = encode(public.Y.FillBytes(make([]byte, 32)))
+
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encode(public)
+
+	default:
+		return jwk, false
+	}
+
+	return jwk, true
+}
+
+// Comment
+func (ctx *Key) Thumbprint() string {
+	jwk, ok := ctx.Jwk()
+
+	if !ok {
+		return ""
+	}
+
+	var members string
+
+	switch jwk.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+
+	case "EC":
+		members = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, jwk.Crv, jwk.X, jwk.Y)
+
+	default:
+		members = fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s"}`, jwk.Crv, jwk.Kty, jwk.X)
+	}
+
+	sum := sha256.Sum256([]byte(members))
+
+	return encode(sum[:])
+}
+
+// Comment
+func (ctx Jwk) Key() (*Key, error) {
+	switch ctx.Kty {
+	case "RSA":
+		n, err := decode(ctx.N)
+
+		if err != nil {
+			return nil, errors.Join(ErrInvalidKey, err)
+		}
+
+		e, err := decode(ctx.E)
+
+		if err != nil {
+			return nil, errors.Join(ErrInvalidKey, err)
+		}
+
+		return NewPublicKey(ctx.Kid, ctx.Alg, &rsa.PublicKey{N: n, E: int(e.Int64())})
+
+	case "EC":
+		if ctx.Crv != elliptic.P256().Params().Name {
+			return nil, fmt.Errorf("%w: curve %s", ErrUnsupportedAlgorithm, ctx.Crv)
+		}
+
+		x, err := decode(ctx.X)
+
+		if err != nil {
+			return nil, errors.Join(ErrInvalidKey, err)
+		}
+
+		y, err := decode(ctx.Y)
+
+		if err != nil {
+			return nil, errors.Join(ErrInvalidKey, err)
+		}
+
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, ErrInvalidKey
+		}
+
+		return NewPublicKey(ctx.Kid, ctx.Alg, &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y})
+
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(ctx.X)
+
+		if err != nil || ctx.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidKey
+		}
+
+		return NewPublicKey(ctx.Kid, ctx.Alg, ed25519.PublicKey(x))
+
+	default:
+		return nil, fmt.Errorf("%w: key type %s", ErrUnsupportedAlgorithm, ctx.Kty)
+	}
+}
+
+// Comment
+func (ctx *KeySet) Jwks() Jwks {
+	jwks := Jwks{Keys: []Jwk{}}
+
+	for _, key := range ctx.keys {
+		if jwk, ok := key.Jwk(); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+
+	return jwks
+}
+
+// Comment
+func ParseJwks(data []byte) (*KeySet, error) {
+	jwks := Jwks{}
+
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := []*Key{}
+
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.Key()
+
+		if err != nil {
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	return NewKeySet(keys...), nil
+}
+
+// Comment
+func NewRemoteKeySet(source string, ttl time.Duration) *RemoteKeySet {
+	if ttl == 0 {
+		ttl = JWKS_TTL
+	}
+
+	return &RemoteKeySet{source: source, ttl: ttl, client: http.DefaultClient}
+}
+
+// Comment
+func (ctx *RemoteKeySet) Client(client *http.Client) *RemoteKeySet {
+	ctx.client = client
+
+	return ctx
+}
+
+// Comment
+func (ctx *RemoteKeySet) read() ([]byte, error) {
+	if !strings.HasPrefix(ctx.source, "http://") && !strings.HasPrefix(ctx.source, "https://") {
+		return os.ReadFile(strings.TrimPrefix(ctx.source, "file://"))
+	}
+
+	res, err := ctx.client.Get(ctx.source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks %s responded with status %d", ctx.source, res.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(res.Body, JWKS_MAX_SIZE))
+}
+
+// Comment
+func (ctx *RemoteKeySet) cached(force bool) (*KeySet, bool, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	now := time.Now()
+
+	// A failed refresh is not retried before the back off ends, the last keys are used until then.
+	if now.Before(ctx.retry) {
+		if ctx.keys != nil {
+			return ctx.keys, true, nil
+		}
+
+		return nil, true, ctx.failure
+	}
+
+	if ctx.keys != nil && now.Before(ctx.expires) && (!force || now.Sub(ctx.fetched) < JWKS_MIN_REFRESH) {
+		return ctx.keys, true, nil
+	}
+
+	return ctx.keys, false, nil
+}
+
+// Comment
+func (ctx *RemoteKeySet) fetch(force bool) (*KeySet, error) {
+	keys, ok, err := ctx.cached(force)
+
+	if ok {
+		return keys, err
+	}
+
+	// One request refreshes the keys, the others keep using the current keys or wait for the first keys.
+	if !ctx.refresh.TryLock() {
+		if keys != nil {
+			return keys, nil
+		}
+
+		ctx.refresh.Lock()
+	}
+
+	defer ctx.refresh.Unlock()
+
+	if keys, ok, err := ctx.cached(force); ok {
+		return keys, err
+	}
+
+	// The keys are read without holding the mutex so a slow source does not block verification.
+	data, err := ctx.read()
+
+	if err == nil {
+		keys, err = ParseJwks(data)
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if err != nil {
+		ctx.retry, ctx.failure = time.Now().Add(JWKS_MIN_REFRESH), err
+
+		if ctx.keys != nil {
+			return ctx.keys, nil
+		}
+
+		return nil, err
+	}
+
+	ctx.keys, ctx.fetched, ctx.expires = keys, time.Now(), time.Now().Add(ctx.ttl)
+
+	return keys, nil
+}
+
+// Comment
+func (ctx *RemoteKeySet) KeySet() (*KeySet, error) {
+	return ctx.fetch(false)
+}
+
+// Comment
+func (ctx *RemoteKeySet) Verify(token string, claims jwt.Claims, options Options) error {
+	keys, err := ctx.fetch(false)
+
+	if err != nil {
+		return err
+	}
+
+	if err = keys.Verify(token, claims, options); !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+
+	if keys, err = ctx.fetch(true); err != nil {
+		return err
+	}
+
+	return keys.Verify(token, claims, options)
+}
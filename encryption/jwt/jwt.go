@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -51,3 +52,59 @@ func JwtDecode[C interface{}](secret string, token string, claims C) (*C, error)
 
 	return &claims, nil
 }
+
+const (
+	ACCESS_TOKEN  = "access"
+	REFRESH_TOKEN = "refresh"
+)
+
+var (
+	ErrInvalidTokenType = errors.New("invalid token type")
+)
+
+type Claims struct {
+	jwt.RegisteredClaims
+	TokenType string `json:"token_type,omitempty"`
+	Family    string `json:"family,omitempty"`
+}
+
+type Claimer interface {
+	jwt.Claims
+	Base() *Claims
+}
+
+type Options struct {
+	Issuer   string
+	Audience []string
+	Leeway   time.Duration
+}
+
+// Comment
+func (ctx *Claims) Base() *Claims {
+	return ctx
+}
+
+// Comment
+func (ctx Options) parser() []jwt.ParserOption {
+	options := []jwt.ParserOption{jwt.WithLeeway(ctx.Leeway), jwt.WithExpirationRequired()}
+
+	if ctx.Issuer != "" {
+		options = append(options, jwt.WithIssuer(ctx.Issuer))
+	}
+
+	if len(ctx.Audience) != 0 {
+		options = append(options, jwt.WithAudience(ctx.Audience...))
+	}
+
+	return options
+}
+
+// Comment
+func Sign(secret []byte, claims jwt.Claims) (string, error) {
+	return NewHmacKey("", secret).Sign(claims)
+}
+
+// Comment
+func Parse(secret []byte, token string, claims jwt.Claims, options Options) error {
+	return NewKeySet(NewHmacKey("", secret)).Verify(token, claims, options)
+}
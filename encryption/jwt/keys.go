@@ -0,0 +1,354 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lucas11776-golang/http/encryption/crypt"
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	PS256 = "PS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+var (
+	ErrInvalidKey           = errors.New("invalid jwt key")
+	ErrUnsupportedAlgorithm = errors.New("unsupported jwt algorithm")
+	ErrKeyNotFound          = errors.New("jwt key not found")
+)
+
+type Key struct {
+	Id        string
+	Algorithm string
+	private   crypto.PrivateKey
+	public    crypto.PublicKey
+}
+
+type Signer interface {
+	Sign(claims jwt.Claims) (string, error)
+}
+
+type Verifier interface {
+	Verify(token string, claims jwt.Claims, options Options) error
+}
+
+type KeySet struct {
+	keys []*Key
+}
+
+// Comment
+func method(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case HS256:
+		return jwt.SigningMethodHS256, nil
+
+	case RS256:
+		return jwt.SigningMethodRS256, nil
+
+	case PS256:
+		return jwt.SigningMethodPS256, nil
+
+	case ES256:
+		return jwt.SigningMethodES256, nil
+
+	case EdDSA:
+		return jwt.SigningMethodEdDSA, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+}
+
+// Comment
+func algorithm(key crypto.PublicKey, algorithm string) (string, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if algorithm == "" {
+			return RS256, nil
+		}
+
+		if algorithm != RS256 && algorithm != PS256 {
+			return "", fmt.Errorf("%w: %s for rsa key", ErrUnsupportedAlgorithm, algorithm)
+		}
+
+	case *ecdsa.PublicKey:
+		if algorithm == "" {
+			return ES256, nil
+		}
+
+		if algorithm != ES256 || key.(*ecdsa.PublicKey).Curve != elliptic.P256() {
+			return "", fmt.Errorf("%w: %s for ecdsa key", ErrUnsupportedAlgorithm, algorithm)
+		}
+
+	case ed25519.PublicKey:
+		if algorithm == "" {
+			return EdDSA, nil
+		}
+
+		if algorithm != EdDSA {
+			return "", fmt.Errorf("%w: %s for ed25519 key", ErrUnsupportedAlgorithm, algorithm)
+		}
+
+	default:
+		return "", ErrInvalidKey
+	}
+
+	return algorithm, nil
+}
+
+// Comment
+func NewHmacKey(id string, secret []byte) *Key {
+	return &Key{Id: id, Algorithm: HS256, private: secret, public: secret}
+}
+
+// Comment
+func NewKey(id string, alg string, private crypto.Signer) (*Key, error) {
+	alg, err := algorithm(private.Public(), alg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{Id: id, Algorithm: alg, private: private, public: private.Public()}
+
+	if key.Id == "" {
+		key.Id = key.Thumbprint()
+	}
+
+	return key, nil
+}
+
+// Comment
+func NewPublicKey(id string, alg string, public crypto.PublicKey) (*Key, error) {
+	alg, err := algorithm(public, alg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{Id: id, Algorithm: alg, public: public}
+
+	if key.Id == "" {
+		key.Id = key.Thumbprint()
+	}
+
+	return key, nil
+}
+
+// Comment
+func GenerateKey(id string, alg string) (*Key, error) {
+	switch alg {
+	case HS256:
+		return NewHmacKey(id, crypt.RandomBytes(32)), nil
+
+	case RS256, PS256:
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewKey(id, alg, private)
+
+	case ES256:
+		private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewKey(id, alg, private)
+
+	case EdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewKey(id, alg, private)
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+}
+
+// Comment
+func ParsePrivateKey(id string, alg string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+
+	var private interface{}
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+
+	default:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	if err != nil {
+		return nil, errors.Join(ErrInvalidKey, err)
+	}
+
+	signer, ok := private.(crypto.Signer)
+
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	return NewKey(id, alg, signer)
+}
+
+// Comment
+func ParsePublicKey(id string, alg string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+
+	var public interface{}
+	var err error
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+
+	case "CERTIFICATE":
+		var certificate *x509.Certificate
+
+		if certificate, err = x509.ParseCertificate(block.Bytes); err == nil {
+			public = certificate.PublicKey
+		}
+
+	default:
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+
+	if err != nil {
+		return nil, errors.Join(ErrInvalidKey, err)
+	}
+
+	return NewPublicKey(id, alg, public)
+}
+
+// Comment
+func (ctx *Key) Public() crypto.PublicKey {
+	return ctx.public
+}
+
+// Comment
+func (ctx *Key) Symmetric() bool {
+	return ctx.Algorithm == HS256
+}
+
+// Comment
+func (ctx *Key) Sign(claims jwt.Claims) (string, error) {
+	if ctx.private == nil {
+		return "", fmt.Errorf("%w: key %s has no private key", ErrInvalidKey, ctx.Id)
+	}
+
+	method, err := method(ctx.Algorithm)
+
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+
+	if ctx.Id != "" {
+		token.Header["kid"] = ctx.Id
+	}
+
+	return token.SignedString(ctx.private)
+}
+
+// Comment
+func NewKeySet(keys ...*Key) *KeySet {
+	return &KeySet{keys: keys}
+}
+
+// Comment
+func (ctx *KeySet) Keys() []*Key {
+	return ctx.keys
+}
+
+// Comment
+func (ctx *KeySet) Key(id string) *Key {
+	for _, key := range ctx.keys {
+		if key.Id == id {
+			return key
+		}
+	}
+
+	return nil
+}
+
+// Comment
+func (ctx *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if len(ctx.keys) == 0 {
+		return "", ErrKeyNotFound
+	}
+
+	return ctx.keys[0].Sign(claims)
+}
+
+// Comment
+func (ctx *KeySet) keyfunc(token *jwt.Token) (any, error) {
+	id, _ := token.Header["kid"].(string)
+
+	candidates := []*Key{}
+
+	if key := ctx.Key(id); key != nil {
+		candidates = append(candidates, key)
+	}
+
+	if id == "" && len(ctx.keys) != 0 {
+		candidates = append(candidates, ctx.keys[0])
+	}
+
+	for _, key := range candidates {
+		if key.Algorithm == token.Method.Alg() {
+			return key.public, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: kid %s with algorithm %v", ErrKeyNotFound, id, token.Header["alg"])
+}
+
+// Comment
+func (ctx *KeySet) Verify(token string, claims jwt.Claims, options Options) error {
+	parsed, err := jwt.ParseWithClaims(token, claims, ctx.keyfunc, options.parser()...)
+
+	if err != nil {
+		return err
+	}
+
+	if !parsed.Valid {
+		return errors.New("invalid token")
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lucas11776-golang/http/utils/rsa"
+)
+
+func TestKeys(t *testing.T) {
+	claims := func() *Claims {
+		return &Claims{RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		}}
+	}
+
+	t.Run("TestAlgorithms", func(t *testing.T) {
+		for _, alg := range []string{HS256, RS256, PS256, ES256, EdDSA} {
+			key, err := GenerateKey("", alg)
+
+			if err != nil {
+				t.Fatalf("Something went wrong when trying to generate (%s) key: %v", alg, err)
+			}
+
+			token, err := key.Sign(claims())
+
+			if err != nil {
+				t.Fatalf("Something went wrong when trying to sign (%s) token: %v", alg, err)
+			}
+
+			actual := &Claims{}
+
+			if err := NewKeySet(key).Verify(token, actual, Options{}); err != nil || actual.Subject != "1" {
+				t.Fatalf("Expected (%s) token to be valid but got (%v)", alg, err)
+			}
+		}
+	})
+
+	t.Run("TestPem", func(t *testing.T) {
+		certificate, private, err := rsa.GenerateCertificate("localhost")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signer, err := ParsePrivateKey("2025-01", RS256, []byte(private))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		verifier, err := ParsePublicKey("2025-01", RS256, []byte(certificate))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token, _ := signer.Sign(claims())
+
+		if err := NewKeySet(verifier).Verify(token, &Claims{}, Options{}); err != nil {
+			t.Fatalf("Expected token signed with pem key to be valid but got (%v)", err)
+		}
+
+		if _, err := ParsePrivateKey("", ES256, []byte(private)); !errors.Is(err, ErrUnsupportedAlgorithm) {
+			t.Fatalf("Expected rsa key with ecdsa algorithm error to be (%v) but got (%v)", ErrUnsupportedAlgorithm, err)
+		}
+	})
+
+	t.Run("TestKeyRotation", func(t *testing.T) {
+		previous, _ := GenerateKey("2025-01", ES256)
+		current, _ := GenerateKey("2025-02", EdDSA)
+
+		old, _ := previous.Sign(claims())
+		token, _ := NewKeySet(current, previous).Sign(claims())
+
+		parsed, _, _ := jwt.NewParser().ParseUnverified(token, &Claims{})
+
+		if parsed.Header["kid"] != "2025-02" {
+			t.Fatalf("Expected kid to be (%s) but got (%v)", "2025-02", parsed.Header["kid"])
+		}
+
+		keys := NewKeySet(current, previous)
+
+		for _, token := range []string{old, token} {
+			if err := keys.Verify(token, &Claims{}, Options{}); err != nil {
+				t.Fatalf("Expected rotated key set to verify token but got (%v)", err)
+			}
+		}
+
+		if err := NewKeySet(current).Verify(old, &Claims{}, Options{}); !errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("Expected removed key error to be (%v) but got (%v)", ErrKeyNotFound, err)
+		}
+	})
+
+	t.Run("TestAlgorithmConfusion", func(t *testing.T) {
+		key, _ := GenerateKey("rsa", RS256)
+
+		public, _ := key.Jwk()
+		forged, _ := NewHmacKey("rsa", []byte(public.N)).Sign(claims())
+
+		if err := NewKeySet(key).Verify(forged, &Claims{}, Options{}); err == nil {
+			t.Fatalf("Expected token signed with hmac not to be verified by rsa key")
+		}
+	})
+
+	t.Run("TestJwks", func(t *testing.T) {
+		rs, _ := GenerateKey("", RS256)
+		es, _ := GenerateKey("", ES256)
+		ed, _ := GenerateKey("", EdDSA)
+		hs, _ := GenerateKey("secret", HS256)
+
+		data, err := json.Marshal(NewKeySet(rs, es, ed, hs).Jwks())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys, err := ParseJwks(data)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(keys.Keys()) != 3 {
+			t.Fatalf("Expected jwks to publish (%d) public keys but got (%d)", 3, len(keys.Keys()))
+		}
+
+		for _, key := range []*Key{rs, es, ed} {
+			if public := keys.Key(key.Id); public == nil || public.Thumbprint() != key.Id {
+				t.Fatalf("Expected jwks to have key with thumbprint (%s)", key.Id)
+			}
+
+			token, _ := key.Sign(claims())
+
+			if err := keys.Verify(token, &Claims{}, Options{}); err != nil {
+				t.Fatalf("Expected jwks to verify (%s) token but got (%v)", key.Algorithm, err)
+			}
+		}
+	})
+
+	t.Run("TestRemoteKeySet", func(t *testing.T) {
+		previous, _ := GenerateKey("2025-01", RS256)
+		current, _ := GenerateKey("2025-02", RS256)
+
+		published := NewKeySet(previous)
+		requests := atomic.Int32{}
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+
+			json.NewEncoder(w).Encode(published.Jwks())
+		}))
+
+		defer server.Close()
+
+		remote := NewRemoteKeySet(server.URL+"/"+JWKS_PATH, time.Minute)
+
+		for i := 0; i < 3; i++ {
+			token, _ := previous.Sign(claims())
+
+			if err := remote.Verify(token, &Claims{}, Options{}); err != nil {
+				t.Fatalf("Expected remote key set to verify token but got (%v)", err)
+			}
+		}
+
+		if requests.Load() != 1 {
+			t.Fatalf("Expected jwks to be fetched (%d) times but got (%d)", 1, requests.Load())
+		}
+
+		published = NewKeySet(current, previous)
+		remote.fetched = time.Now().Add(-JWKS_MIN_REFRESH)
+
+		token, _ := current.Sign(claims())
+
+		if err := remote.Verify(token, &Claims{}, Options{}); err != nil {
+			t.Fatalf("Expected unknown kid to refresh remote key set but got (%v)", err)
+		}
+
+		if requests.Load() != 2 {
+			t.Fatalf("Expected jwks to be fetched (%d) times but got (%d)", 2, requests.Load())
+		}
+
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		data, _ := json.Marshal(NewKeySet(current).Jwks())
+
+		os.WriteFile(path, data, 0644)
+
+		if err := NewRemoteKeySet(path, 0).Verify(token, &Claims{}, Options{}); err != nil {
+			t.Fatalf("Expected file key set to verify token but got (%v)", err)
+		}
+	})
+
+	t.Run("TestRemoteKeySetFailure", func(t *testing.T) {
+		key, _ := GenerateKey("2025-03", RS256)
+		requests := atomic.Int32{}
+		failing := atomic.Bool{}
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+
+			if failing.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			json.NewEncoder(w).Encode(NewKeySet(key).Jwks())
+		}))
+
+		defer server.Close()
+
+		failing.Store(true)
+
+		remote := NewRemoteKeySet(server.URL+"/"+JWKS_PATH, time.Minute)
+
+		for i := 0; i < 3; i++ {
+			if _, err := remote.KeySet(); err == nil {
+				t.Fatalf("Expected failing jwks to return an error")
+			}
+		}
+
+		// A failed refresh backs off instead of fetching on every request.
+		if requests.Load() != 1 {
+			t.Fatalf("Expected jwks to be fetched (%d) times but got (%d)", 1, requests.Load())
+		}
+
+		failing.Store(false)
+		remote.retry = time.Now()
+
+		token, _ := key.Sign(claims())
+
+		if err := remote.Verify(token, &Claims{}, Options{}); err != nil {
+			t.Fatalf("Expected remote key set to verify token after back off but got (%v)", err)
+		}
+
+		failing.Store(true)
+		remote.expires = time.Now()
+
+		for i := 0; i < 3; i++ {
+			if err := remote.Verify(token, &Claims{}, Options{}); err != nil {
+				t.Fatalf("Expected stale keys to verify token while jwks fails but got (%v)", err)
+			}
+		}
+
+		if requests.Load() != 3 {
+			t.Fatalf("Expected jwks to be fetched (%d) times but got (%d)", 3, requests.Load())
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package key
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/lucas11776-golang/http/encryption/crypt"
+	"github.com/lucas11776-golang/http/utils/env"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	KEY_SIZE          = 32
+	KEY_PREFIX        = "base64:"
+	APP_KEY           = "APP_KEY"
+	APP_PREVIOUS_KEYS = "APP_PREVIOUS_KEYS"
+	SIGNING_INFO      = "signing"
+	ENCRYPTION_INFO   = "encryption"
+)
+
+var (
+	ErrMissingKey = errors.New("application key is missing")
+	ErrInvalidKey = errors.New("application key must be 32 bytes base64 encoded")
+)
+
+type Keyring struct {
+	current  []byte
+	previous [][]byte
+}
+
+// Comment
+func Generate() string {
+	return KEY_PREFIX + base64.StdEncoding.EncodeToString(crypt.RandomBytes(KEY_SIZE))
+}
+
+// Comment
+func Parse(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return nil, ErrMissingKey
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, KEY_PREFIX))
+
+	if err != nil || len(key) != KEY_SIZE {
+		return nil, ErrInvalidKey
+	}
+
+	return key, nil
+}
+
+// Comment
+func Derive(key []byte, info string) []byte {
+	derived := make([]byte, KEY_SIZE)
+
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(info)), derived); err != nil {
+		panic(err)
+	}
+
+	return derived
+}
+
+// Comment
+func NewKeyring(current []byte, previous ...[]byte) *Keyring {
+	return &Keyring{current: current, previous: previous}
+}
+
+// Comment
+func Random() *Keyring {
+	return NewKeyring(crypt.RandomBytes(KEY_SIZE))
+}
+
+// Comment
+func Decode(current string, previous ...string) (*Keyring, error) {
+	key, err := Parse(current)
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys := [][]byte{}
+
+	for _, value := range previous {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		k, err := Parse(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, k)
+	}
+
+	return NewKeyring(key, keys...), nil
+}
+
+// Comment
+func Load() (*Keyring, error) {
+	return Decode(env.Env(APP_KEY), strings.Split(env.Env(APP_PREVIOUS_KEYS), ",")...)
+}
+
+// Comment
+func (ctx *Keyring) Current() []byte {
+	return ctx.current
+}
+
+// Comment
+func (ctx *Keyring) Keys() [][]byte {
+	return append([][]byte{ctx.current}, ctx.previous...)
+}
+
+// Comment
+func (ctx *Keyring) SigningKey() []byte {
+	return Derive(ctx.current, SIGNING_INFO)
+}
+
+// Comment
+func (ctx *Keyring) EncryptionKey() []byte {
+	return Derive(ctx.current, ENCRYPTION_INFO)
+}
+
+// Comment
+func (ctx *Keyring) SigningKeys() [][]byte {
+	keys := [][]byte{}
+
+	for _, key := range ctx.Keys() {
+		keys = append(keys, Derive(key, SIGNING_INFO))
+	}
+
+	return keys
+}
+
+// Comment
+func (ctx *Keyring) EncryptionKeys() [][]byte {
+	keys := [][]byte{}
+
+	for _, key := range ctx.Keys() {
+		keys = append(keys, Derive(key, ENCRYPTION_INFO))
+	}
+
+	return keys
+}
+
+// Comment
+func (ctx *Keyring) Pairs() [][]byte {
+	pairs := [][]byte{}
+
+	for _, key := range ctx.Keys() {
+		pairs = append(pairs, Derive(key, SIGNING_INFO), Derive(key, ENCRYPTION_INFO))
+	}
+
+	return pairs
+}
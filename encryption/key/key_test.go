@@ -0,0 +1,77 @@
+package key
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lucas11776-golang/http/utils/env"
+)
+
+func TestKey(t *testing.T) {
+	t.Run("TestGenerateParse", func(t *testing.T) {
+		generated := Generate()
+
+		if !strings.HasPrefix(generated, KEY_PREFIX) {
+			t.Fatalf("Expected generated key to start with (%s) but got (%s)", KEY_PREFIX, generated)
+		}
+
+		key, err := Parse(generated)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(key) != KEY_SIZE {
+			t.Fatalf("Expected key size to be (%d) but got (%d)", KEY_SIZE, len(key))
+		}
+
+		if _, err := Parse("base64:c2hvcnQ="); err != ErrInvalidKey {
+			t.Fatalf("Expected short key error to be (%v) but got (%v)", ErrInvalidKey, err)
+		}
+
+		if _, err := Parse(""); err != ErrMissingKey {
+			t.Fatalf("Expected empty key error to be (%v) but got (%v)", ErrMissingKey, err)
+		}
+	})
+
+	t.Run("TestDerivedKeys", func(t *testing.T) {
+		keyring := Random()
+
+		if bytes.Equal(keyring.SigningKey(), keyring.EncryptionKey()) {
+			t.Fatalf("Expected signing and encryption keys to be different")
+		}
+
+		if bytes.Equal(keyring.SigningKey(), keyring.Current()) {
+			t.Fatalf("Expected signing key to be derived from application key")
+		}
+
+		if !bytes.Equal(keyring.SigningKey(), NewKeyring(keyring.Current()).SigningKey()) {
+			t.Fatalf("Expected derived keys to be deterministic")
+		}
+	})
+
+	t.Run("TestLoad", func(t *testing.T) {
+		current, previous := Generate(), Generate()
+
+		env.Set(APP_KEY, current)
+		env.Set(APP_PREVIOUS_KEYS, previous)
+
+		defer env.Set(APP_KEY, "")
+		defer env.Set(APP_PREVIOUS_KEYS, "")
+
+		keyring, err := Load()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(keyring.Keys()) != 2 || len(keyring.Pairs()) != 4 {
+			t.Fatalf("Expected keyring to have (%d) keys but got (%d)", 2, len(keyring.Keys()))
+		}
+
+		if key, _ := Parse(previous); !bytes.Equal(keyring.SigningKeys()[1], Derive(key, SIGNING_INFO)) {
+			t.Fatalf("Expected previous signing key to be derived from previous key")
+		}
+	})
+}
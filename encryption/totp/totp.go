@@ -0,0 +1,219 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucas11776-golang/http/utils/qrcode"
+)
+
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+const (
+	DIGITS      = 6
+	PERIOD      = 30 * time.Second
+	SKEW        = 1
+	SECRET_SIZE = 20
+	QR_SCALE    = 6
+)
+
+var (
+	ErrInvalidSecret        = errors.New("totp secret is not valid base32")
+	ErrUnsupportedAlgorithm = errors.New("totp algorithm is not supported")
+	encoding                = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+type Options struct {
+	Issuer    string
+	Digits    int
+	Period    time.Duration
+	Skew      int
+	Algorithm Algorithm
+}
+
+// Comment
+func (ctx Options) defaults() Options {
+	if ctx.Digits == 0 {
+		ctx.Digits = DIGITS
+	}
+
+	if ctx.Period == 0 {
+		ctx.Period = PERIOD
+	}
+
+	// A negative skew disables drift tolerance.
+	if ctx.Skew == 0 {
+		ctx.Skew = SKEW
+	} else if ctx.Skew < 0 {
+		ctx.Skew = 0
+	}
+
+	if ctx.Algorithm == "" {
+		ctx.Algorithm = SHA1
+	}
+
+	return ctx
+}
+
+// Comment
+func (ctx Options) Window() time.Duration {
+	options := ctx.defaults()
+
+	// A step is accepted from skew steps before it until skew steps after it, plus the step it started in.
+	return options.Period * time.Duration(options.Skew*2+2)
+}
+
+// Comment
+func (ctx Options) hash() (func() hash.Hash, error) {
+	switch ctx.Algorithm {
+	case SHA1:
+		return sha1.New, nil
+	case SHA256:
+		return sha256.New, nil
+	case SHA512:
+		return sha512.New, nil
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
+
+// Comment
+func GenerateSecret() (string, error) {
+	secret := make([]byte, SECRET_SIZE)
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// Comment
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.TrimRight(strings.ToUpper(strings.ReplaceAll(secret, " ", "")), "="))
+
+	if err != nil {
+		return nil, ErrInvalidSecret
+	}
+
+	return key, nil
+}
+
+// Comment
+func Step(t time.Time, options Options) int64 {
+	return t.Unix() / int64(options.defaults().Period/time.Second)
+}
+
+// Comment
+func hotp(key []byte, step int64, options Options) (string, error) {
+	h, err := options.hash()
+
+	if err != nil {
+		return "", err
+	}
+
+	counter := make([]byte, 8)
+
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(h, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226 section 5.3).
+	offset := sum[len(sum)-1] & 0x0F
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7FFFFFFF
+
+	modulo := uint32(1)
+
+	for i := 0; i < options.Digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", options.Digits, code%modulo), nil
+}
+
+// Comment
+func GenerateCode(secret string, t time.Time, options Options) (string, error) {
+	key, err := decodeSecret(secret)
+
+	if err != nil {
+		return "", err
+	}
+
+	options = options.defaults()
+
+	return hotp(key, Step(t, options), options)
+}
+
+// Comment
+func Validate(secret string, code string, t time.Time, options Options) (int64, bool) {
+	options = options.defaults()
+
+	key, err := decodeSecret(secret)
+
+	if err != nil || len(code) != options.Digits {
+		return 0, false
+	}
+
+	current := Step(t, options)
+
+	for i := -options.Skew; i <= options.Skew; i++ {
+		expected, err := hotp(key, current+int64(i), options)
+
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + int64(i), true
+		}
+	}
+
+	return 0, false
+}
+
+// Comment
+func Uri(account string, secret string, options Options) string {
+	options = options.defaults()
+
+	label := url.PathEscape(account)
+	query := url.Values{}
+
+	query.Set("secret", secret)
+
+	if options.Issuer != "" {
+		label = url.PathEscape(options.Issuer) + ":" + label
+		query.Set("issuer", options.Issuer)
+	}
+
+	query.Set("algorithm", string(options.Algorithm))
+	query.Set("digits", strconv.Itoa(options.Digits))
+	query.Set("period", strconv.Itoa(int(options.Period/time.Second)))
+
+	return "otpauth://totp/" + label + "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// Comment
+func QrCode(account string, secret string, options Options) ([]byte, error) {
+	return qrcode.PNG(Uri(account, secret, options), QR_SCALE)
+}
@@ -0,0 +1,106 @@
+package totp
+
+import (
+	"bytes"
+	"image/png"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTotp(t *testing.T) {
+	t.Run("TestRfc6238", func(t *testing.T) {
+		secrets := map[Algorithm]string{
+			SHA1:   encoding.EncodeToString([]byte("12345678901234567890")),
+			SHA256: encoding.EncodeToString([]byte("12345678901234567890123456789012")),
+			SHA512: encoding.EncodeToString([]byte("1234567890123456789012345678901234567890123456789012345678901234")),
+		}
+
+		tests := []struct {
+			time      int64
+			algorithm Algorithm
+			code      string
+		}{
+			{59, SHA1, "94287082"},
+			{59, SHA256, "46119246"},
+			{59, SHA512, "90693936"},
+			{1111111109, SHA1, "07081804"},
+			{1111111109, SHA256, "68084774"},
+			{1111111109, SHA512, "25091201"},
+			{1234567890, SHA1, "89005924"},
+			{2000000000, SHA1, "69279037"},
+			{20000000000, SHA1, "65353130"},
+		}
+
+		for _, test := range tests {
+			code, err := GenerateCode(secrets[test.algorithm], time.Unix(test.time, 0), Options{Digits: 8, Algorithm: test.algorithm})
+
+			if err != nil {
+				t.Fatalf("Something went wrong when trying to generate code: %v", err)
+			}
+
+			if code != test.code {
+				t.Fatalf("Expected code at (%d) with (%s) to be (%s) but got (%s)", test.time, test.algorithm, test.code, code)
+			}
+		}
+	})
+
+	t.Run("TestValidate", func(t *testing.T) {
+		secret, err := GenerateSecret()
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to generate secret: %v", err)
+		}
+
+		now := time.Unix(1700000000, 0)
+		previous, _ := GenerateCode(secret, now.Add(-PERIOD), Options{})
+
+		step, ok := Validate(secret, previous, now, Options{})
+
+		if !ok || step != Step(now, Options{})-1 {
+			t.Fatalf("Expected previous code to be valid at step (%d) but got (%d, %t)", Step(now, Options{})-1, step, ok)
+		}
+
+		if _, ok := Validate(secret, previous, now, Options{Skew: -1}); ok {
+			t.Fatalf("Expected previous code to be invalid without skew")
+		}
+
+		old, _ := GenerateCode(secret, now.Add(-PERIOD*2), Options{})
+
+		if _, ok := Validate(secret, old, now, Options{}); ok {
+			t.Fatalf("Expected code outside drift window to be invalid")
+		}
+
+		if _, ok := Validate("not base32!", "123456", now, Options{}); ok {
+			t.Fatalf("Expected invalid secret to be rejected")
+		}
+	})
+
+	t.Run("TestUri", func(t *testing.T) {
+		uri := Uri("jeo@doe.com", "JBSWY3DPEHPK3PXP", Options{Issuer: "Acme Co"})
+		u, err := url.Parse(uri)
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to parse uri: %v", err)
+		}
+
+		if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Acme Co:jeo@doe.com" {
+			t.Fatalf("Expected uri label to be (%s) but got (%s)", "Acme Co:jeo@doe.com", uri)
+		}
+
+		if strings.Contains(uri, "+") || u.Query().Get("secret") != "JBSWY3DPEHPK3PXP" || u.Query().Get("issuer") != "Acme Co" || u.Query().Get("period") != "30" {
+			t.Fatalf("Expected uri query to contain secret, issuer and period but got (%s)", uri)
+		}
+
+		raw, err := QrCode("jeo@doe.com", "JBSWY3DPEHPK3PXP", Options{Issuer: "Acme Co"})
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create qr code: %v", err)
+		}
+
+		if _, err := png.Decode(bytes.NewReader(raw)); err != nil {
+			t.Fatalf("Something went wrong when trying to decode qr code png: %v", err)
+		}
+	})
+}
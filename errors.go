@@ -0,0 +1,221 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/lucas11776-golang/http/pages"
+)
+
+const (
+	PROBLEM_JSON_CONTENT_TYPE = "application/problem+json"
+	PROBLEM_DEFAULT_TYPE      = "about:blank"
+)
+
+type HttpError struct {
+	Status   Status
+	Type     string
+	Title    string
+	Detail   string
+	Instance string
+	Errors   SessionErrorsBag
+	Err      error
+}
+
+type Problem struct {
+	Type     string           `json:"type"`
+	Title    string           `json:"title"`
+	Status   int              `json:"status"`
+	Detail   string           `json:"detail,omitempty"`
+	Instance string           `json:"instance,omitempty"`
+	Errors   SessionErrorsBag `json:"errors,omitempty"`
+}
+
+type ErrorHandler func(req *Request, err error) *Response
+
+type HandlerCallback func(req *Request, res *Response) (*Response, error)
+
+// Comment
+func NewHttpError(status Status, detail string) *HttpError {
+	return &HttpError{
+		Status: status,
+		Type:   PROBLEM_DEFAULT_TYPE,
+		Title:  StatusText(status),
+		Detail: detail,
+	}
+}
+
+// Comment
+func BadRequest(detail string) *HttpError {
+	return NewHttpError(HTTP_RESPONSE_BAD_REQUEST, detail)
+}
+
+// Comment
+func Unauthorized(detail string) *HttpError {
+	return NewHttpError(HTTP_RESPONSE_UNAUTHORIZED, detail)
+}
+
+// Comment
+func Forbidden(detail string) *HttpError {
+	return NewHttpError(HTTP_RESPONSE_FORBIDDEN, detail)
+}
+
+// Comment
+func NotFound(detail string) *HttpError {
+	return NewHttpError(HTTP_RESPONSE_NOT_FOUND, detail)
+}
+
+// Comment
+func Validation(errors SessionErrorsBag) *HttpError {
+	err := NewHttpError(HTTP_RESPONSE_UNPROCESSABLE_CONTENT, FormValidationErrorMessage)
+
+	err.Errors = errors
+
+	return err
+}
+
+// Comment
+func InternalServerError(err error) *HttpError {
+	return &HttpError{
+		Status: HTTP_RESPONSE_INTERNAL_SERVER_ERROR,
+		Type:   PROBLEM_DEFAULT_TYPE,
+		Title:  StatusText(HTTP_RESPONSE_INTERNAL_SERVER_ERROR),
+		Err:    err,
+	}
+}
+
+// Comment
+func (ctx *HttpError) Error() string {
+	message := strings.Join([]string{ctx.Title, ctx.Detail}, ": ")
+
+	if ctx.Detail == "" {
+		message = ctx.Title
+	}
+
+	if ctx.Err != nil {
+		return strings.Join([]string{message, ctx.Err.Error()}, ": ")
+	}
+
+	return message
+}
+
+// Comment
+func (ctx *HttpError) Unwrap() error {
+	return ctx.Err
+}
+
+// Comment
+func (ctx *HttpError) Problem() *Problem {
+	return &Problem{
+		Type:     ctx.Type,
+		Title:    ctx.Title,
+		Status:   int(ctx.Status),
+		Detail:   ctx.Detail,
+		Instance: ctx.Instance,
+		Errors:   ctx.Errors,
+	}
+}
+
+// Comment
+func ToHttpError(err error) *HttpError {
+	var httpError *HttpError
+
+	if errors.As(err, &httpError) {
+		return httpError
+	}
+
+	return InternalServerError(err)
+}
+
+// Comment
+func Handle(callback HandlerCallback) WebCallback {
+	return func(req *Request, res *Response) *Response {
+		r, err := callback(req, res)
+
+		if err != nil {
+			return res.Error(err)
+		}
+
+		return r
+	}
+}
+
+// Comment
+func (ctx *Response) Problem(err *HttpError) *Response {
+	data, _ := json.Marshal(err.Problem())
+
+	return ctx.SetStatus(err.Status).SetHeader("content-type", PROBLEM_JSON_CONTENT_TYPE).SetBody(data)
+}
+
+// Comment
+func (ctx *Response) Error(err error) *Response {
+	if ctx.Request == nil || ctx.Request.Server == nil {
+		return ctx.Problem(ToHttpError(err))
+	}
+
+	return ctx.Request.Server.HandleError(ctx.Request, err)
+}
+
+// Comment
+func (ctx *HTTP) ErrorHandler(handler ErrorHandler) *HTTP {
+	ctx.errorHandler = handler
+
+	return ctx
+}
+
+// Comment
+func (ctx *HTTP) SetErrorView(view string) *HTTP {
+	ctx.errorView = view
+
+	return ctx
+}
+
+// Comment
+func (ctx *HTTP) HandleError(req *Request, err error) *Response {
+	if ctx.errorHandler != nil {
+		if res := ctx.errorHandler(req, err); res != nil {
+			return res
+		}
+	}
+
+	return DefaultErrorHandler(req, err)
+}
+
+// Comment
+func (ctx *HTTP) errorPage(req *Request, err *HttpError) string {
+	view, ok := ctx.Get("view").(*View)
+
+	if !ok || ctx.errorView == "" {
+		return pages.ErrorPage(int(err.Status), err.Title, err.Detail)
+	}
+
+	html, e := view.Read(ctx.errorView, ViewData{
+		"status":  int(err.Status),
+		"title":   err.Title,
+		"detail":  err.Detail,
+		"problem": err.Problem(),
+	}, req)
+
+	if e != nil {
+		return pages.ErrorPage(int(err.Status), err.Title, err.Detail)
+	}
+
+	return string(html)
+}
+
+// Comment
+func DefaultErrorHandler(req *Request, err error) *Response {
+	httpError := ToHttpError(err)
+
+	// Json requests get problem+json even when they accept html.
+	if strings.ToLower(req.ContentType()) == "application/json" || req.Accepts("text/html", PROBLEM_JSON_CONTENT_TYPE, "application/json") != "text/html" {
+		return req.Response.Problem(httpError)
+	}
+
+	if req.Server == nil {
+		return req.Response.SetStatus(httpError.Status).Html(pages.ErrorPage(int(httpError.Status), httpError.Title, httpError.Detail))
+	}
+
+	return req.Response.SetStatus(httpError.Status).Html(req.Server.errorPage(req, httpError))
+}
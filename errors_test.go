@@ -0,0 +1,167 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/lucas11776-golang/http/types"
+	"github.com/open2b/scriggo"
+)
+
+func TestErrors(t *testing.T) {
+	request := func(t *testing.T, server *HTTP, path string, accept string) *Response {
+		req, err := NewRequest("GET", path, "HTTP/1.1", types.Headers{"accept": accept}, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		return server.HandleRequest(server.NewRequest(req.Request, nil))
+	}
+
+	t.Run("TestProblemJson", func(t *testing.T) {
+		server := Server("127.0.0.1", 0)
+
+		server.Route().Get("products/{product}", Handle(func(req *Request, res *Response) (*Response, error) {
+			return nil, NotFound("Product does not exist")
+		}))
+
+		res := request(t, server, "products/1", "application/json")
+
+		if res.StatusCode != int(HTTP_RESPONSE_NOT_FOUND) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", HTTP_RESPONSE_NOT_FOUND, res.StatusCode)
+		}
+
+		if res.GetHeader("content-type") != PROBLEM_JSON_CONTENT_TYPE {
+			t.Fatalf("Expected content-type to be (%s) but got (%s)", PROBLEM_JSON_CONTENT_TYPE, res.GetHeader("content-type"))
+		}
+
+		body, _ := io.ReadAll(res.Body)
+		problem := Problem{}
+
+		if err := json.Unmarshal(body, &problem); err != nil {
+			t.Fatalf("Failed to parse problem: %v", err)
+		}
+
+		if problem.Status != 404 || problem.Title != "Not Found" || problem.Detail != "Product does not exist" || problem.Type != PROBLEM_DEFAULT_TYPE {
+			t.Fatalf("Expected problem to be not found but got (%s)", string(body))
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestValidationProblem", func(t *testing.T) {
+		res := InitResponse().Error(Validation(SessionErrorsBag{"email": "The email is required"}))
+
+		body, _ := io.ReadAll(res.Body)
+		problem := Problem{}
+
+		if err := json.Unmarshal(body, &problem); err != nil {
+			t.Fatalf("Failed to parse problem: %v", err)
+		}
+
+		if problem.Status != int(HTTP_RESPONSE_UNPROCESSABLE_CONTENT) {
+			t.Fatalf("Expected problem status to be (%d) but got (%d)", HTTP_RESPONSE_UNPROCESSABLE_CONTENT, problem.Status)
+		}
+
+		if problem.Errors["email"] != "The email is required" {
+			t.Fatalf("Expected problem email error to be (%s) but got (%s)", "The email is required", problem.Errors["email"])
+		}
+	})
+
+	t.Run("TestUnknownErrorIsInternalServerError", func(t *testing.T) {
+		server := Server("127.0.0.1", 0)
+
+		server.Route().Get("/", Handle(func(req *Request, res *Response) (*Response, error) {
+			return nil, errors.New("database connection refused")
+		}))
+
+		res := request(t, server, "/", "text/html")
+
+		body, _ := io.ReadAll(res.Body)
+
+		if res.StatusCode != int(HTTP_RESPONSE_INTERNAL_SERVER_ERROR) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", HTTP_RESPONSE_INTERNAL_SERVER_ERROR, res.StatusCode)
+		}
+
+		if res.GetHeader("content-type") != "text/html" {
+			t.Fatalf("Expected content-type to be (%s) but got (%s)", "text/html", res.GetHeader("content-type"))
+		}
+
+		if strings.Contains(string(body), "database connection refused") {
+			t.Fatalf("Expected error page to not expose error message but got (%s)", string(body))
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestErrorView", func(t *testing.T) {
+		server := Server("127.0.0.1", 0)
+
+		server.Set("view", NewView(&viewReaderTest{
+			Files: scriggo.Files{"error.html": []byte(`<h1>{{ status }} - {{ title }}</h1>`)},
+		}, "html")).SetErrorView("error")
+
+		res := request(t, server, "missing", "text/html")
+
+		body, _ := io.ReadAll(res.Body)
+
+		if string(body) != "<h1>404 - Not Found</h1>" {
+			t.Fatalf("Expected body to be (%s) but got (%s)", "<h1>404 - Not Found</h1>", string(body))
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestCustomErrorHandler", func(t *testing.T) {
+		server := Server("127.0.0.1", 0)
+
+		server.ErrorHandler(func(req *Request, err error) *Response {
+			return req.Response.SetStatus(ToHttpError(err).Status).Text("custom: " + ToHttpError(err).Title)
+		})
+
+		server.Route().Get("admin", Handle(func(req *Request, res *Response) (*Response, error) {
+			return nil, Forbidden("")
+		}))
+
+		res := request(t, server, "admin", "application/json")
+
+		body, _ := io.ReadAll(res.Body)
+
+		if res.StatusCode != int(HTTP_RESPONSE_FORBIDDEN) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", HTTP_RESPONSE_FORBIDDEN, res.StatusCode)
+		}
+
+		if string(body) != "custom: Forbidden" {
+			t.Fatalf("Expected body to be (%s) but got (%s)", "custom: Forbidden", string(body))
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestMiddlewareErrorHandler", func(t *testing.T) {
+		server := Server("127.0.0.1", 0)
+
+		server.ErrorHandler(func(req *Request, err error) *Response {
+			return req.Response.SetStatus(ToHttpError(err).Status).Text("custom: " + ToHttpError(err).Title)
+		})
+
+		server.Route().Get("download", func(req *Request, res *Response) *Response {
+			return res.Text("download")
+		}).Middleware(ValidSignature)
+
+		res := request(t, server, "download", "application/json")
+
+		body, _ := io.ReadAll(res.Body)
+
+		// Errors of middlewares go through the error handler like errors of routes.
+		if res.StatusCode != int(HTTP_RESPONSE_FORBIDDEN) || string(body) != "custom: Forbidden" {
+			t.Fatalf("Expected body to be (%s) but got (%d, %s)", "custom: Forbidden", res.StatusCode, string(body))
+		}
+
+		server.Close()
+	})
+}
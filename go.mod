@@ -5,22 +5,25 @@ go 1.23.0
 toolchain go1.24.1
 
 require (
+	github.com/go-gomail/gomail v0.0.0-20160411212932-81ebce5c23df
+	github.com/golang-jwt/jwt/v5 v5.2.3
 	github.com/gorilla/sessions v1.4.0
 	github.com/lucas11776-golang/orm v0.0.0-20250708120329-d5d4a4de54ce
 	github.com/open2b/scriggo v0.60.0
 	github.com/quic-go/quic-go v0.53.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
 	golang.org/x/net v0.41.0
 	golang.org/x/text v0.26.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/antlr4-go/antlr/v4 v4.13.0 // indirect
-	github.com/go-gomail/gomail v0.0.0-20160411212932-81ebce5c23df // indirect
 	github.com/go-task/slim-sprig/v3 v3.0.0 // indirect
-	github.com/golang-jwt/jwt/v5 v5.2.3 // indirect
 	github.com/libsql/sqlite-antlr4-parser v0.0.0-20240327125255-dbf53b6cbf06 // indirect
 	github.com/mattn/go-sqlite3 v1.14.24 // indirect
 	github.com/tursodatabase/go-libsql v0.0.0-20250609073118-9c24e0e7fa97 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	go.uber.org/automaxprocs v1.6.0 // indirect
 	gopkg.in/alexcesaro/quotedprintable.v3 v3.0.0-20150716171945-2caba252f4dc // indirect
 )
@@ -28,13 +31,13 @@ require (
 require (
 	github.com/go-task/slim-sprig v0.0.0-20230315185526-52ccab3ef572 // indirect
 	github.com/google/pprof v0.0.0-20250630185457-6e76a2b096b5 // indirect
-	github.com/gorilla/securecookie v1.1.2 // indirect
+	github.com/gorilla/securecookie v1.1.2
 	github.com/joho/godotenv v1.5.1
 	github.com/onsi/ginkgo/v2 v2.23.4 // indirect
 	github.com/quic-go/qpack v0.5.1 // indirect
 	github.com/spf13/cast v1.9.2
 	go.uber.org/mock v0.5.2 // indirect
-	golang.org/x/crypto v0.39.0 // indirect
+	golang.org/x/crypto v0.39.0
 	golang.org/x/exp v0.0.0-20250606033433-dcc06ee1d476 // indirect
 	golang.org/x/mod v0.25.0 // indirect
 	golang.org/x/sync v0.15.0 // indirect
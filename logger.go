@@ -0,0 +1,53 @@
+package http
+
+import (
+	"log/slog"
+	"strings"
+)
+
+const (
+	REQUEST_ID_KEY = "request.id"
+)
+
+// Comment
+func (ctx *HTTP) SetLogger(logger *slog.Logger) *HTTP {
+	ctx.logger = logger
+
+	return ctx
+}
+
+// Comment
+func (ctx *HTTP) Logger() *slog.Logger {
+	if ctx.logger == nil {
+		return slog.Default()
+	}
+
+	return ctx.logger
+}
+
+// Comment
+func (ctx *Request) SetRequestId(id string) *Request {
+	return ctx.Set(REQUEST_ID_KEY, id)
+}
+
+// Comment
+func (ctx *Request) RequestId() string {
+	id, _ := ctx.Get(REQUEST_ID_KEY).(string)
+
+	return id
+}
+
+// Comment
+func (ctx *Request) Logger() *slog.Logger {
+	logger := slog.Default()
+
+	if ctx.Server != nil {
+		logger = ctx.Server.Logger()
+	}
+
+	if id := ctx.RequestId(); id != "" {
+		logger = logger.With(slog.String("request_id", id))
+	}
+
+	return logger.With(slog.String("method", ctx.Method), slog.String("path", "/"+strings.TrimPrefix(ctx.URL.Path, "/")))
+}
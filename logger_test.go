@@ -0,0 +1,58 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/lucas11776-golang/http/types"
+)
+
+func TestLogger(t *testing.T) {
+	t.Run("TestDefaultLogger", func(t *testing.T) {
+		if (&HTTP{}).Logger() != slog.Default() {
+			t.Fatalf("Expected server without logger to use the default logger")
+		}
+	})
+
+	t.Run("TestRequestLogger", func(t *testing.T) {
+		logs := &bytes.Buffer{}
+
+		req, err := NewRequest("GET", "/orders", "HTTP/1.1", make(types.Headers), bytes.NewReader([]byte{}))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		req.Server = (&HTTP{}).SetLogger(slog.New(slog.NewJSONHandler(logs, nil)))
+
+		Recover(req.SetRequestId("b7e1"), req.Response, func() *Response {
+			panic("orders table is missing")
+		})
+
+		entry := map[string]interface{}{}
+
+		if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+			t.Fatalf("Something went wrong when trying to decode log: %s", err.Error())
+		}
+
+		expected := map[string]interface{}{
+			"level":      "ERROR",
+			"msg":        "panic: orders table is missing",
+			"request_id": "b7e1",
+			"method":     "GET",
+			"path":       "/orders",
+		}
+
+		for key, value := range expected {
+			if entry[key] != value {
+				t.Fatalf("Expected log (%s) to be (%v) but got (%v)", key, value, entry[key])
+			}
+		}
+
+		if _, ok := entry["stack"].(string); !ok {
+			t.Fatalf("Expected panic log to have stack")
+		}
+	})
+}
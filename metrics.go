@@ -0,0 +1,102 @@
+package http
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucas11776-golang/http/metrics"
+)
+
+const (
+	METRICS_PATH         = "metrics"
+	METRICS_OTHER_METHOD = "OTHER"
+)
+
+var (
+	requestsTotal        = metrics.NewCounter("http_requests_total", "Total number of HTTP requests.", "route", "method", "status", "protocol")
+	requestDuration      = metrics.NewHistogram("http_request_duration_seconds", "HTTP request duration in seconds.", "route", "method", "status", "protocol")
+	requestsInFlight     = metrics.NewGauge("http_requests_in_flight", "Number of HTTP requests being handled.")
+	websocketConnections = metrics.NewGauge("http_websocket_connections", "Number of open WebSocket connections.")
+	websocketMessages    = metrics.NewCounter("http_websocket_messages_total", "Total number of WebSocket messages.", "direction")
+	sessionStoreDuration = metrics.NewHistogram("http_session_store_duration_seconds", "Session store operation duration in seconds.", "operation")
+	viewRenderDuration   = metrics.NewHistogram("http_view_render_duration_seconds", "View render duration in seconds.", "view")
+)
+
+type metricsSessionStore struct {
+	store SessionStore
+}
+
+// Comment
+func (ctx *HTTP) Metrics(path ...string) *Route {
+	uri := METRICS_PATH
+
+	if len(path) > 0 {
+		uri = path[0]
+	}
+
+	return ctx.Route().Get(uri, func(req *Request, res *Response) *Response {
+		body := &bytes.Buffer{}
+
+		if err := metrics.Default().Write(body); err != nil {
+			return res.Error(err)
+		}
+
+		return res.SetHeader("content-type", metrics.CONTENT_TYPE).SetBody(body.Bytes())
+	})
+}
+
+// Comment
+func observeRequest(req *Request, res *Response, start time.Time) {
+	route := ""
+
+	// Unmatched requests share a label so scanners can not create a series per path.
+	if req.Route() != nil {
+		route = "/" + strings.TrimPrefix(req.Route().Path(), "/")
+	}
+
+	labels := []string{route, metricsMethod(req.Method), strconv.Itoa(res.StatusCode), req.Protocol()}
+
+	requestsTotal.Inc(labels...)
+	requestDuration.ObserveSince(start, labels...)
+}
+
+// Comment
+func metricsMethod(method string) string {
+	switch m := Method(strings.ToUpper(method)); m {
+	case METHOD_GET, METHOD_POST, METHOD_PUT, METHOD_PATCH, METHOD_DELETE, METHOD_HEAD, METHOD_OPTIONS, METHOD_CONNECT, "TRACE":
+		return string(m)
+	default:
+		// Clients choose the method, unknown methods share a label so they can not create a series each.
+		return METRICS_OTHER_METHOD
+	}
+}
+
+// Comment
+func (ctx *metricsSessionStore) Read(id string) ([]byte, error) {
+	defer sessionStoreDuration.ObserveSince(time.Now(), "read")
+
+	return ctx.store.Read(id)
+}
+
+// Comment
+func (ctx *metricsSessionStore) Write(id string, data []byte, ttl time.Duration) error {
+	defer sessionStoreDuration.ObserveSince(time.Now(), "write")
+
+	return ctx.store.Write(id, data, ttl)
+}
+
+// Comment
+func (ctx *metricsSessionStore) Destroy(id string) error {
+	defer sessionStoreDuration.ObserveSince(time.Now(), "destroy")
+
+	return ctx.store.Destroy(id)
+}
+
+// Comment
+func (ctx *metricsSessionStore) Gc() error {
+	defer sessionStoreDuration.ObserveSince(time.Now(), "gc")
+
+	return ctx.store.Gc()
+}
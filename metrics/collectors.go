@@ -0,0 +1,317 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type series struct {
+	values  []string
+	value   float64
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+type metric struct {
+	name   string
+	help   string
+	labels []string
+	mutex  sync.Mutex
+	series map[string]*series
+}
+
+type Counter struct {
+	*metric
+}
+
+type Gauge struct {
+	*metric
+}
+
+type GaugeFunc struct {
+	name  string
+	help  string
+	value func() float64
+}
+
+type Histogram struct {
+	*metric
+	buckets []float64
+}
+
+// Comment
+func newMetric(name string, help string, labels []string) *metric {
+	return &metric{name: name, help: help, labels: labels, series: map[string]*series{}}
+}
+
+// Comment
+func (ctx *metric) Name() string {
+	return ctx.name
+}
+
+// Comment
+func (ctx *metric) get(values []string, buckets int) *series {
+	if len(values) != len(ctx.labels) {
+		panic(fmt.Errorf("%w: %s %v", ErrLabels, ctx.name, values))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	if s, ok := ctx.series[key]; ok {
+		return s
+	}
+
+	s := &series{values: slices.Clone(values), buckets: make([]uint64, buckets)}
+
+	ctx.series[key] = s
+
+	return s
+}
+
+// Comment
+func (ctx *metric) find(values []string) *series {
+	if s, ok := ctx.series[strings.Join(values, "\xff")]; ok {
+		return s
+	}
+
+	return &series{}
+}
+
+// Comment
+func (ctx *metric) sorted() []*series {
+	keys := make([]string, 0, len(ctx.series))
+
+	for key := range ctx.series {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	sorted := make([]*series, len(keys))
+
+	for i, key := range keys {
+		sorted[i] = ctx.series[key]
+	}
+
+	return sorted
+}
+
+// Comment
+func (ctx *metric) add(value float64, values []string) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.get(values, 0).value += value
+}
+
+// Comment
+func (ctx *metric) value(values []string) float64 {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	return ctx.find(values).value
+}
+
+// Comment
+func (ctx *metric) write(w io.Writer, kind string) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if err := header(w, ctx.name, ctx.help, kind); err != nil {
+		return err
+	}
+
+	for _, s := range ctx.sorted() {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", ctx.name, labels(ctx.labels, s.values), format(s.value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Comment
+func (ctx *Counter) Type() string {
+	return COUNTER
+}
+
+// Comment
+func (ctx *Counter) Inc(values ...string) {
+	ctx.add(1, values)
+}
+
+// Comment
+func (ctx *Counter) Add(value float64, values ...string) {
+	// Counters can only go up, a negative value would look like a restart.
+	if value < 0 {
+		return
+	}
+
+	ctx.add(value, values)
+}
+
+// Comment
+func (ctx *Counter) Value(values ...string) float64 {
+	return ctx.value(values)
+}
+
+// Comment
+func (ctx *Counter) Write(w io.Writer) error {
+	return ctx.write(w, COUNTER)
+}
+
+// Comment
+func (ctx *Gauge) Type() string {
+	return GAUGE
+}
+
+// Comment
+func (ctx *Gauge) Set(value float64, values ...string) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.get(values, 0).value = value
+}
+
+// Comment
+func (ctx *Gauge) Inc(values ...string) {
+	ctx.add(1, values)
+}
+
+// Comment
+func (ctx *Gauge) Dec(values ...string) {
+	ctx.add(-1, values)
+}
+
+// Comment
+func (ctx *Gauge) Add(value float64, values ...string) {
+	ctx.add(value, values)
+}
+
+// Comment
+func (ctx *Gauge) Value(values ...string) float64 {
+	return ctx.value(values)
+}
+
+// Comment
+func (ctx *Gauge) Write(w io.Writer) error {
+	return ctx.write(w, GAUGE)
+}
+
+// Comment
+func (ctx *GaugeFunc) Name() string {
+	return ctx.name
+}
+
+// Comment
+func (ctx *GaugeFunc) Type() string {
+	return GAUGE
+}
+
+// Comment
+func (ctx *GaugeFunc) Write(w io.Writer) error {
+	if err := header(w, ctx.name, ctx.help, GAUGE); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%s %s\n", ctx.name, format(ctx.value()))
+
+	return err
+}
+
+// Comment
+func (ctx *Histogram) Type() string {
+	return HISTOGRAM
+}
+
+// Comment
+func (ctx *Histogram) Buckets(buckets ...float64) *Histogram {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.buckets = slices.Clone(buckets)
+
+	slices.Sort(ctx.buckets)
+
+	// Observations counted in the old buckets can not be moved.
+	ctx.series = map[string]*series{}
+
+	return ctx
+}
+
+// Comment
+func (ctx *Histogram) Observe(value float64, values ...string) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	s := ctx.get(values, len(ctx.buckets))
+
+	if i, _ := slices.BinarySearch(ctx.buckets, value); i < len(ctx.buckets) {
+		s.buckets[i]++
+	}
+
+	s.sum += value
+	s.count++
+}
+
+// Comment
+func (ctx *Histogram) ObserveSince(start time.Time, values ...string) {
+	ctx.Observe(time.Since(start).Seconds(), values...)
+}
+
+// Comment
+func (ctx *Histogram) Count(values ...string) uint64 {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	return ctx.find(values).count
+}
+
+// Comment
+func (ctx *Histogram) Sum(values ...string) float64 {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	return ctx.find(values).sum
+}
+
+// Comment
+func (ctx *Histogram) Write(w io.Writer) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if err := header(w, ctx.name, ctx.help, HISTOGRAM); err != nil {
+		return err
+	}
+
+	names := append(slices.Clone(ctx.labels), "le")
+
+	for _, s := range ctx.sorted() {
+		var cumulative uint64
+
+		for i, le := range ctx.buckets {
+			cumulative += s.buckets[i]
+
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", ctx.name, labels(names, append(slices.Clone(s.values), format(le))), cumulative); err != nil {
+				return err
+			}
+		}
+
+		_, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			ctx.name, labels(names, append(slices.Clone(s.values), "+Inf")), s.count,
+			ctx.name, labels(ctx.labels, s.values), format(s.sum),
+			ctx.name, labels(ctx.labels, s.values), s.count,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+	COUNTER      = "counter"
+	GAUGE        = "gauge"
+	HISTOGRAM    = "histogram"
+)
+
+var DEFAULT_BUCKETS = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var (
+	ErrMetricExists = errors.New("metric already registered with another type")
+	ErrLabels       = errors.New("metric label values do not match label names")
+)
+
+type Collector interface {
+	Name() string
+	Type() string
+	Write(w io.Writer) error
+}
+
+type Registry struct {
+	mutex      sync.RWMutex
+	collectors map[string]Collector
+}
+
+var registry = NewRegistry()
+
+// Comment
+func NewRegistry() *Registry {
+	return &Registry{collectors: map[string]Collector{}}
+}
+
+// Comment
+func Default() *Registry {
+	return registry
+}
+
+// Comment
+func (ctx *Registry) Register(collector Collector) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if _, ok := ctx.collectors[collector.Name()]; ok {
+		return fmt.Errorf("%w: %s", ErrMetricExists, collector.Name())
+	}
+
+	ctx.collectors[collector.Name()] = collector
+
+	return nil
+}
+
+// Comment
+func (ctx *Registry) Unregister(name string) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	delete(ctx.collectors, name)
+}
+
+// Comment
+func (ctx *Registry) Get(name string) Collector {
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+
+	return ctx.collectors[name]
+}
+
+// Comment
+func register[T Collector](ctx *Registry, name string, create func() T) T {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if collector, ok := ctx.collectors[name]; ok {
+		existing, ok := collector.(T)
+
+		if !ok {
+			panic(fmt.Errorf("%w: %s", ErrMetricExists, name))
+		}
+
+		return existing
+	}
+
+	collector := create()
+
+	ctx.collectors[name] = collector
+
+	return collector
+}
+
+// Comment
+func (ctx *Registry) Counter(name string, help string, labels ...string) *Counter {
+	return register(ctx, name, func() *Counter {
+		return &Counter{metric: newMetric(name, help, labels)}
+	})
+}
+
+// Comment
+func (ctx *Registry) Gauge(name string, help string, labels ...string) *Gauge {
+	return register(ctx, name, func() *Gauge {
+		return &Gauge{metric: newMetric(name, help, labels)}
+	})
+}
+
+// Comment
+func (ctx *Registry) GaugeFunc(name string, help string, value func() float64) *GaugeFunc {
+	return register(ctx, name, func() *GaugeFunc {
+		return &GaugeFunc{name: name, help: help, value: value}
+	})
+}
+
+// Comment
+func (ctx *Registry) Histogram(name string, help string, labels ...string) *Histogram {
+	return register(ctx, name, func() *Histogram {
+		return &Histogram{metric: newMetric(name, help, labels), buckets: DEFAULT_BUCKETS}
+	})
+}
+
+// Comment
+func (ctx *Registry) Write(w io.Writer) error {
+	ctx.mutex.RLock()
+
+	names := make([]string, 0, len(ctx.collectors))
+
+	for name := range ctx.collectors {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	collectors := make([]Collector, 0, len(names))
+
+	for _, name := range names {
+		collectors = append(collectors, ctx.collectors[name])
+	}
+
+	ctx.mutex.RUnlock()
+
+	writer := bufio.NewWriter(w)
+
+	for _, collector := range collectors {
+		if err := collector.Write(writer); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// Comment
+func (ctx *Registry) String() string {
+	builder := &strings.Builder{}
+
+	ctx.Write(builder)
+
+	return builder.String()
+}
+
+// Comment
+func NewCounter(name string, help string, labels ...string) *Counter {
+	return Default().Counter(name, help, labels...)
+}
+
+// Comment
+func NewGauge(name string, help string, labels ...string) *Gauge {
+	return Default().Gauge(name, help, labels...)
+}
+
+// Comment
+func NewGaugeFunc(name string, help string, value func() float64) *GaugeFunc {
+	return Default().GaugeFunc(name, help, value)
+}
+
+// Comment
+func NewHistogram(name string, help string, labels ...string) *Histogram {
+	return Default().Histogram(name, help, labels...)
+}
+
+// Comment
+func header(w io.Writer, name string, help string, kind string) error {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+
+	return err
+}
+
+// Comment
+func labels(names []string, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	for i, name := range names {
+		pairs[i] = name + `="` + replacer.Replace(values[i]) + `"`
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Comment
+func format(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
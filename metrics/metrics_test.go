@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Run("TestCounterAndGauge", func(t *testing.T) {
+		registry := NewRegistry()
+
+		orders := registry.Counter("orders_total", "Orders placed.", "status")
+		queue := registry.Gauge("queue_size", "Jobs waiting in the queue.")
+
+		orders.Inc("paid")
+		orders.Add(2, "paid")
+		orders.Add(-1, "paid")
+		orders.Inc(`fai"led`)
+		queue.Set(5)
+		queue.Dec()
+
+		registry.GaugeFunc("workers", "Running workers.", func() float64 { return 3 })
+
+		expected := "# HELP orders_total Orders placed.\n" +
+			"# TYPE orders_total counter\n" +
+			"orders_total{status=\"fai\\\"led\"} 1\n" +
+			"orders_total{status=\"paid\"} 3\n" +
+			"# HELP queue_size Jobs waiting in the queue.\n" +
+			"# TYPE queue_size gauge\n" +
+			"queue_size 4\n" +
+			"# HELP workers Running workers.\n" +
+			"# TYPE workers gauge\n" +
+			"workers 3\n"
+
+		if registry.String() != expected {
+			t.Fatalf("Expected metrics to be (%s) but got (%s)", expected, registry.String())
+		}
+
+		if registry.Counter("orders_total", "Orders placed.", "status") != orders || orders.Value("refunded") != 0 {
+			t.Fatalf("Expected registry to return the registered counter")
+		}
+	})
+
+	t.Run("TestHistogram", func(t *testing.T) {
+		registry := NewRegistry()
+
+		latency := registry.Histogram("latency_seconds", "Request latency.", "route").Buckets(0.5, 0.1)
+
+		for _, value := range []float64{0.05, 0.1, 0.3, 2} {
+			latency.Observe(value, "/orders")
+		}
+
+		expected := "# HELP latency_seconds Request latency.\n" +
+			"# TYPE latency_seconds histogram\n" +
+			"latency_seconds_bucket{route=\"/orders\",le=\"0.1\"} 2\n" +
+			"latency_seconds_bucket{route=\"/orders\",le=\"0.5\"} 3\n" +
+			"latency_seconds_bucket{route=\"/orders\",le=\"+Inf\"} 4\n" +
+			"latency_seconds_sum{route=\"/orders\"} 2.45\n" +
+			"latency_seconds_count{route=\"/orders\"} 4\n"
+
+		if registry.String() != expected {
+			t.Fatalf("Expected metrics to be (%s) but got (%s)", expected, registry.String())
+		}
+	})
+
+	t.Run("TestRegister", func(t *testing.T) {
+		registry := NewRegistry()
+
+		registry.Gauge("users", "Users.")
+
+		if err := registry.Register(&GaugeFunc{name: "users"}); !errors.Is(err, ErrMetricExists) {
+			t.Fatalf("Expected error (%v) but got (%v)", ErrMetricExists, err)
+		}
+
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, ErrMetricExists) {
+				t.Fatalf("Expected panic (%v) but got (%v)", ErrMetricExists, err)
+			}
+		}()
+
+		registry.Counter("users", "Users.")
+	})
+
+	t.Run("TestLabels", func(t *testing.T) {
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, ErrLabels) {
+				t.Fatalf("Expected panic (%v) but got (%v)", ErrLabels, err)
+			}
+		}()
+
+		NewRegistry().Counter("orders_total", "Orders placed.", "status").Inc()
+	})
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lucas11776-golang/http/metrics"
+	"github.com/lucas11776-golang/http/types"
+	req "github.com/lucas11776-golang/http/utils/request"
+)
+
+func TestMetrics(t *testing.T) {
+	server := Server("127.0.0.1", 0)
+
+	server.Session(nil, NewMemorySessionStore())
+
+	server.Set("view", NewView(fstest.MapFS{
+		"metrics-order.html": {Data: []byte(`<h1>Order {{ order }}</h1>`)},
+	}, "html"))
+
+	orders := metrics.NewCounter("app_metrics_orders_total", "Orders placed.", "status")
+
+	server.Route().Get("metrics-test/orders/{order}", func(req *Request, res *Response) *Response {
+		req.Session.Set("order", req.Parameters.Get("order"))
+
+		orders.Inc("paid")
+
+		return res.View("metrics-order", ViewData{"order": req.Parameters.Get("order")})
+	})
+
+	server.Metrics("internal/metrics").Middleware(func(req *Request, res *Response, next Next) *Response {
+		if req.GetHeader("authorization") != "Bearer metrics" {
+			return res.Error(Unauthorized(""))
+		}
+
+		return next()
+	})
+
+	go server.Listen()
+
+	get := func(uri string, headers types.Headers) *Response {
+		http, err := req.CreateRequest().SetHeaders(headers).Get("http://" + server.Host() + "/" + uri)
+
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+
+		res, err := HttpToResponse(http)
+
+		if err != nil {
+			t.Fatalf("Failed to parse http: %v", err)
+		}
+
+		return res
+	}
+
+	get("metrics-test/orders/1", types.Headers{})
+	get("metrics-test/orders/2", types.Headers{})
+
+	for _, method := range []string{"PURGE", "FOO1"} {
+		req, err := NewRequest(Method(method), "metrics-test/missing", "HTTP/1.1", types.Headers{}, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		server.HandleRequest(server.NewRequest(req.Request, nil))
+	}
+
+	if res := get("internal/metrics", types.Headers{}); res.StatusCode != int(HTTP_RESPONSE_UNAUTHORIZED) {
+		t.Fatalf("Expected status code to be (%d) but got (%d)", HTTP_RESPONSE_UNAUTHORIZED, res.StatusCode)
+	}
+
+	res := get("internal/metrics", types.Headers{"authorization": "Bearer metrics"})
+
+	if res.GetHeader("Content-Type") != metrics.CONTENT_TYPE {
+		t.Fatalf("Expected header content-type to be (%s) but got (%s)", metrics.CONTENT_TYPE, res.GetHeader("Content-Type"))
+	}
+
+	body, _ := io.ReadAll(res.Body)
+
+	expected := []string{
+		`http_requests_total{route="/metrics-test/orders/{order}",method="GET",status="200",protocol="HTTP/1.1"} 2`,
+		`http_request_duration_seconds_count{route="/metrics-test/orders/{order}",method="GET",status="200",protocol="HTTP/1.1"} 2`,
+		`http_request_duration_seconds_bucket{route="/metrics-test/orders/{order}",method="GET",status="200",protocol="HTTP/1.1",le="+Inf"} 2`,
+		`http_requests_total{route="/internal/metrics",method="GET",status="401",protocol="HTTP/1.1"}`,
+		`http_requests_in_flight 1`,
+		`http_view_render_duration_seconds_count{view="metrics-order"} 2`,
+		`http_session_store_duration_seconds_count{operation="write"}`,
+		"# TYPE http_websocket_connections gauge\n",
+		`app_metrics_orders_total{status="paid"} 2`,
+		`http_requests_total{route="",method="OTHER",status="404",protocol="HTTP/1.1"} 2`,
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(string(body), line) {
+			t.Fatalf("Expected metrics to contain (%s) but got (%s)", line, string(body))
+		}
+	}
+
+	for _, method := range []string{"PURGE", "FOO1"} {
+		if strings.Contains(string(body), `method="`+method+`"`) {
+			t.Fatalf("Expected metrics to not contain method (%s)", method)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/auth"
+)
+
+const (
+	ACCESS_LOG_COMMON   = "common"
+	ACCESS_LOG_COMBINED = "combined"
+	ACCESS_LOG_JSON     = "json"
+	ACCESS_LOG_TIME     = "02/Jan/2006:15:04:05 -0700"
+)
+
+type AccessLogUser func(req *http.Request) string
+
+type AccessLogEntry struct {
+	Time      time.Time
+	Ip        string
+	User      string
+	Method    string
+	Uri       string
+	Protocol  string
+	Route     string
+	Status    int
+	Bytes     int
+	Latency   time.Duration
+	Referer   string
+	UserAgent string
+	RequestId string
+}
+
+type AccessLog struct {
+	format string
+	output io.Writer
+	user   AccessLogUser
+	logger *slog.Logger
+	mutex  sync.Mutex
+}
+
+// The default logger is shared so every request writes through the same mutex.
+var accessLog = NewAccessLog(ACCESS_LOG_COMBINED).Middleware()
+
+// Comment
+func NewAccessLog(format string) *AccessLog {
+	return (&AccessLog{format: format, user: AccessLogAuthUser}).Output(os.Stdout)
+}
+
+// Comment
+func AccessLogMiddleware(req *http.Request, res *http.Response, next http.Next) *http.Response {
+	return accessLog(req, res, next)
+}
+
+// Comment
+func (ctx *AccessLog) Output(output io.Writer) *AccessLog {
+	ctx.output = output
+	ctx.logger = slog.New(slog.NewJSONHandler(output, nil))
+
+	return ctx
+}
+
+// Comment
+func (ctx *AccessLog) User(user AccessLogUser) *AccessLog {
+	ctx.user = user
+
+	return ctx
+}
+
+// Comment
+func AccessLogAuthUser(req *http.Request) string {
+	if user := AuthUser(req); user != "" {
+		return user
+	}
+
+	// Only requests that used a guard are checked so the log does not load users on every request.
+	if _, ok := req.Get(auth.REQUEST_AUTH_KEY).(*auth.Authenticator); !ok || !auth.Auth(req).Has() {
+		return ""
+	}
+
+	return auth.Auth(req).Id()
+}
+
+// Comment
+func (ctx *AccessLog) entry(req *http.Request, res *http.Response, start time.Time, size int) *AccessLogEntry {
+	entry := &AccessLogEntry{
+		Time:      start,
+		User:      ctx.user(req),
+		Method:    req.Method,
+		Uri:       "/" + strings.TrimPrefix(req.URL.RequestURI(), "/"),
+		Protocol:  req.Protocol(),
+		Status:    res.StatusCode,
+		Bytes:     size,
+		Latency:   time.Since(start),
+		Referer:   req.GetHeader("referer"),
+		UserAgent: req.GetHeader("user-agent"),
+		RequestId: req.RequestId(),
+	}
+
+	if req.Conn != nil {
+		entry.Ip = req.IP()
+	} else if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		entry.Ip = host
+	}
+
+	if route := req.Route(); route != nil {
+		entry.Route = "/" + strings.TrimPrefix(route.Path(), "/")
+	}
+
+	return entry
+}
+
+// Comment
+func (ctx *AccessLog) Log(entry *AccessLogEntry) {
+	if ctx.format == ACCESS_LOG_JSON {
+		ctx.logger.LogAttrs(context.Background(), slog.LevelInfo, "request",
+			slog.String("ip", entry.Ip),
+			slog.String("user", entry.User),
+			slog.String("method", entry.Method),
+			slog.String("uri", entry.Uri),
+			slog.String("protocol", entry.Protocol),
+			slog.String("route", entry.Route),
+			slog.Int("status", entry.Status),
+			slog.Int("bytes", entry.Bytes),
+			slog.Float64("latency_ms", float64(entry.Latency.Microseconds())/1000),
+			slog.String("referer", entry.Referer),
+			slog.String("user_agent", entry.UserAgent),
+			slog.String("request_id", entry.RequestId),
+		)
+
+		return
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	io.WriteString(ctx.output, entry.String(ctx.format)+"\n")
+}
+
+// Comment
+func (ctx *AccessLogEntry) String(format string) string {
+	bytes := "-"
+
+	if ctx.Bytes > 0 {
+		bytes = strconv.Itoa(ctx.Bytes)
+	}
+
+	line := fmt.Sprintf(`%s - %s [%s] "%s %s %s" %d %s`,
+		dash(ctx.Ip),
+		dash(ctx.User),
+		ctx.Time.Format(ACCESS_LOG_TIME),
+		ctx.Method,
+		ctx.Uri,
+		ctx.Protocol,
+		ctx.Status,
+		bytes,
+	)
+
+	if format == ACCESS_LOG_COMBINED {
+		line += fmt.Sprintf(` "%s" "%s"`, dash(ctx.Referer), dash(ctx.UserAgent))
+	}
+
+	// Fields after the standard format are ignored by common log parsers.
+	return line + fmt.Sprintf(` %.3fms "%s" %s`, float64(ctx.Latency.Microseconds())/1000, dash(ctx.Route), dash(ctx.RequestId))
+}
+
+// Comment
+func dash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	// Quotes and new lines in headers must not be able to forge log lines.
+	quoted := strconv.Quote(value)
+
+	return quoted[1 : len(quoted)-1]
+}
+
+// Comment
+func (ctx *AccessLog) Middleware() http.Middleware {
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		start := time.Now()
+
+		if res = next(); res == nil {
+			return res
+		}
+
+		size := 0
+
+		if res.Body != nil {
+			body, _ := io.ReadAll(res.Body)
+
+			res.SetBody(body)
+
+			size = len(body)
+		}
+
+		ctx.Log(ctx.entry(req, res, start, size))
+
+		return res
+	}
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/lucas11776-golang/http"
+	htesting "github.com/lucas11776-golang/http/testing"
+	"github.com/lucas11776-golang/http/types"
+)
+
+func TestAccessLog(t *testing.T) {
+	t.Run("TestCombined", func(t *testing.T) {
+		server := http.Server("127.0.0.1", 0)
+		logs := &bytes.Buffer{}
+
+		server.Use(RequestId, NewAccessLog(ACCESS_LOG_COMBINED).Output(logs).Middleware())
+
+		server.Route().Get("users/{user}", func(req *http.Request, res *http.Response) *http.Response {
+			return res.Text("Jane")
+		}).Middleware(BasicAuth("Users", Users(map[string]string{"admin": "secret"})))
+
+		go server.Listen()
+
+		testCase := htesting.NewTestCase(t, server, false)
+
+		defer testCase.Cleanup()
+
+		testCase.Request().
+			WithBasicAuth("admin", "secret").
+			SetHeaders(types.Headers{"X-Request-ID": "req-1", "Referer": "https://app.test", "User-Agent": `curl "8"`}).
+			Get("users/1?tab=posts").
+			AssertOk()
+
+		pattern := `^- - admin \[[^\]]+\] "GET /users/1\?tab=posts HTTP/1\.1" 200 4 "https://app\.test" "curl \\"8\\"" [0-9.]+ms "/users/\{user\}" req-1\n$`
+
+		if !regexp.MustCompile(pattern).MatchString(logs.String()) {
+			t.Fatalf("Expected access log to match (%s) but got (%s)", pattern, logs.String())
+		}
+	})
+
+	t.Run("TestCommon", func(t *testing.T) {
+		entry := &AccessLogEntry{Method: "GET", Uri: "/", Protocol: "HTTP/2.0", Status: 304}
+
+		if line := entry.String(ACCESS_LOG_COMMON); !strings.HasPrefix(line, `- - - [`) || !strings.Contains(line, `] "GET / HTTP/2.0" 304 - 0.000ms "-" -`) {
+			t.Fatalf("Expected common log line but got (%s)", line)
+		}
+	})
+
+	t.Run("TestJson", func(t *testing.T) {
+		server := http.Server("127.0.0.1", 0)
+		logs := &bytes.Buffer{}
+
+		server.Use(NewAccessLog(ACCESS_LOG_JSON).Output(logs).Middleware())
+
+		server.Route().Post("orders", func(req *http.Request, res *http.Response) *http.Response {
+			return res.SetStatus(http.HTTP_RESPONSE_CREATED).Json(map[string]int{"id": 1})
+		})
+
+		go server.Listen()
+
+		testCase := htesting.NewTestCase(t, server, false)
+
+		defer testCase.Cleanup()
+
+		testCase.Request().Post("orders", []byte{}).AssertStatusCode(http.HTTP_RESPONSE_CREATED)
+
+		entry := map[string]interface{}{}
+
+		if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+			t.Fatalf("Something went wrong when trying to decode access log: %v", err)
+		}
+
+		expected := map[string]interface{}{
+			"msg":      "request",
+			"method":   "POST",
+			"route":    "/orders",
+			"protocol": "HTTP/1.1",
+			"status":   float64(201),
+			"bytes":    float64(8),
+		}
+
+		for key, value := range expected {
+			if entry[key] != value {
+				t.Fatalf("Expected access log (%s) to be (%v) but got (%v)", key, value, entry[key])
+			}
+		}
+
+		if _, ok := entry["latency_ms"].(float64); !ok {
+			t.Fatalf("Expected access log to have latency")
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lucas11776-golang/http"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	REQUEST_AUTH_USER_KEY = "auth.user"
+	UnauthorizedMessage   = "Unauthorized."
+)
+
+var ErrUnsupportedHtpasswd = errors.New("unsupported htpasswd entry")
+
+// Unknown users are compared against a dummy hash so they take as long as known users.
+const htpasswdDummyHash = "$2a$10$kQ5l8k8pck3G2s/6LJuZVeNi4w9vjD3/bPg0eIasYTJ5IbwgXRueK"
+
+type BasicVerifier func(username string, password string) bool
+
+type Htpasswd map[string]string
+
+// Comment
+func BasicAuth(realm string, verifier BasicVerifier) http.Middleware {
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		username, password, ok := req.BasicAuth()
+
+		if !ok || !verifier(username, password) {
+			return res.SetHeader("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s", charset="UTF-8"`, quote(realm))).
+				Error(http.Unauthorized(UnauthorizedMessage))
+		}
+
+		req.Set(REQUEST_AUTH_USER_KEY, username)
+
+		return next()
+	}
+}
+
+// Comment
+func AuthUser(req *http.Request) string {
+	username, _ := req.Get(REQUEST_AUTH_USER_KEY).(string)
+
+	return username
+}
+
+// Comment
+func SecureCompare(a string, b string) bool {
+	// Digests have a fixed length so the comparison does not leak the length of the secret.
+	x, y := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+
+	return subtle.ConstantTimeCompare(x[:], y[:]) == 1
+}
+
+// Comment
+func Users(users map[string]string) BasicVerifier {
+	return func(username string, password string) bool {
+		expected, ok := users[username]
+
+		return SecureCompare(expected, password) && ok
+	}
+}
+
+// Comment
+func LoadHtpasswd(path string) (Htpasswd, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return ParseHtpasswd(file)
+}
+
+// Comment
+func ParseHtpasswd(reader io.Reader) (Htpasswd, error) {
+	htpasswd := Htpasswd{}
+	scanner := bufio.NewScanner(reader)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		entry := strings.TrimSpace(scanner.Text())
+
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		username, hashed, ok := strings.Cut(entry, ":")
+
+		if !ok || username == "" || !supportedHtpasswd(hashed) {
+			return nil, fmt.Errorf("%w on line %d", ErrUnsupportedHtpasswd, line)
+		}
+
+		htpasswd[username] = hashed
+	}
+
+	return htpasswd, scanner.Err()
+}
+
+// Comment
+func supportedHtpasswd(hashed string) bool {
+	for _, prefix := range []string{"$2y$", "$2a$", "$2b$", "{SHA}"} {
+		if strings.HasPrefix(hashed, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Comment
+func (ctx Htpasswd) Verify(username string, password string) bool {
+	hashed, ok := ctx[username]
+
+	if strings.HasPrefix(hashed, "{SHA}") {
+		sum := sha1.Sum([]byte(password))
+
+		return SecureCompare(strings.TrimPrefix(hashed, "{SHA}"), base64.StdEncoding.EncodeToString(sum[:])) && ok
+	}
+
+	if !ok {
+		hashed = htpasswdDummyHash
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password)) == nil && ok
+}
+
+// Comment
+func quote(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lucas11776-golang/http"
+	htesting "github.com/lucas11776-golang/http/testing"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicAuth(t *testing.T) {
+	sum := sha1.Sum([]byte("metrics"))
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+
+	htpasswd, err := ParseHtpasswd(strings.NewReader(strings.Join([]string{
+		"# Internal tools",
+		"jane:" + string(hashed),
+		"",
+		"prometheus:{SHA}" + base64.StdEncoding.EncodeToString(sum[:]),
+	}, "\n")))
+
+	if err != nil {
+		t.Fatalf("Something went wrong when trying to parse htpasswd: %v", err)
+	}
+
+	server := http.Server("127.0.0.1", 0)
+
+	server.Route().Get("admin", func(req *http.Request, res *http.Response) *http.Response {
+		return res.Html("<h1>" + AuthUser(req) + "</h1>")
+	}).Middleware(BasicAuth(`Internal "tools"`, Users(map[string]string{"admin": "password"})))
+
+	server.Route().Get("metrics", func(req *http.Request, res *http.Response) *http.Response {
+		return res.Html("<h1>" + AuthUser(req) + "</h1>")
+	}).Middleware(BasicAuth("Metrics", htpasswd.Verify))
+
+	go server.Listen()
+
+	testCase := htesting.NewTestCase(t, server, false)
+
+	defer testCase.Cleanup()
+
+	t.Run("TestChallenge", func(t *testing.T) {
+		testCase.Request().Get("admin").
+			AssertUnauthorized().
+			AssertHeader("WWW-Authenticate", `Basic realm="Internal \"tools\"", charset="UTF-8"`)
+
+		testCase.Request().WithBasicAuth("admin", "wrong").Get("admin").AssertUnauthorized()
+		testCase.Request().WithBasicAuth("jane", "password").Get("admin").AssertUnauthorized()
+	})
+
+	t.Run("TestUsers", func(t *testing.T) {
+		testCase.Request().WithBasicAuth("admin", "password").Get("admin").
+			AssertOk().
+			AssertBody([]byte("<h1>admin</h1>"))
+	})
+
+	t.Run("TestHtpasswd", func(t *testing.T) {
+		testCase.Request().WithBasicAuth("jane", "secret").Get("metrics").AssertBody([]byte("<h1>jane</h1>"))
+		testCase.Request().WithBasicAuth("prometheus", "metrics").Get("metrics").AssertBody([]byte("<h1>prometheus</h1>"))
+		testCase.Request().WithBasicAuth("prometheus", "secret").Get("metrics").AssertUnauthorized()
+		testCase.Request().WithBasicAuth("john", "secret").Get("metrics").AssertUnauthorized()
+
+		if htpasswd.Verify("john", "htpasswd-dummy-password") {
+			t.Fatalf("Expected unknown user to be (%v) but got (%v)", false, true)
+		}
+
+		if _, err := ParseHtpasswd(strings.NewReader("jane:$apr1$salt$hash")); !errors.Is(err, ErrUnsupportedHtpasswd) {
+			t.Fatalf("Expected error to be (%v) but got (%v)", ErrUnsupportedHtpasswd, err)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package http
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+)
+
+var CORS_METHODS = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+
+var (
+	ErrCorsWildcardCredentials = errors.New("cors origin * can not be used with credentials")
+)
+
+type CorsConfig struct {
+	Origins        []string
+	AllowOrigin    func(origin string, req *http.Request) bool
+	Methods        []string
+	Headers        []string
+	ExposedHeaders []string
+	Credentials    bool
+	MaxAge         time.Duration
+}
+
+// Comment
+func Cors(config CorsConfig) http.Middleware {
+	// Echoing every origin with credentials would let any website read authenticated responses.
+	if config.Credentials && slices.Contains(config.Origins, "*") {
+		panic(ErrCorsWildcardCredentials)
+	}
+
+	if len(config.Methods) == 0 {
+		config.Methods = CORS_METHODS
+	}
+
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		origin := req.GetHeader("origin")
+		preflight := strings.ToUpper(req.Method) == string(http.METHOD_OPTIONS) && req.GetHeader("access-control-request-method") != ""
+
+		if preflight {
+			vary(res, "Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers")
+
+			if origin != "" && config.allowed(origin, req) {
+				config.preflight(origin, req, res)
+			}
+
+			return res.SetStatus(http.HTTP_RESPONSE_NO_CONTENT)
+		}
+
+		res = next()
+
+		if res == nil {
+			return res
+		}
+
+		vary(res, "Origin")
+
+		if origin == "" || !config.allowed(origin, req) {
+			return res
+		}
+
+		config.allowOrigin(origin, res)
+
+		if len(config.ExposedHeaders) > 0 {
+			res.SetHeader("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+		}
+
+		return res
+	}
+}
+
+// Comment
+func (ctx CorsConfig) allowed(origin string, req *http.Request) bool {
+	if ctx.AllowOrigin != nil && ctx.AllowOrigin(origin, req) {
+		return true
+	}
+
+	for _, pattern := range ctx.Origins {
+		if pattern == "*" || strings.EqualFold(pattern, origin) {
+			return true
+		}
+
+		prefix, suffix, wildcard := strings.Cut(strings.ToLower(pattern), "*")
+		o := strings.ToLower(origin)
+
+		// A wildcard only matches subdomains e.g. https://*.example.com matches https://api.example.com.
+		if wildcard && len(o) > len(prefix)+len(suffix) && strings.HasPrefix(o, prefix) && strings.HasSuffix(o, suffix) &&
+			!strings.Contains(o[len(prefix):len(o)-len(suffix)], "/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Comment
+func (ctx CorsConfig) allowOrigin(origin string, res *http.Response) {
+	if slices.Contains(ctx.Origins, "*") && !ctx.Credentials && ctx.AllowOrigin == nil {
+		res.SetHeader("Access-Control-Allow-Origin", "*")
+	} else {
+		res.SetHeader("Access-Control-Allow-Origin", origin)
+	}
+
+	if ctx.Credentials {
+		res.SetHeader("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// Comment
+func (ctx CorsConfig) preflight(origin string, req *http.Request, res *http.Response) {
+	method := strings.ToUpper(req.GetHeader("access-control-request-method"))
+
+	if !slices.Contains(ctx.Methods, method) {
+		return
+	}
+
+	requested := []string{}
+
+	for _, header := range strings.Split(req.GetHeader("access-control-request-headers"), ",") {
+		if header = strings.TrimSpace(header); header != "" {
+			requested = append(requested, header)
+		}
+	}
+
+	// Without configured headers the requested headers are allowed.
+	for _, header := range requested {
+		if len(ctx.Headers) > 0 && !slices.ContainsFunc(ctx.Headers, func(h string) bool { return strings.EqualFold(h, header) }) {
+			return
+		}
+	}
+
+	ctx.allowOrigin(origin, res)
+
+	res.SetHeader("Access-Control-Allow-Methods", strings.Join(ctx.Methods, ", "))
+
+	if len(requested) > 0 {
+		res.SetHeader("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	}
+
+	if ctx.MaxAge > 0 {
+		res.SetHeader("Access-Control-Max-Age", strconv.Itoa(int(ctx.MaxAge.Seconds())))
+	}
+}
+
+// Comment
+func vary(res *http.Response, headers ...string) {
+	values := []string{}
+
+	for _, value := range strings.Split(res.Header.Get("Vary"), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	for _, header := range headers {
+		if !slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, header) }) {
+			values = append(values, header)
+		}
+	}
+
+	res.SetHeader("Vary", strings.Join(values, ", "))
+}
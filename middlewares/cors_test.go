@@ -0,0 +1,145 @@
+package http
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+	htesting "github.com/lucas11776-golang/http/testing"
+	"github.com/lucas11776-golang/http/types"
+)
+
+func TestCors(t *testing.T) {
+	server := http.Server("127.0.0.1", 0)
+
+	authenticated := func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		if req.GetHeader("authorization") != "Bearer token" {
+			return res.Problem(http.Unauthorized(UnauthorizedMessage))
+		}
+
+		return next()
+	}
+
+	server.Route().Group("api", func(route *http.Router) {
+		route.Cors(Cors(CorsConfig{
+			Origins:        []string{"https://shop.test", "https://*.shop.test"},
+			AllowOrigin:    func(origin string, req *http.Request) bool { return strings.HasSuffix(origin, ".partner.test") },
+			Methods:        []string{"GET", "POST"},
+			Headers:        []string{"Authorization", "Content-Type"},
+			ExposedHeaders: []string{"X-Total-Count"},
+			Credentials:    true,
+			MaxAge:         time.Hour,
+		}))
+
+		route.Get("orders/{id}", func(req *http.Request, res *http.Response) *http.Response {
+			return res.SetHeader("X-Total-Count", "1").Json(map[string]string{"id": req.Parameters.Get("id")})
+		})
+
+		route.Delete("orders/{id}", func(req *http.Request, res *http.Response) *http.Response {
+			return res.Json(map[string]string{})
+		})
+	}, authenticated)
+
+	server.Route().Get("home", func(req *http.Request, res *http.Response) *http.Response {
+		return res.Html("<h1>Home</h1>")
+	})
+
+	go server.Listen()
+
+	testCase := htesting.NewTestCase(t, server, false)
+
+	defer testCase.Cleanup()
+
+	preflight := func(origin string, method string, headers string) *htesting.Response {
+		return testCase.Request().SetHeaders(types.Headers{
+			"origin":                         origin,
+			"access-control-request-method":  method,
+			"access-control-request-headers": headers,
+		}).Options("api/orders/1")
+	}
+
+	t.Run("TestPreflight", func(t *testing.T) {
+		// The route middleware requires a token, preflight requests are answered before it runs.
+		preflight("https://shop.test", "GET", "authorization, content-type").
+			AssertStatusCode(http.HTTP_RESPONSE_NO_CONTENT).
+			AssertHeaders(types.Headers{
+				"Access-Control-Allow-Origin":      "https://shop.test",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, POST",
+				"Access-Control-Allow-Headers":     "authorization, content-type",
+				"Access-Control-Max-Age":           "3600",
+				"Vary":                             "Origin, Access-Control-Request-Method, Access-Control-Request-Headers",
+			})
+
+		for _, res := range []*htesting.Response{
+			preflight("https://evil.test", "GET", ""),
+			preflight("https://shop.test", "DELETE", ""),
+			preflight("https://shop.test", "GET", "x-forwarded-for"),
+		} {
+			res.AssertStatusCode(http.HTTP_RESPONSE_NO_CONTENT)
+
+			if origin := res.Response.Header.Get("Access-Control-Allow-Origin"); origin != "" {
+				t.Fatalf("Expected preflight to be rejected but got allowed origin (%s)", origin)
+			}
+		}
+	})
+
+	t.Run("TestOrigins", func(t *testing.T) {
+		allowed := map[string]bool{
+			"https://admin.shop.test":      true,
+			"https://a.b.shop.test":        true,
+			"https://api.partner.test":     true,
+			"https://shop.test.evil":       false,
+			"https://evil.test/.shop.test": false,
+			"http://admin.shop.test":       false,
+		}
+
+		for origin, expected := range allowed {
+			res := preflight(origin, "POST", "")
+
+			if (res.Response.Header.Get("Access-Control-Allow-Origin") == origin) != expected {
+				t.Fatalf("Expected origin (%s) allowed to be (%t)", origin, expected)
+			}
+		}
+	})
+
+	t.Run("TestRequest", func(t *testing.T) {
+		testCase.Request().SetHeaders(types.Headers{"origin": "https://shop.test", "authorization": "Bearer token"}).
+			Get("api/orders/1").
+			AssertOk().
+			AssertHeaders(types.Headers{
+				"Access-Control-Allow-Origin":      "https://shop.test",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Total-Count",
+				"Vary":                             "Origin",
+			})
+
+		res := testCase.Request().SetHeaders(types.Headers{"origin": "https://evil.test", "authorization": "Bearer token"}).Get("api/orders/1").AssertOk()
+
+		if res.Response.Header.Get("Access-Control-Allow-Origin") != "" || res.Response.Header.Get("Vary") != "Origin" {
+			t.Fatalf("Expected response without cors headers to vary by origin")
+		}
+	})
+
+	t.Run("TestOptions", func(t *testing.T) {
+		res := testCase.Request().Options("home").
+			AssertStatusCode(http.HTTP_RESPONSE_NO_CONTENT).
+			AssertHeader("Allow", "OPTIONS, GET")
+
+		if res.Response.Header.Get("Access-Control-Allow-Origin") != "" {
+			t.Fatalf("Expected route without cors to not have cors headers")
+		}
+
+		testCase.Request().Options("missing").AssertNotFound()
+	})
+	t.Run("TestWildcardCredentials", func(t *testing.T) {
+		defer func() {
+			if err := recover(); err != ErrCorsWildcardCredentials {
+				t.Fatalf("Expected panic to be (%v) but got (%v)", ErrCorsWildcardCredentials, err)
+			}
+		}()
+
+		Cors(CorsConfig{Origins: []string{"*"}, Credentials: true})
+	})
+}
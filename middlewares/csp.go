@@ -0,0 +1,273 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/lucas11776-golang/http"
+)
+
+const (
+	CSP_SELF                  = "'self'"
+	CSP_NONE                  = "'none'"
+	CSP_UNSAFE_INLINE         = "'unsafe-inline'"
+	CSP_UNSAFE_EVAL           = "'unsafe-eval'"
+	CSP_STRICT_DYNAMIC        = "'strict-dynamic'"
+	CSP_NONCE                 = "'nonce'"
+	CSP_DATA                  = "data:"
+	CSP_HTTPS                 = "https:"
+	CSP_REPORT_PATH           = "csp-report"
+	CSP_REPORT_MAX_SIZE int64 = 64 * 1024
+)
+
+type Csp struct {
+	directives []string
+	sources    map[string][]string
+	reportOnly bool
+}
+
+type CspReport struct {
+	DocumentUri        string `json:"document-uri"`
+	Referrer           string `json:"referrer"`
+	BlockedUri         string `json:"blocked-uri"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	OriginalPolicy     string `json:"original-policy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"source-file"`
+	LineNumber         int    `json:"line-number"`
+	ColumnNumber       int    `json:"column-number"`
+	StatusCode         int    `json:"status-code"`
+	Sample             string `json:"script-sample"`
+}
+
+type CspReportHandler func(req *http.Request, report *CspReport)
+
+// Comment
+func NewCsp() *Csp {
+	return &Csp{sources: map[string][]string{}}
+}
+
+// Comment
+func (ctx *Csp) Directive(name string, sources ...string) *Csp {
+	if !slices.Contains(ctx.directives, name) {
+		ctx.directives = append(ctx.directives, name)
+	}
+
+	ctx.sources[name] = append(ctx.sources[name], sources...)
+
+	return ctx
+}
+
+// Comment
+func (ctx *Csp) DefaultSrc(sources ...string) *Csp {
+	return ctx.Directive("default-src", sources...)
+}
+
+// Comment
+func (ctx *Csp) ScriptSrc(sources ...string) *Csp {
+	return ctx.Directive("script-src", sources...)
+}
+
+// Comment
+func (ctx *Csp) StyleSrc(sources ...string) *Csp {
+	return ctx.Directive("style-src", sources...)
+}
+
+// Comment
+func (ctx *Csp) ImgSrc(sources ...string) *Csp {
+	return ctx.Directive("img-src", sources...)
+}
+
+// Comment
+func (ctx *Csp) ConnectSrc(sources ...string) *Csp {
+	return ctx.Directive("connect-src", sources...)
+}
+
+// Comment
+func (ctx *Csp) FontSrc(sources ...string) *Csp {
+	return ctx.Directive("font-src", sources...)
+}
+
+// Comment
+func (ctx *Csp) ObjectSrc(sources ...string) *Csp {
+	return ctx.Directive("object-src", sources...)
+}
+
+// Comment
+func (ctx *Csp) FrameSrc(sources ...string) *Csp {
+	return ctx.Directive("frame-src", sources...)
+}
+
+// Comment
+func (ctx *Csp) FrameAncestors(sources ...string) *Csp {
+	return ctx.Directive("frame-ancestors", sources...)
+}
+
+// Comment
+func (ctx *Csp) BaseUri(sources ...string) *Csp {
+	return ctx.Directive("base-uri", sources...)
+}
+
+// Comment
+func (ctx *Csp) FormAction(sources ...string) *Csp {
+	return ctx.Directive("form-action", sources...)
+}
+
+// Comment
+func (ctx *Csp) UpgradeInsecureRequests() *Csp {
+	return ctx.Directive("upgrade-insecure-requests")
+}
+
+// Comment
+func (ctx *Csp) ReportUri(uri string) *Csp {
+	return ctx.Directive("report-uri", uri)
+}
+
+// Comment
+func (ctx *Csp) ReportOnly(uri ...string) *Csp {
+	ctx.reportOnly = true
+
+	if len(uri) > 0 {
+		ctx.ReportUri(uri[0])
+	}
+
+	return ctx
+}
+
+// Comment
+func (ctx *Csp) Has(directive string) bool {
+	return slices.Contains(ctx.directives, directive)
+}
+
+// Comment
+func (ctx *Csp) Header() string {
+	if ctx.reportOnly {
+		return "Content-Security-Policy-Report-Only"
+	}
+
+	return "Content-Security-Policy"
+}
+
+// Comment
+func (ctx *Csp) String(nonce string) string {
+	directives := []string{}
+
+	for _, name := range ctx.directives {
+		sources := []string{name}
+
+		for _, source := range ctx.sources[name] {
+			if source == CSP_NONCE {
+				source = "'nonce-" + nonce + "'"
+			}
+
+			sources = append(sources, source)
+		}
+
+		directives = append(directives, strings.Join(sources, " "))
+	}
+
+	return strings.Join(directives, "; ")
+}
+
+// Comment
+func CspReports(router *http.Router, path string, handler CspReportHandler) *http.Route {
+	if handler == nil {
+		handler = func(req *http.Request, report *CspReport) {
+			req.Logger().Warn("Content security policy violation",
+				slog.String("directive", report.EffectiveDirective),
+				slog.String("blocked_uri", report.BlockedUri),
+				slog.String("document_uri", report.DocumentUri),
+			)
+		}
+	}
+
+	return router.Post(path, func(req *http.Request, res *http.Response) *http.Response {
+		reports, err := cspReports(req)
+
+		if err != nil {
+			return res.Error(http.BadRequest(err.Error()))
+		}
+
+		for _, report := range reports {
+			handler(req, report)
+		}
+
+		return res.SetStatus(http.HTTP_RESPONSE_NO_CONTENT)
+	})
+}
+
+// Comment
+func cspReports(req *http.Request) ([]*CspReport, error) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, CSP_REPORT_MAX_SIZE))
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Browsers using the Reporting API send a list of reports with camel case fields.
+	if strings.ToLower(req.ContentType()) == "application/reports+json" {
+		reports := []struct {
+			Type string `json:"type"`
+			Body struct {
+				DocumentURL        string `json:"documentURL"`
+				Referrer           string `json:"referrer"`
+				BlockedURL         string `json:"blockedURL"`
+				EffectiveDirective string `json:"effectiveDirective"`
+				OriginalPolicy     string `json:"originalPolicy"`
+				Disposition        string `json:"disposition"`
+				SourceFile         string `json:"sourceFile"`
+				LineNumber         int    `json:"lineNumber"`
+				ColumnNumber       int    `json:"columnNumber"`
+				StatusCode         int    `json:"statusCode"`
+				Sample             string `json:"sample"`
+			} `json:"body"`
+		}{}
+
+		if err := json.Unmarshal(body, &reports); err != nil {
+			return nil, err
+		}
+
+		violations := []*CspReport{}
+
+		for _, report := range reports {
+			if report.Type != "csp-violation" {
+				continue
+			}
+
+			violations = append(violations, &CspReport{
+				DocumentUri:        report.Body.DocumentURL,
+				Referrer:           report.Body.Referrer,
+				BlockedUri:         report.Body.BlockedURL,
+				ViolatedDirective:  report.Body.EffectiveDirective,
+				EffectiveDirective: report.Body.EffectiveDirective,
+				OriginalPolicy:     report.Body.OriginalPolicy,
+				Disposition:        report.Body.Disposition,
+				SourceFile:         report.Body.SourceFile,
+				LineNumber:         report.Body.LineNumber,
+				ColumnNumber:       report.Body.ColumnNumber,
+				StatusCode:         report.Body.StatusCode,
+				Sample:             report.Body.Sample,
+			})
+		}
+
+		return violations, nil
+	}
+
+	report := struct {
+		Report *CspReport `json:"csp-report"`
+	}{}
+
+	if err := json.Unmarshal(body, &report); err != nil {
+		return nil, err
+	}
+
+	if report.Report == nil {
+		return []*CspReport{}, nil
+	}
+
+	return []*CspReport{report.Report}, nil
+}
@@ -0,0 +1,288 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/encryption/crypt"
+)
+
+type DigestAlgorithm string
+
+const (
+	DIGEST_MD5    DigestAlgorithm = "MD5"
+	DIGEST_SHA256 DigestAlgorithm = "SHA-256"
+)
+
+const DIGEST_NONCE_TTL = time.Minute * 5
+
+type DigestPasswords func(username string) (string, bool)
+
+type digestNonce struct {
+	count  uint64
+	issued time.Time
+}
+
+type digest struct {
+	realm     string
+	algorithm DigestAlgorithm
+	passwords DigestPasswords
+	key       []byte
+	opaque    string
+	mutex     sync.Mutex
+	nonces    map[string]*digestNonce
+}
+
+// Comment
+func DigestAuth(realm string, passwords DigestPasswords, algorithm ...DigestAlgorithm) http.Middleware {
+	return newDigest(realm, passwords, algorithm...).handle
+}
+
+// Comment
+func newDigest(realm string, passwords DigestPasswords, algorithm ...DigestAlgorithm) *digest {
+	d := &digest{
+		realm:     realm,
+		algorithm: DIGEST_MD5,
+		passwords: passwords,
+		key:       crypt.RandomBytes(32),
+		opaque:    hex.EncodeToString(crypt.RandomBytes(16)),
+		nonces:    map[string]*digestNonce{},
+	}
+
+	if len(algorithm) > 0 {
+		d.algorithm = algorithm[0]
+	}
+
+	return d
+}
+
+// Comment
+func DigestUsers(users map[string]string) DigestPasswords {
+	return func(username string) (string, bool) {
+		password, ok := users[username]
+
+		return password, ok
+	}
+}
+
+// Comment
+func (ctx *digest) handle(req *http.Request, res *http.Response, next http.Next) *http.Response {
+	params, ok := digestParams(req.GetHeader("authorization"))
+
+	if !ok {
+		return ctx.challenge(res, false)
+	}
+
+	valid, stale := ctx.validNonce(params["nonce"])
+
+	if !valid || !ctx.authenticate(req, params) {
+		return ctx.challenge(res, false)
+	}
+
+	// The client knows the password but has to retry with a fresh nonce.
+	if stale {
+		return ctx.challenge(res, true)
+	}
+
+	if !ctx.count(params["nonce"], params["nc"]) {
+		return ctx.challenge(res, false)
+	}
+
+	req.Set(REQUEST_AUTH_USER_KEY, params["username"])
+
+	return next()
+}
+
+// Comment
+func (ctx *digest) authenticate(req *http.Request, params map[string]string) bool {
+	algorithm := params["algorithm"]
+
+	if algorithm == "" {
+		algorithm = string(DIGEST_MD5)
+	}
+
+	if params["realm"] != ctx.realm || params["qop"] != "auth" || params["opaque"] != ctx.opaque || !strings.EqualFold(algorithm, string(ctx.algorithm)) {
+		return false
+	}
+
+	if params["uri"] != req.URL.RequestURI() {
+		return false
+	}
+
+	password, ok := ctx.passwords(params["username"])
+
+	ha1 := ctx.hash(params["username"], ctx.realm, password)
+	ha2 := ctx.hash(req.Method, params["uri"])
+	response := ctx.hash(ha1, params["nonce"], params["nc"], params["cnonce"], params["qop"], ha2)
+
+	return SecureCompare(response, params["response"]) && ok
+}
+
+// Comment
+func (ctx *digest) hash(values ...string) string {
+	var h hash.Hash
+
+	switch ctx.algorithm {
+	case DIGEST_SHA256:
+		h = sha256.New()
+
+	default:
+		h = md5.New()
+	}
+
+	h.Write([]byte(strings.Join(values, ":")))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Comment
+func (ctx *digest) nonce() string {
+	timestamp := strconv.FormatInt(time.Now().UnixNano(), 16)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(timestamp + ":" + ctx.sign(timestamp)))
+}
+
+// Comment
+func (ctx *digest) sign(timestamp string) string {
+	mac := hmac.New(sha256.New, ctx.key)
+
+	mac.Write([]byte(timestamp))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Comment
+func (ctx *digest) validNonce(nonce string) (bool, bool) {
+	decoded, err := base64.RawURLEncoding.DecodeString(nonce)
+
+	if err != nil {
+		return false, false
+	}
+
+	timestamp, signature, ok := strings.Cut(string(decoded), ":")
+
+	if !ok || !hmac.Equal([]byte(signature), []byte(ctx.sign(timestamp))) {
+		return false, false
+	}
+
+	issued, err := strconv.ParseInt(timestamp, 16, 64)
+
+	if err != nil {
+		return false, false
+	}
+
+	return true, time.Since(time.Unix(0, issued)) > DIGEST_NONCE_TTL
+}
+
+// Comment
+func (ctx *digest) count(nonce string, nc string) bool {
+	count, err := strconv.ParseUint(nc, 16, 64)
+
+	if err != nil {
+		return false
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	for key, n := range ctx.nonces {
+		if time.Since(n.issued) > DIGEST_NONCE_TTL {
+			delete(ctx.nonces, key)
+		}
+	}
+
+	n, ok := ctx.nonces[nonce]
+
+	if !ok {
+		n = &digestNonce{issued: time.Now()}
+		ctx.nonces[nonce] = n
+	}
+
+	// Nonce counts must increase, a replayed request reuses a count.
+	if count <= n.count {
+		return false
+	}
+
+	n.count = count
+
+	return true
+}
+
+// Comment
+func (ctx *digest) challenge(res *http.Response, stale bool) *http.Response {
+	challenge := fmt.Sprintf(
+		`Digest realm="%s", qop="auth", algorithm=%s, nonce="%s", opaque="%s"`,
+		quote(ctx.realm),
+		ctx.algorithm,
+		ctx.nonce(),
+		ctx.opaque,
+	)
+
+	if stale {
+		challenge += ", stale=true"
+	}
+
+	return res.SetHeader("WWW-Authenticate", challenge).Error(http.Unauthorized(UnauthorizedMessage))
+}
+
+// Comment
+func digestParams(header string) (map[string]string, bool) {
+	scheme, rest, ok := strings.Cut(strings.TrimSpace(header), " ")
+
+	if !ok || !strings.EqualFold(scheme, "digest") {
+		return nil, false
+	}
+
+	params := map[string]string{}
+
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimLeft(rest, ", ") {
+		key, value, ok := strings.Cut(rest, "=")
+
+		if !ok {
+			return nil, false
+		}
+
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		if strings.HasPrefix(value, `"`) {
+			end, unquoted := 1, strings.Builder{}
+
+			for ; end < len(value) && value[end] != '"'; end++ {
+				if value[end] == '\\' && end+1 < len(value) {
+					end++
+				}
+
+				unquoted.WriteByte(value[end])
+			}
+
+			if end >= len(value) {
+				return nil, false
+			}
+
+			params[key], rest = unquoted.String(), value[end+1:]
+
+			continue
+		}
+
+		value, rest, _ = strings.Cut(value, ",")
+		params[key] = strings.TrimSpace(value)
+	}
+
+	for _, key := range []string{"username", "realm", "nonce", "uri", "response", "nc", "cnonce"} {
+		if params[key] == "" {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/types"
+)
+
+func TestDigestAuth(t *testing.T) {
+	users := DigestUsers(map[string]string{"Mufasa": "Circle of Life"})
+
+	request := func(t *testing.T, authorization string) *http.Request {
+		req, err := http.NewRequest(http.METHOD_GET, "http://app.test/dir/index.html?page=1", "HTTP/1.1", types.Headers{"authorization": authorization}, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		return req
+	}
+
+	handle := func(d *digest, req *http.Request) *http.Response {
+		return d.handle(req, req.Response, func() *http.Response {
+			return req.Response.Html("<h1>" + AuthUser(req) + "</h1>")
+		})
+	}
+
+	// The test acts as the client: answering the challenge of the previous response.
+	authorization := func(d *digest, res *http.Response, password string, nc int) string {
+		challenge, _ := digestParams(strings.Replace(res.Header.Get("WWW-Authenticate"), "Digest ", "Digest username=_, uri=_, response=_, nc=_, cnonce=_, ", 1))
+		params := map[string]string{
+			"nonce":  challenge["nonce"],
+			"nc":     fmt.Sprintf("%08x", nc),
+			"cnonce": "0a4f113b",
+			"uri":    "/dir/index.html?page=1",
+		}
+
+		ha1 := d.hash("Mufasa", challenge["realm"], password)
+		ha2 := d.hash("GET", params["uri"])
+		response := d.hash(ha1, params["nonce"], params["nc"], params["cnonce"], "auth", ha2)
+
+		return fmt.Sprintf(
+			`Digest username="Mufasa", realm="%s", nonce="%s", uri="%s", qop=auth, nc=%s, cnonce="%s", response="%s", opaque="%s", algorithm=%s`,
+			challenge["realm"], params["nonce"], params["uri"], params["nc"], params["cnonce"], response, challenge["opaque"], challenge["algorithm"],
+		)
+	}
+
+	t.Run("TestRfcExample", func(t *testing.T) {
+		expected := map[DigestAlgorithm]string{
+			DIGEST_MD5:    "8ca523f5e9506fed4657c9700eebdbec",
+			DIGEST_SHA256: "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+		}
+
+		for algorithm, response := range expected {
+			d := newDigest("http-auth@example.org", users, algorithm)
+			ha1 := d.hash("Mufasa", "http-auth@example.org", "Circle of Life")
+			ha2 := d.hash("GET", "/dir/index.html")
+			nonce, cnonce := "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+
+			if got := d.hash(ha1, nonce, "00000001", cnonce, "auth", ha2); got != response {
+				t.Fatalf("Expected %s response to be (%s) but got (%s)", algorithm, response, got)
+			}
+		}
+	})
+
+	t.Run("TestChallenge", func(t *testing.T) {
+		d := newDigest("testrealm@host.com", users)
+		res := handle(d, request(t, ""))
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_UNAUTHORIZED) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_UNAUTHORIZED, res.StatusCode)
+		}
+
+		challenge := res.Header.Get("WWW-Authenticate")
+
+		if !strings.HasPrefix(challenge, `Digest realm="testrealm@host.com", qop="auth", algorithm=MD5, nonce="`) || strings.Contains(challenge, "stale") {
+			t.Fatalf("Expected digest challenge but got (%s)", challenge)
+		}
+	})
+
+	for _, algorithm := range []DigestAlgorithm{DIGEST_MD5, DIGEST_SHA256} {
+		t.Run("TestAuthenticate"+string(algorithm), func(t *testing.T) {
+			d := newDigest("testrealm@host.com", users, algorithm)
+			challenge := handle(d, request(t, ""))
+			res := handle(d, request(t, authorization(d, challenge, "Circle of Life", 1)))
+
+			if res.StatusCode != int(http.HTTP_RESPONSE_OK) {
+				t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_OK, res.StatusCode)
+			}
+
+			if res := handle(d, request(t, authorization(d, challenge, "Circle of Life", 1))); res.StatusCode != int(http.HTTP_RESPONSE_UNAUTHORIZED) {
+				t.Fatalf("Expected replayed nonce count to be rejected but got (%d)", res.StatusCode)
+			}
+
+			if res := handle(d, request(t, authorization(d, challenge, "Circle of Life", 2))); res.StatusCode != int(http.HTTP_RESPONSE_OK) {
+				t.Fatalf("Expected next nonce count to be accepted but got (%d)", res.StatusCode)
+			}
+
+			if res := handle(d, request(t, authorization(d, challenge, "circle of life", 3))); res.StatusCode != int(http.HTTP_RESPONSE_UNAUTHORIZED) {
+				t.Fatalf("Expected wrong password to be rejected but got (%d)", res.StatusCode)
+			}
+		})
+	}
+
+	t.Run("TestStaleNonce", func(t *testing.T) {
+		d := newDigest("testrealm@host.com", users)
+		challenge := handle(d, request(t, ""))
+		timestamp := strconv.FormatInt(time.Now().Add(-DIGEST_NONCE_TTL-time.Second).UnixNano(), 16)
+		stale := base64.RawURLEncoding.EncodeToString([]byte(timestamp + ":" + d.sign(timestamp)))
+
+		challenge.Header.Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="testrealm@host.com", qop="auth", algorithm=MD5, nonce="%s", opaque="%s"`, stale, d.opaque))
+
+		res := handle(d, request(t, authorization(d, challenge, "Circle of Life", 1)))
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_UNAUTHORIZED) || !strings.HasSuffix(res.Header.Get("WWW-Authenticate"), "stale=true") {
+			t.Fatalf("Expected stale challenge but got (%d, %s)", res.StatusCode, res.Header.Get("WWW-Authenticate"))
+		}
+
+		if res := handle(d, request(t, authorization(d, challenge, "wrong", 1))); strings.Contains(res.Header.Get("WWW-Authenticate"), "stale") {
+			t.Fatalf("Expected stale flag only for valid credentials")
+		}
+	})
+}
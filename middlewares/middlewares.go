@@ -1,28 +1,121 @@
 package http
 
 import (
+	"net/url"
+	"path"
+	"slices"
 	"strings"
 
 	"github.com/lucas11776-golang/http"
-	"github.com/lucas11776-golang/http/pages"
 )
 
+const CsrfMismatchMessage = "CSRF token mismatch."
+
+type CsrfConfig struct {
+	Except  []string
+	Origins []string
+	Cookie  bool
+}
+
 // Comment
 func CsrfMiddleware(req *http.Request, res *http.Response, next http.Next) *http.Response {
-	switch http.Method(strings.ToUpper(req.Method)) {
-	case http.METHOD_POST, http.METHOD_PATCH, http.METHOD_PUT, http.METHOD_DELETE:
-		if req.Session == nil {
-			return next()
+	return Csrf(CsrfConfig{Cookie: true})(req, res, next)
+}
+
+// Comment
+func Csrf(config CsrfConfig) http.Middleware {
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		switch http.Method(strings.ToUpper(req.Method)) {
+		case http.METHOD_POST, http.METHOD_PATCH, http.METHOD_PUT, http.METHOD_DELETE:
+			if csrfExcept(config.Except, req.Path()) {
+				return next()
+			}
+
+			if !csrfTrustedOrigin(config.Origins, req) || !csrfValidToken(req) {
+				return csrfMismatch(req, res)
+			}
+
+			return csrfCookie(config, req, next())
+
+		default:
+			return csrfCookie(config, req, next())
+		}
+	}
+}
+
+// Comment
+func csrfExcept(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.Trim(pattern, "/")
+
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(p, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
 		}
+	}
+
+	return false
+}
+
+// Comment
+func csrfTrustedOrigin(origins []string, req *http.Request) bool {
+	origin := req.GetHeader("origin")
 
-		if req.FormValue(http.CSRF_INPUT_NAME) != req.Session.CsrfToken() {
-			return res.Html(pages.CsrfExpired())
+	if origin == "" || origin == "null" {
+		return req.GetHeader("sec-fetch-site") != "cross-site"
+	}
+
+	if slices.Contains(origins, origin) {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, req.Host)
+}
+
+// Comment
+func csrfValidToken(req *http.Request) bool {
+	tokens := []string{
+		req.FormValue(http.CSRF_INPUT_NAME),
+		req.GetHeader(http.CSRF_HEADER_NAME),
+		req.GetHeader(http.XSRF_HEADER_NAME),
+	}
+
+	if req.Session == nil {
+		return tokens[2] != "" && http.ValidCsrfToken(req.Cookie(http.XSRF_COOKIE_NAME), tokens[2])
+	}
+
+	for _, token := range tokens {
+		if http.ValidCsrfToken(req.Session.CsrfToken(), token) {
+			return true
 		}
+	}
 
-		return next()
+	return false
+}
 
-	default:
-		return next()
+// Comment
+func csrfMismatch(req *http.Request, res *http.Response) *http.Response {
+	return res.Error(http.NewHttpError(http.HTTP_RESPONSE_PAGE_EXPIRED, CsrfMismatchMessage))
+}
+
+// Comment
+func csrfCookie(config CsrfConfig, req *http.Request, res *http.Response) *http.Response {
+	if !config.Cookie || req.Session == nil || res == nil {
+		return res
 	}
 
+	cookie := http.NewCookie(http.XSRF_COOKIE_NAME, http.MaskCsrfToken(req.Session.CsrfToken()))
+
+	cookie.HttpOnly = false
+
+	return res.SetCookie(cookie)
 }
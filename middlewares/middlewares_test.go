@@ -0,0 +1,131 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/types"
+	str "github.com/lucas11776-golang/http/utils/strings"
+)
+
+func TestCsrf(t *testing.T) {
+	sessions := http.InitSession("session", []byte(str.Random(10)))
+
+	request := func(t *testing.T, method http.Method, path string, headers types.Headers, body string) *http.Request {
+		req, err := http.NewRequest(method, strings.Join([]string{"http://shop.test", path}, "/"), "HTTP/1.1", headers, strings.NewReader(body))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		req.Session = sessions.Session(req)
+		req.Response.Session = req.Session
+
+		return req
+	}
+
+	handle := func(config CsrfConfig, req *http.Request) *http.Response {
+		return Csrf(config)(req, req.Response, func() *http.Response {
+			return req.Response.Html("<h1>Order placed</h1>")
+		})
+	}
+
+	t.Run("TestFormToken", func(t *testing.T) {
+		req := request(t, http.METHOD_POST, "orders", types.Headers{"content-type": "application/x-www-form-urlencoded"}, "")
+
+		req.Form = map[string][]string{http.CSRF_INPUT_NAME: {req.Session.CsrfToken()}}
+
+		if res := handle(CsrfConfig{}, req); res.StatusCode != int(http.HTTP_RESPONSE_OK) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_OK, res.StatusCode)
+		}
+	})
+
+	t.Run("TestMaskedHeaderToken", func(t *testing.T) {
+		for _, header := range []string{http.CSRF_HEADER_NAME, http.XSRF_HEADER_NAME} {
+			req := request(t, http.METHOD_PUT, "orders/1", types.Headers{"content-type": "application/json"}, `{"status":"paid"}`)
+
+			masked := http.MaskCsrfToken(req.Session.CsrfToken())
+
+			if masked == http.MaskCsrfToken(req.Session.CsrfToken()) {
+				t.Fatalf("Expected masked tokens to differ on every mask")
+			}
+
+			req.Header.Set(header, masked)
+
+			if res := handle(CsrfConfig{}, req); res.StatusCode != int(http.HTTP_RESPONSE_OK) {
+				t.Fatalf("Expected status code with header (%s) to be (%d) but got (%d)", header, http.HTTP_RESPONSE_OK, res.StatusCode)
+			}
+		}
+	})
+
+	t.Run("TestMismatch", func(t *testing.T) {
+		req := request(t, http.METHOD_POST, "orders", types.Headers{"content-type": "application/json", "x-csrf-token": "invalid"}, `{}`)
+
+		res := handle(CsrfConfig{}, req)
+
+		if res.StatusCode != int(http.HTTP_RESPONSE_PAGE_EXPIRED) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_PAGE_EXPIRED, res.StatusCode)
+		}
+
+		if res.GetHeader("content-type") != http.PROBLEM_JSON_CONTENT_TYPE {
+			t.Fatalf("Expected content type to be (%s) but got (%s)", http.PROBLEM_JSON_CONTENT_TYPE, res.GetHeader("content-type"))
+		}
+	})
+
+	t.Run("TestExcept", func(t *testing.T) {
+		config := CsrfConfig{Except: []string{"webhooks/*", "api/stripe"}}
+
+		for _, path := range []string{"webhooks/github", "webhooks/stripe/events", "api/stripe"} {
+			if res := handle(config, request(t, http.METHOD_POST, path, types.Headers{}, "")); res.StatusCode != int(http.HTTP_RESPONSE_OK) {
+				t.Fatalf("Expected exempt path (%s) status code to be (%d) but got (%d)", path, http.HTTP_RESPONSE_OK, res.StatusCode)
+			}
+		}
+
+		if res := handle(config, request(t, http.METHOD_POST, "api/orders", types.Headers{}, "")); res.StatusCode != int(http.HTTP_RESPONSE_PAGE_EXPIRED) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", http.HTTP_RESPONSE_PAGE_EXPIRED, res.StatusCode)
+		}
+	})
+
+	t.Run("TestOrigin", func(t *testing.T) {
+		tests := []struct {
+			headers  types.Headers
+			config   CsrfConfig
+			expected http.Status
+		}{
+			{types.Headers{"origin": "http://shop.test"}, CsrfConfig{}, http.HTTP_RESPONSE_OK},
+			{types.Headers{"origin": "http://evil.test"}, CsrfConfig{}, http.HTTP_RESPONSE_PAGE_EXPIRED},
+			{types.Headers{"origin": "http://admin.shop.test"}, CsrfConfig{Origins: []string{"http://admin.shop.test"}}, http.HTTP_RESPONSE_OK},
+			{types.Headers{"sec-fetch-site": "cross-site"}, CsrfConfig{}, http.HTTP_RESPONSE_PAGE_EXPIRED},
+			{types.Headers{"sec-fetch-site": "same-origin"}, CsrfConfig{}, http.HTTP_RESPONSE_OK},
+		}
+
+		for _, test := range tests {
+			req := request(t, http.METHOD_POST, "orders", test.headers, "")
+
+			req.Header.Set(http.CSRF_HEADER_NAME, req.Session.CsrfToken())
+
+			if res := handle(test.config, req); res.StatusCode != int(test.expected) {
+				t.Fatalf("Expected status code with headers (%v) to be (%d) but got (%d)", test.headers, test.expected, res.StatusCode)
+			}
+		}
+	})
+
+	t.Run("TestXsrfCookie", func(t *testing.T) {
+		req := request(t, http.METHOD_GET, "orders", types.Headers{}, "")
+
+		res := handle(CsrfConfig{Cookie: true}, req)
+
+		cookie := res.GetHeader("set-cookie")
+
+		if !strings.HasPrefix(cookie, http.XSRF_COOKIE_NAME+"=") || strings.Contains(cookie, "HttpOnly") {
+			t.Fatalf("Expected readable xsrf cookie but got (%s)", cookie)
+		}
+
+		token := strings.TrimPrefix(strings.Split(cookie, ";")[0], http.XSRF_COOKIE_NAME+"=")
+
+		if !http.ValidCsrfToken(req.Session.CsrfToken(), token) {
+			t.Fatalf("Expected xsrf cookie to hold the masked session token")
+		}
+	})
+}
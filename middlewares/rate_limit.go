@@ -0,0 +1,314 @@
+package http
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/auth"
+)
+
+type RateLimitAlgorithm string
+
+const (
+	TOKEN_BUCKET   RateLimitAlgorithm = "token_bucket"
+	SLIDING_WINDOW RateLimitAlgorithm = "sliding_window"
+)
+
+const TooManyRequestsMessage = "Too Many Attempts."
+
+type RateLimitKey func(req *http.Request) string
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type RateLimiter struct {
+	name      string
+	limit     int
+	period    time.Duration
+	algorithm RateLimitAlgorithm
+	key       RateLimitKey
+	store     RateLimitStore
+}
+
+var unnamed atomic.Int64
+
+var limiters = struct {
+	mutex    sync.RWMutex
+	limiters map[string]*RateLimiter
+}{limiters: map[string]*RateLimiter{}}
+
+// Comment
+func NewRateLimiter(limit int, period time.Duration) *RateLimiter {
+	// Every limiter gets its own counters, use DefineRateLimiter for a name that is stable across processes.
+	return &RateLimiter{
+		name:      fmt.Sprintf("%d/%s/%d", limit, period, unnamed.Add(1)),
+		limit:     limit,
+		period:    period,
+		algorithm: SLIDING_WINDOW,
+		key:       ByIp,
+		store:     NewMemoryRateLimitStore(),
+	}
+}
+
+// Comment
+func PerMinute(limit int) *RateLimiter {
+	return NewRateLimiter(limit, time.Minute)
+}
+
+// Comment
+func PerHour(limit int) *RateLimiter {
+	return NewRateLimiter(limit, time.Hour)
+}
+
+// Comment
+func (ctx *RateLimiter) Algorithm(algorithm RateLimitAlgorithm) *RateLimiter {
+	ctx.algorithm = algorithm
+
+	return ctx
+}
+
+// Comment
+func (ctx *RateLimiter) By(key RateLimitKey) *RateLimiter {
+	ctx.key = key
+
+	return ctx
+}
+
+// Comment
+func (ctx *RateLimiter) Store(store RateLimitStore) *RateLimiter {
+	ctx.store = store
+
+	return ctx
+}
+
+// Comment
+func DefineRateLimiter(name string, limiter *RateLimiter) *RateLimiter {
+	limiters.mutex.Lock()
+	defer limiters.mutex.Unlock()
+
+	limiter.name = name
+	limiters.limiters[name] = limiter
+
+	return limiter
+}
+
+// Comment
+func Throttle(name string) http.Middleware {
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		limiters.mutex.RLock()
+		limiter, ok := limiters.limiters[name]
+		limiters.mutex.RUnlock()
+
+		if !ok {
+			return res.Error(http.InternalServerError(fmt.Errorf("rate limiter %s is not defined", name)))
+		}
+
+		return limiter.Middleware()(req, res, next)
+	}
+}
+
+// Comment
+func ByIp(req *http.Request) string {
+	if req.Conn != nil {
+		return req.IP()
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
+
+// Comment
+func ByUser(guards ...string) RateLimitKey {
+	return func(req *http.Request) string {
+		if !auth.Auth(req).Has(guards...) {
+			return ""
+		}
+
+		guard := auth.Auth(req).Guard(guards...)
+
+		if guard.Guest() {
+			return ""
+		}
+
+		return "user:" + guard.Id()
+	}
+}
+
+// Comment
+func ByRoute(req *http.Request) string {
+	if route := req.Route(); route != nil {
+		return route.Method() + " " + route.Path() + ":" + ByIp(req)
+	}
+
+	return req.Method + " " + req.Path() + ":" + ByIp(req)
+}
+
+// Comment
+func (ctx *RateLimiter) Attempt(req *http.Request) (*RateLimitResult, error) {
+	key := ctx.key(req)
+
+	// Requests without a key e.g. guests limited by user are limited by ip.
+	if key == "" {
+		key = ByIp(req)
+	}
+
+	return ctx.Hit(key)
+}
+
+// Comment
+func (ctx *RateLimiter) Hit(key string) (*RateLimitResult, error) {
+	key = "rate_limit:" + ctx.name + ":" + key
+
+	var result *RateLimitResult
+
+	// The store runs the update atomically and may run it again when the state changed in between.
+	err := ctx.store.Update(key, ctx.period*2, func(state *RateLimitState) *RateLimitState {
+		switch ctx.algorithm {
+		case TOKEN_BUCKET:
+			state, result = ctx.tokenBucket(state, time.Now())
+
+		default:
+			state, result = ctx.slidingWindow(state, time.Now())
+		}
+
+		return state
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Comment
+func (ctx *RateLimiter) tokenBucket(state *RateLimitState, now time.Time) (*RateLimitState, *RateLimitResult) {
+	rate := float64(ctx.limit) / float64(ctx.period)
+
+	if state == nil {
+		state = &RateLimitState{Tokens: float64(ctx.limit), Time: now.UnixNano()}
+	}
+
+	state.Tokens = math.Min(float64(ctx.limit), state.Tokens+float64(now.UnixNano()-state.Time)*rate)
+	state.Time = now.UnixNano()
+
+	result := &RateLimitResult{Limit: ctx.limit}
+
+	if state.Tokens >= 1 {
+		state.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = nanoseconds((1 - state.Tokens) / rate)
+	}
+
+	result.Remaining = int(state.Tokens)
+	result.Reset = nanoseconds((float64(ctx.limit) - state.Tokens) / rate)
+
+	return state, result
+}
+
+// Comment
+func (ctx *RateLimiter) slidingWindow(state *RateLimitState, now time.Time) (*RateLimitState, *RateLimitResult) {
+	window := now.Truncate(ctx.period)
+
+	if state == nil {
+		state = &RateLimitState{Time: window.UnixNano()}
+	}
+
+	if start := time.Unix(0, state.Time); !start.Equal(window) {
+		if start.Add(ctx.period).Equal(window) {
+			state.Previous = state.Tokens
+		} else {
+			state.Previous = 0
+		}
+
+		state.Tokens, state.Time = 0, window.UnixNano()
+	}
+
+	elapsed := now.Sub(window)
+
+	// The previous window is weighted by how much of it still overlaps the sliding window.
+	estimate := func() float64 {
+		return state.Previous*(1-float64(elapsed)/float64(ctx.period)) + state.Tokens
+	}
+
+	result := &RateLimitResult{Limit: ctx.limit, Reset: ctx.period - elapsed}
+
+	if estimate()+1 <= float64(ctx.limit) {
+		state.Tokens++
+		result.Allowed = true
+	} else if state.Tokens+1 <= float64(ctx.limit) {
+		weight := (float64(ctx.limit) - state.Tokens - 1) / state.Previous
+		result.RetryAfter = nanoseconds((1-weight)*float64(ctx.period)) - elapsed
+	} else {
+		weight := (float64(ctx.limit) - 1) / state.Tokens
+		result.RetryAfter = ctx.period - elapsed + nanoseconds((1-weight)*float64(ctx.period))
+	}
+
+	result.Remaining = max(int(float64(ctx.limit)-estimate()), 0)
+
+	return state, result
+}
+
+// Comment
+func (ctx *RateLimiter) headers(res *http.Response, result *RateLimitResult) *http.Response {
+	res.SetHeader("RateLimit-Policy", fmt.Sprintf("%d;w=%d", ctx.limit, seconds(ctx.period)))
+	res.SetHeader("RateLimit-Limit", strconv.Itoa(result.Limit))
+	res.SetHeader("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	res.SetHeader("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+	if !result.Allowed {
+		res.SetHeader("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+	}
+
+	return res
+}
+
+// Comment
+func nanoseconds(ns float64) time.Duration {
+	return time.Duration(math.Ceil(ns))
+}
+
+// Comment
+func seconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
+
+// Comment
+func (ctx *RateLimiter) Middleware() http.Middleware {
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		result, err := ctx.Attempt(req)
+
+		if err != nil {
+			return res.Error(err)
+		}
+
+		if !result.Allowed {
+			ctx.headers(res, result)
+
+			return res.Error(http.NewHttpError(http.HTTP_RESPONSE_TOO_MANY_REQUIRED, TooManyRequestsMessage))
+		}
+
+		if res = next(); res == nil {
+			return res
+		}
+
+		return ctx.headers(res, result)
+	}
+}
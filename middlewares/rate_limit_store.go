@@ -0,0 +1,237 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lucas11776-golang/http/utils/database"
+	"github.com/lucas11776-golang/orm"
+	"github.com/spf13/cast"
+)
+
+const (
+	RATE_LIMIT_TABLE    = "rate_limits"
+	RATE_LIMIT_ATTEMPTS = 10
+	RATE_LIMIT_SWEEP    = time.Minute
+)
+
+var (
+	ErrRateLimitContention = errors.New("rate limit state kept changing while being updated")
+)
+
+type RateLimitState struct {
+	Tokens   float64 `json:"tokens"`
+	Previous float64 `json:"previous"`
+	Time     int64   `json:"time"`
+}
+
+type RateLimitStore interface {
+	Update(key string, ttl time.Duration, update func(state *RateLimitState) *RateLimitState) error
+}
+
+type rateLimitItem struct {
+	state   RateLimitState
+	expires time.Time
+}
+
+type MemoryRateLimitStore struct {
+	mutex sync.Mutex
+	items map[string]*rateLimitItem
+	swept time.Time
+}
+
+type RateLimitRecord struct {
+	Table     string `table:"rate_limits"`
+	ID        int64  `column:"id" type:"primary_key"`
+	Key       string `column:"key" type:"string"`
+	State     string `column:"state" type:"text"`
+	ExpiresAt int64  `column:"expires_at" type:"integer"`
+}
+
+type DatabaseRateLimitStore struct {
+	connection string
+}
+
+// Comment
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{items: map[string]*rateLimitItem{}, swept: time.Now()}
+}
+
+// Comment
+func (ctx *MemoryRateLimitStore) Get(key string) (*RateLimitState, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	item, ok := ctx.items[key]
+
+	if !ok || time.Now().After(item.expires) {
+		delete(ctx.items, key)
+
+		return nil, nil
+	}
+
+	state := item.state
+
+	return &state, nil
+}
+
+// Comment
+func (ctx *MemoryRateLimitStore) Update(key string, ttl time.Duration, update func(state *RateLimitState) *RateLimitState) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	var state *RateLimitState
+
+	if item, ok := ctx.items[key]; ok && time.Now().Before(item.expires) {
+		current := item.state
+		state = &current
+	}
+
+	ctx.items[key] = &rateLimitItem{state: *update(state), expires: time.Now().Add(ttl)}
+
+	// Every client adds a key, expired keys are swept so the store does not keep growing.
+	if time.Since(ctx.swept) >= RATE_LIMIT_SWEEP {
+		ctx.gc()
+	}
+
+	return nil
+}
+
+// Comment
+func (ctx *MemoryRateLimitStore) Gc() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.gc()
+
+	return nil
+}
+
+// Comment
+func (ctx *MemoryRateLimitStore) gc() {
+	now := time.Now()
+
+	for key, item := range ctx.items {
+		if now.After(item.expires) {
+			delete(ctx.items, key)
+		}
+	}
+
+	ctx.swept = now
+}
+
+// Comment
+func NewDatabaseRateLimitStore(connection string) *DatabaseRateLimitStore {
+	return &DatabaseRateLimitStore{connection: connection}
+}
+
+// Comment
+func (ctx *DatabaseRateLimitStore) Migrate() error {
+	db, err := database.Connection(ctx.connection)
+
+	if err != nil {
+		return err
+	}
+
+	if err := orm.DB.Database(ctx.connection).Migration().Migrate(orm.Models{RateLimitRecord{}}); err != nil {
+		return err
+	}
+
+	return database.Unique(db, RATE_LIMIT_TABLE, "key")
+}
+
+// Comment
+func (ctx *DatabaseRateLimitStore) Get(key string) (*RateLimitState, error) {
+	state, _, err := ctx.get(key)
+
+	return state, err
+}
+
+// Comment
+func (ctx *DatabaseRateLimitStore) get(key string) (*RateLimitState, *string, error) {
+	db := orm.DB.Database(ctx.connection)
+
+	if db == nil {
+		return nil, nil, fmt.Errorf("%w: %s", database.ErrConnection, ctx.connection)
+	}
+
+	results, err := db.Query(&orm.Statement{
+		Table: RATE_LIMIT_TABLE,
+		Where: []interface{}{&orm.Where{Key: "key", Operator: orm.EQUALS, Value: key}},
+		Limit: 1,
+	})
+
+	if err != nil || len(results) == 0 {
+		return nil, nil, err
+	}
+
+	data := cast.ToString(results[0]["state"])
+
+	if cast.ToInt64(results[0]["expires_at"]) <= time.Now().Unix() {
+		return nil, &data, nil
+	}
+
+	state := &RateLimitState{}
+
+	if err := json.Unmarshal([]byte(data), state); err != nil {
+		return nil, nil, err
+	}
+
+	return state, &data, nil
+}
+
+// Comment
+func (ctx *DatabaseRateLimitStore) Update(key string, ttl time.Duration, update func(state *RateLimitState) *RateLimitState) error {
+	for attempt := 0; attempt < RATE_LIMIT_ATTEMPTS; attempt++ {
+		state, current, err := ctx.get(key)
+
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(update(state))
+
+		if err != nil {
+			return err
+		}
+
+		values := orm.Values{"key": key, "state": string(data), "expires_at": time.Now().Add(ttl).Unix()}
+
+		// The write only succeeds when the state is still the one that was read, otherwise it is read again.
+		if current == nil {
+			if inserted, err := database.Insert(ctx.connection, RATE_LIMIT_TABLE, values, "key"); err != nil || inserted {
+				return err
+			}
+
+			continue
+		}
+
+		affected, err := database.Update(ctx.connection, RATE_LIMIT_TABLE, values,
+			&orm.Where{Key: "key", Operator: orm.EQUALS, Value: key},
+			&orm.Where{Key: "state", Operator: orm.EQUALS, Value: *current},
+		)
+
+		if err != nil || affected == 1 {
+			return err
+		}
+	}
+
+	return ErrRateLimitContention
+}
+
+// Comment
+func (ctx *DatabaseRateLimitStore) Gc() error {
+	db := orm.DB.Database(ctx.connection)
+
+	if db == nil {
+		return fmt.Errorf("%w: %s", database.ErrConnection, ctx.connection)
+	}
+
+	return db.Delete(&orm.Statement{
+		Table: RATE_LIMIT_TABLE,
+		Where: []interface{}{&orm.Where{Key: "expires_at", Operator: orm.LESS_THEN_EQUALS, Value: time.Now().Unix()}},
+	})
+}
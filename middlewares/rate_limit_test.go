@@ -0,0 +1,203 @@
+package http
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+	htesting "github.com/lucas11776-golang/http/testing"
+	"github.com/lucas11776-golang/http/types"
+	"github.com/lucas11776-golang/orm"
+	"github.com/lucas11776-golang/orm/databases/sqlite"
+)
+
+func TestRateLimit(t *testing.T) {
+	window := time.Now().Truncate(time.Minute)
+
+	t.Run("TestSlidingWindow", func(t *testing.T) {
+		limiter := NewRateLimiter(10, time.Minute)
+
+		var state *RateLimitState
+		var result *RateLimitResult
+
+		for i := 0; i < 10; i++ {
+			if state, result = limiter.slidingWindow(state, window.Add(time.Second*10)); !result.Allowed {
+				t.Fatalf("Expected attempt (%d) to be allowed", i+1)
+			}
+		}
+
+		if state, result = limiter.slidingWindow(state, window.Add(time.Second*10)); result.Allowed || result.RetryAfter != time.Second*56 || result.Remaining != 0 {
+			t.Fatalf("Expected attempt to be limited with retry after (%s) but got (%s)", time.Second*56, result.RetryAfter)
+		}
+
+		// Half of the previous window still overlaps the sliding window.
+		for i := 0; i < 5; i++ {
+			if state, result = limiter.slidingWindow(state, window.Add(time.Second*90)); !result.Allowed {
+				t.Fatalf("Expected attempt (%d) in next window to be allowed", i+1)
+			}
+		}
+
+		if state, result = limiter.slidingWindow(state, window.Add(time.Second*90)); result.Allowed || result.RetryAfter != time.Second*6 {
+			t.Fatalf("Expected attempt to be limited with retry after (%s) but got (%s)", time.Second*6, result.RetryAfter)
+		}
+
+		if _, result = limiter.slidingWindow(state, window.Add(time.Second*96)); !result.Allowed {
+			t.Fatalf("Expected attempt after retry to be allowed")
+		}
+	})
+
+	t.Run("TestTokenBucket", func(t *testing.T) {
+		limiter := NewRateLimiter(5, time.Second*10).Algorithm(TOKEN_BUCKET)
+
+		var state *RateLimitState
+		var result *RateLimitResult
+
+		for i := 0; i < 5; i++ {
+			if state, result = limiter.tokenBucket(state, window); !result.Allowed || result.Remaining != 4-i {
+				t.Fatalf("Expected attempt (%d) to be allowed with (%d) remaining", i+1, 4-i)
+			}
+		}
+
+		if state, result = limiter.tokenBucket(state, window); result.Allowed || result.RetryAfter != time.Second*2 || result.Reset != time.Second*10 {
+			t.Fatalf("Expected attempt to be limited with retry after (%s) but got (%s)", time.Second*2, result.RetryAfter)
+		}
+
+		if _, result = limiter.tokenBucket(state, window.Add(time.Second*2)); !result.Allowed {
+			t.Fatalf("Expected refilled token to be allowed")
+		}
+	})
+
+	t.Run("TestMiddleware", func(t *testing.T) {
+		server := http.Server("127.0.0.1", 0)
+
+		DefineRateLimiter("login", PerMinute(2).By(ByRoute))
+
+		server.Route().Post("login", func(req *http.Request, res *http.Response) *http.Response {
+			return res.Json(map[string]string{})
+		}).Middleware(Throttle("login"))
+
+		server.Route().Get("undefined", func(req *http.Request, res *http.Response) *http.Response {
+			return res.Json(map[string]string{})
+		}).Middleware(Throttle("undefined"))
+
+		go server.Listen()
+
+		testCase := htesting.NewTestCase(t, server, false)
+
+		defer testCase.Cleanup()
+
+		testCase.Request().Post("login", []byte{}).
+			AssertOk().
+			AssertHeaders(types.Headers{"RateLimit-Limit": "2", "RateLimit-Remaining": "1", "RateLimit-Policy": "2;w=60"})
+
+		testCase.Request().Post("login", []byte{}).AssertOk().AssertHeader("RateLimit-Remaining", "0")
+
+		res := testCase.Request().SetHeader("accept", "application/json").Post("login", []byte{}).
+			AssertStatusCode(http.HTTP_RESPONSE_TOO_MANY_REQUIRED).
+			AssertHeader("Content-Type", http.PROBLEM_JSON_CONTENT_TYPE).
+			AssertHeadersHas("Retry-After")
+
+		if res.Response.GetHeader("Retry-After") == "0" {
+			t.Fatalf("Expected retry after to be in the future")
+		}
+
+		testCase.Request().SetHeader("accept", "text/html").Post("login", []byte{}).
+			AssertStatusCode(http.HTTP_RESPONSE_TOO_MANY_REQUIRED).
+			AssertHeader("Content-Type", "text/html")
+
+		testCase.Request().Get("undefined").AssertStatusCode(http.HTTP_RESPONSE_INTERNAL_SERVER_ERROR)
+	})
+
+	t.Run("TestDatabaseStore", func(t *testing.T) {
+		orm.DB.Add("rate-limit", sqlite.Connect(":memory:"))
+
+		store := NewDatabaseRateLimitStore("rate-limit")
+
+		if err := store.Migrate(); err != nil {
+			t.Fatalf("Something went wrong when trying to migrate rate limit table: %v", err)
+		}
+
+		limiter := PerMinute(2).Algorithm(TOKEN_BUCKET).Store(store)
+
+		for i, allowed := range []bool{true, true, false} {
+			result, err := limiter.Hit("jane@doe.com")
+
+			if err != nil {
+				t.Fatalf("Something went wrong when trying to hit rate limiter: %v", err)
+			}
+
+			if result.Allowed != allowed {
+				t.Fatalf("Expected attempt (%d) allowed to be (%t) but got (%t)", i+1, allowed, result.Allowed)
+			}
+		}
+
+		if result, _ := limiter.Hit("john@doe.com"); !result.Allowed {
+			t.Fatalf("Expected other key to be allowed")
+		}
+	})
+
+	t.Run("TestDatabaseStoreConcurrent", func(t *testing.T) {
+		orm.DB.Add("rate-limit-concurrent", sqlite.Connect(":memory:"))
+
+		if err := NewDatabaseRateLimitStore("rate-limit-concurrent").Migrate(); err != nil {
+			t.Fatalf("Something went wrong when trying to migrate rate limit table: %v", err)
+		}
+
+		var allowed atomic.Int64
+		var group sync.WaitGroup
+
+		// Each limiter has its own store like a limiter in another process.
+		for i := 0; i < 20; i++ {
+			group.Add(1)
+
+			go func() {
+				defer group.Done()
+
+				limiter := DefineRateLimiter("concurrent", PerMinute(5).Store(NewDatabaseRateLimitStore("rate-limit-concurrent")))
+
+				if result, err := limiter.Hit("jane@doe.com"); err == nil && result.Allowed {
+					allowed.Add(1)
+				}
+			}()
+		}
+
+		group.Wait()
+
+		if allowed.Load() != 5 {
+			t.Fatalf("Expected allowed attempts to be (%d) but got (%d)", 5, allowed.Load())
+		}
+	})
+
+	t.Run("TestUnnamedLimiters", func(t *testing.T) {
+		store := NewMemoryRateLimitStore()
+		login, contact := PerMinute(1).Store(store), PerMinute(1).Store(store)
+
+		if result, _ := login.Hit("127.0.0.1"); !result.Allowed {
+			t.Fatalf("Expected first login attempt to be allowed")
+		}
+
+		if result, _ := contact.Hit("127.0.0.1"); !result.Allowed {
+			t.Fatalf("Expected unnamed limiter with the same rate to have its own counter")
+		}
+	})
+
+	t.Run("TestMemoryStoreSweep", func(t *testing.T) {
+		store := NewMemoryRateLimitStore()
+		keep := func(state *RateLimitState) *RateLimitState { return &RateLimitState{} }
+
+		for _, key := range []string{"127.0.0.1", "127.0.0.2", "127.0.0.3"} {
+			store.Update(key, time.Millisecond, keep)
+		}
+
+		time.Sleep(time.Millisecond * 5)
+
+		store.swept = time.Now().Add(-RATE_LIMIT_SWEEP)
+		store.Update("127.0.0.4", time.Minute, keep)
+
+		if len(store.items) != 1 {
+			t.Fatalf("Expected store to have (%d) items but got (%d)", 1, len(store.items))
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"encoding/hex"
+	"regexp"
+
+	"github.com/lucas11776-golang/http"
+	"github.com/lucas11776-golang/http/encryption/crypt"
+)
+
+const (
+	REQUEST_ID_HEADER = "X-Request-ID"
+	REQUEST_ID_SIZE   = 16
+)
+
+// Propagated ids are written to the logs so only short printable ids are trusted.
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._:/+=-]{1,128}$`)
+
+// Comment
+func RequestId(req *http.Request, res *http.Response, next http.Next) *http.Response {
+	id := req.GetHeader(REQUEST_ID_HEADER)
+
+	if !requestIdPattern.MatchString(id) {
+		id = NewRequestId()
+	}
+
+	req.SetRequestId(id)
+
+	if res = next(); res == nil {
+		return res
+	}
+
+	return res.SetHeader(REQUEST_ID_HEADER, id)
+}
+
+// Comment
+func NewRequestId() string {
+	return hex.EncodeToString(crypt.RandomBytes(REQUEST_ID_SIZE))
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/lucas11776-golang/http"
+	htesting "github.com/lucas11776-golang/http/testing"
+)
+
+func TestRequestId(t *testing.T) {
+	server := http.Server("127.0.0.1", 0)
+	logs := &bytes.Buffer{}
+
+	server.SetLogger(slog.New(slog.NewJSONHandler(logs, nil))).Use(RequestId)
+
+	server.Route().Get("orders", func(req *http.Request, res *http.Response) *http.Response {
+		req.Logger().Info("Listing orders")
+
+		return res.Json([]string{})
+	})
+
+	go server.Listen()
+
+	testCase := htesting.NewTestCase(t, server, false)
+
+	defer testCase.Cleanup()
+
+	t.Run("TestPropagate", func(t *testing.T) {
+		logs.Reset()
+
+		testCase.Request().SetHeader(REQUEST_ID_HEADER, "9f2c-41d8").Get("orders").AssertOk().AssertHeader(REQUEST_ID_HEADER, "9f2c-41d8")
+
+		entry := map[string]interface{}{}
+
+		if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+			t.Fatalf("Something went wrong when trying to decode log: %v", err)
+		}
+
+		if entry["request_id"] != "9f2c-41d8" || entry["path"] != "/orders" {
+			t.Fatalf("Expected log request id to be (%s) but got (%v)", "9f2c-41d8", entry["request_id"])
+		}
+	})
+
+	t.Run("TestGenerate", func(t *testing.T) {
+		for _, id := range []string{"", "bad id\nforged"} {
+			res := testCase.Request().SetHeader(REQUEST_ID_HEADER, id).Get("orders").AssertOk()
+
+			if generated := res.Response.GetHeader(REQUEST_ID_HEADER); len(generated) != REQUEST_ID_SIZE*2 {
+				t.Fatalf("Expected generated request id but got (%s)", generated)
+			}
+		}
+	})
+}
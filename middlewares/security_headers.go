@@ -0,0 +1,193 @@
+package http
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lucas11776-golang/http"
+)
+
+const (
+	HSTS_MAX_AGE          = time.Hour * 24 * 365
+	FRAME_OPTIONS_DENY    = "DENY"
+	FRAME_OPTIONS_SAME    = "SAMEORIGIN"
+	DEFAULT_REFERRER      = "strict-origin-when-cross-origin"
+	DEFAULT_OPENER_POLICY = "same-origin"
+	REQUIRE_CORP          = "require-corp"
+	CREDENTIALLESS        = "credentialless"
+	PERMISSION_SELF       = "self"
+	PERMISSION_ALL        = "*"
+)
+
+type SecurityHeaders struct {
+	hsts           time.Duration
+	subdomains     bool
+	preload        bool
+	frameOptions   string
+	referrerPolicy string
+	permissions    map[string][]string
+	features       []string
+	openerPolicy   string
+	embedderPolicy string
+	csp            *Csp
+}
+
+// Comment
+func NewSecurityHeaders() *SecurityHeaders {
+	return &SecurityHeaders{
+		hsts:           HSTS_MAX_AGE,
+		subdomains:     true,
+		frameOptions:   FRAME_OPTIONS_SAME,
+		referrerPolicy: DEFAULT_REFERRER,
+		permissions:    map[string][]string{},
+		openerPolicy:   DEFAULT_OPENER_POLICY,
+	}
+}
+
+// Comment
+func SecurityHeadersMiddleware(req *http.Request, res *http.Response, next http.Next) *http.Response {
+	return NewSecurityHeaders().Middleware()(req, res, next)
+}
+
+// Comment
+func (ctx *SecurityHeaders) Hsts(maxAge time.Duration, subdomains bool, preload bool) *SecurityHeaders {
+	ctx.hsts, ctx.subdomains, ctx.preload = maxAge, subdomains, preload
+
+	return ctx
+}
+
+// Comment
+func (ctx *SecurityHeaders) FrameOptions(value string) *SecurityHeaders {
+	ctx.frameOptions = value
+
+	return ctx
+}
+
+// Comment
+func (ctx *SecurityHeaders) ReferrerPolicy(policy string) *SecurityHeaders {
+	ctx.referrerPolicy = policy
+
+	return ctx
+}
+
+// Comment
+func (ctx *SecurityHeaders) PermissionsPolicy(feature string, allowlist ...string) *SecurityHeaders {
+	if !slices.Contains(ctx.features, feature) {
+		ctx.features = append(ctx.features, feature)
+	}
+
+	ctx.permissions[feature] = allowlist
+
+	return ctx
+}
+
+// Comment
+func (ctx *SecurityHeaders) CrossOriginOpenerPolicy(policy string) *SecurityHeaders {
+	ctx.openerPolicy = policy
+
+	return ctx
+}
+
+// Comment
+func (ctx *SecurityHeaders) CrossOriginEmbedderPolicy(policy string) *SecurityHeaders {
+	ctx.embedderPolicy = policy
+
+	return ctx
+}
+
+// Comment
+func (ctx *SecurityHeaders) ContentSecurityPolicy(csp *Csp) *SecurityHeaders {
+	ctx.csp = csp
+
+	return ctx
+}
+
+// Comment
+func (ctx *SecurityHeaders) permissionsPolicy() string {
+	policies := []string{}
+
+	for _, feature := range ctx.features {
+		allowlist := []string{}
+
+		for _, origin := range ctx.permissions[feature] {
+			if origin == PERMISSION_SELF || origin == PERMISSION_ALL {
+				allowlist = append(allowlist, origin)
+			} else {
+				allowlist = append(allowlist, `"`+origin+`"`)
+			}
+		}
+
+		policies = append(policies, feature+"=("+strings.Join(allowlist, " ")+")")
+	}
+
+	return strings.Join(policies, ", ")
+}
+
+// Comment
+func (ctx *SecurityHeaders) contentSecurityPolicy(nonce string) string {
+	policy := ctx.csp.String(nonce)
+
+	if ctx.csp.Has("frame-ancestors") {
+		return policy
+	}
+
+	// X-Frame-Options is replaced by frame-ancestors in browsers supporting CSP.
+	ancestors := map[string]string{FRAME_OPTIONS_DENY: CSP_NONE, FRAME_OPTIONS_SAME: CSP_SELF}[strings.ToUpper(ctx.frameOptions)]
+
+	if ancestors == "" {
+		return policy
+	}
+
+	if policy == "" {
+		return "frame-ancestors " + ancestors
+	}
+
+	return policy + "; frame-ancestors " + ancestors
+}
+
+// Comment
+func (ctx *SecurityHeaders) Middleware() http.Middleware {
+	return func(req *http.Request, res *http.Response, next http.Next) *http.Response {
+		if res = next(); res == nil {
+			return res
+		}
+
+		if ctx.hsts > 0 {
+			hsts := fmt.Sprintf("max-age=%d", int(ctx.hsts.Seconds()))
+
+			if ctx.subdomains {
+				hsts += "; includeSubDomains"
+			}
+
+			if ctx.preload {
+				hsts += "; preload"
+			}
+
+			res.SetHeader("Strict-Transport-Security", hsts)
+		}
+
+		res.SetHeader("X-Content-Type-Options", "nosniff")
+
+		headers := map[string]string{
+			"X-Frame-Options":              ctx.frameOptions,
+			"Referrer-Policy":              ctx.referrerPolicy,
+			"Permissions-Policy":           ctx.permissionsPolicy(),
+			"Cross-Origin-Opener-Policy":   ctx.openerPolicy,
+			"Cross-Origin-Embedder-Policy": ctx.embedderPolicy,
+		}
+
+		for header, value := range headers {
+			if value != "" {
+				res.SetHeader(header, value)
+			}
+		}
+
+		if ctx.csp != nil {
+			res.SetHeader(ctx.csp.Header(), ctx.contentSecurityPolicy(req.CspNonce()))
+		}
+
+		return res
+	}
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lucas11776-golang/http"
+	htesting "github.com/lucas11776-golang/http/testing"
+	"github.com/lucas11776-golang/http/types"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	server := http.Server("127.0.0.1", 0)
+	reports := []*CspReport{}
+
+	server.Set("view", http.NewView(fstest.MapFS{
+		"home.html": {Data: []byte(`<script nonce="{{ csp_nonce() }}">app()</script>`)},
+	}, "html"))
+
+	server.Use(NewSecurityHeaders().
+		FrameOptions(FRAME_OPTIONS_DENY).
+		PermissionsPolicy("camera").
+		PermissionsPolicy("geolocation", PERMISSION_SELF, "https://maps.test").
+		CrossOriginEmbedderPolicy(REQUIRE_CORP).
+		ContentSecurityPolicy(NewCsp().DefaultSrc(CSP_SELF).ScriptSrc(CSP_SELF, CSP_NONCE).ObjectSrc(CSP_NONE)).
+		Middleware())
+
+	server.Route().Get("home", func(req *http.Request, res *http.Response) *http.Response {
+		return res.View("home", http.ViewData{})
+	})
+
+	server.Route().Group("beta", func(route *http.Router) {
+		route.Get("/", func(req *http.Request, res *http.Response) *http.Response {
+			return res.Html("<h1>Beta</h1>")
+		})
+	}, NewSecurityHeaders().Hsts(0, false, false).ContentSecurityPolicy(NewCsp().ScriptSrc(CSP_SELF).ReportOnly("/"+CSP_REPORT_PATH)).Middleware())
+
+	CspReports(server.Route(), CSP_REPORT_PATH, func(req *http.Request, report *CspReport) {
+		reports = append(reports, report)
+	})
+
+	go server.Listen()
+
+	testCase := htesting.NewTestCase(t, server, false)
+
+	defer testCase.Cleanup()
+
+	t.Run("TestHeaders", func(t *testing.T) {
+		testCase.Request().Get("home").AssertOk().AssertHeaders(types.Headers{
+			"Strict-Transport-Security":    "max-age=31536000; includeSubDomains",
+			"X-Content-Type-Options":       "nosniff",
+			"X-Frame-Options":              "DENY",
+			"Referrer-Policy":              "strict-origin-when-cross-origin",
+			"Permissions-Policy":           `camera=(), geolocation=(self "https://maps.test")`,
+			"Cross-Origin-Opener-Policy":   "same-origin",
+			"Cross-Origin-Embedder-Policy": "require-corp",
+		})
+	})
+
+	t.Run("TestHstsDisabled", func(t *testing.T) {
+		req, _ := http.NewRequest(http.METHOD_GET, "http://app.test/", "HTTP/1.1", types.Headers{}, strings.NewReader(""))
+		res := NewSecurityHeaders().Hsts(0, false, false).FrameOptions("").Middleware()(req, req.Response, func() *http.Response {
+			return req.Response.Html("<h1>Home</h1>")
+		})
+
+		if res.GetHeader("Strict-Transport-Security") != "" || res.GetHeader("X-Frame-Options") != "" || res.GetHeader("X-Content-Type-Options") != "nosniff" {
+			t.Fatalf("Expected hsts and frame options to be disabled")
+		}
+	})
+
+	t.Run("TestNonce", func(t *testing.T) {
+		nonces := []string{}
+
+		for i := 0; i < 2; i++ {
+			res := testCase.Request().Get("home").AssertOk()
+			body, _ := io.ReadAll(res.Response.Body)
+			nonce := regexp.MustCompile(`nonce="([^"]+)"`).FindStringSubmatch(string(body))
+
+			if nonce == nil || len(nonce[1]) < 16 {
+				t.Fatalf("Expected view to contain nonce but got (%s)", string(body))
+			}
+
+			res.AssertHeader("Content-Security-Policy", "default-src 'self'; script-src 'self' 'nonce-"+nonce[1]+"'; object-src 'none'; frame-ancestors 'none'")
+
+			nonces = append(nonces, nonce[1])
+		}
+
+		if nonces[0] == nonces[1] {
+			t.Fatalf("Expected a new nonce for every request")
+		}
+	})
+
+	t.Run("TestReportOnly", func(t *testing.T) {
+		testCase.Request().Get("beta").
+			AssertOk().
+			AssertHeader("Content-Security-Policy-Report-Only", "script-src 'self'; report-uri /csp-report; frame-ancestors 'self'")
+
+		testCase.Request().SetHeader("content-type", "application/csp-report").
+			Post(CSP_REPORT_PATH, []byte(`{"csp-report":{"document-uri":"https://app.test/beta","blocked-uri":"inline","effective-directive":"script-src-elem","line-number":7}}`)).
+			AssertStatusCode(http.HTTP_RESPONSE_NO_CONTENT)
+
+		testCase.Request().SetHeader("content-type", "application/reports+json").
+			Post(CSP_REPORT_PATH, []byte(`[{"type":"csp-violation","body":{"documentURL":"https://app.test/beta","blockedURL":"https://evil.test/x.js","effectiveDirective":"script-src-elem"}},{"type":"deprecation","body":{}}]`)).
+			AssertStatusCode(http.HTTP_RESPONSE_NO_CONTENT)
+
+		testCase.Request().SetHeader("content-type", "application/csp-report").Post(CSP_REPORT_PATH, []byte(`{`)).AssertStatusCode(http.HTTP_RESPONSE_BAD_REQUEST)
+
+		if len(reports) != 2 || reports[0].LineNumber != 7 || reports[0].BlockedUri != "inline" || !strings.HasPrefix(reports[1].BlockedUri, "https://evil.test") {
+			t.Fatalf("Expected (%d) csp reports to be collected but got (%d)", 2, len(reports))
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package pages
+
+import (
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type DebugLine struct {
+	Number  int
+	Code    string
+	Current bool
+}
+
+type DebugFrame struct {
+	Function string
+	File     string
+	Line     int
+	Source   []DebugLine
+}
+
+type Debug struct {
+	Message string
+	Method  string
+	Url     string
+	Route   string
+	Stack   string
+	Frames  []DebugFrame
+	Headers map[string]string
+	Session map[string]string
+}
+
+// Comment
+func debugTable(title string, values map[string]string) string {
+	keys := []string{}
+
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	rows := []string{`<h2>` + html.EscapeString(title) + `</h2>`, `<table>`}
+
+	for _, k := range keys {
+		rows = append(rows, `<tr><th>`+html.EscapeString(k)+`</th><td>`+html.EscapeString(values[k])+`</td></tr>`)
+	}
+
+	return strings.Join(append(rows, `</table>`), "\r\n")
+}
+
+// Comment
+func debugFrame(frame DebugFrame) string {
+	lines := []string{
+		`<div class="frame">`,
+		`  <h3>` + html.EscapeString(frame.Function) + `</h3>`,
+		`  <small>` + html.EscapeString(frame.File) + `:` + strconv.Itoa(frame.Line) + `</small>`,
+	}
+
+	if len(frame.Source) != 0 {
+		lines = append(lines, `  <pre>`)
+
+		for _, line := range frame.Source {
+			code := strconv.Itoa(line.Number) + `  ` + html.EscapeString(line.Code)
+
+			if line.Current {
+				code = `<mark>` + code + `</mark>`
+			}
+
+			lines = append(lines, code)
+		}
+
+		lines = append(lines, `  </pre>`)
+	}
+
+	return strings.Join(append(lines, `</div>`), "\r\n")
+}
+
+// Comment
+func DebugPage(debug Debug) string {
+	frames := []string{}
+
+	for _, frame := range debug.Frames {
+		frames = append(frames, debugFrame(frame))
+	}
+
+	return strings.Join([]string{
+		`<!DOCTYPE html>`,
+		`<head>`,
+		`  <title>500 | ` + html.EscapeString(debug.Message) + `</title>`,
+		`  <style>`,
+		`    body { font-family: monospace; margin: 20px; }`,
+		`    .frame { border-bottom: 1px solid #ddd; padding: 10px 0; }`,
+		`    mark { display: block; background: #fdd; }`,
+		`    th { text-align: left; padding-right: 20px; }`,
+		`  </style>`,
+		`</head>`,
+		`<body>`,
+		`  <h1>` + html.EscapeString(debug.Message) + `</h1>`,
+		`  <p>` + html.EscapeString(debug.Method) + ` ` + html.EscapeString(debug.Url) + `</p>`,
+		`  <p>Route: ` + html.EscapeString(debug.Route) + `</p>`,
+		`  <h2>Stack Trace</h2>`,
+		strings.Join(frames, "\r\n"),
+		debugTable("Request Headers", debug.Headers),
+		debugTable("Session", debug.Session),
+		`  <h2>Raw Stack</h2>`,
+		`  <pre>` + html.EscapeString(debug.Stack) + `</pre>`,
+		`</body>`,
+		`</html>`,
+	}, "\r\n")
+}
@@ -1,6 +1,8 @@
 package pages
 
 import (
+	"html"
+	"strconv"
 	"strings"
 
 	"github.com/lucas11776-golang/http/utils/helper"
@@ -19,9 +21,24 @@ func RedirectPage(to string) string {
 	}, "\r\n")
 }
 
+// Comment
+func ErrorPage(status int, title string, detail string) string {
+	return strings.Join([]string{
+		`<!DOCTYPE html>`,
+		`<head>`,
+		`  <title>` + strconv.Itoa(status) + ` | ` + html.EscapeString(title) + `</title>`,
+		`</head>`,
+		`<body>`,
+		`	<h1>` + strconv.Itoa(status) + ` | ` + html.EscapeString(title) + `</h1>`,
+		`	<p>` + html.EscapeString(detail) + `</p>`,
+		`</body>`,
+		`</html>`,
+	}, "\r\n")
+}
+
 // Comment
 func ServerErrorPage() string {
-	return ""
+	return ErrorPage(500, "Internal Server Error", "")
 }
 
 // Comment
@@ -0,0 +1,166 @@
+package http
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/lucas11776-golang/http/pages"
+)
+
+const (
+	DEBUG_SOURCE_LINES = 5
+	DEBUG_MAX_FRAMES   = 20
+	DEBUG_REDACTED     = "[redacted]"
+)
+
+var DEBUG_REDACTED_HEADERS = []string{"Authorization", "Proxy-Authorization", "Cookie", CSRF_HEADER_NAME, XSRF_HEADER_NAME}
+
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+	pcs   []uintptr
+}
+
+// Comment
+func (ctx *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", ctx.Value)
+}
+
+// Comment
+func Recover(req *Request, res *Response, next Next) (response *Response) {
+	defer func() {
+		value := recover()
+
+		if value == nil {
+			return
+		}
+
+		pcs := make([]uintptr, 64)
+
+		err := &PanicError{
+			Value: value,
+			Stack: debug.Stack(),
+			pcs:   pcs[:runtime.Callers(3, pcs)],
+		}
+
+		req.Logger().Error(err.Error(), slog.String("stack", string(err.Stack)))
+
+		response = recovered(req, err)
+	}()
+
+	return next()
+}
+
+// Comment
+func recovered(req *Request, err *PanicError) *Response {
+	if req.Server == nil || !req.Server.IsDebug() {
+		return req.Response.Error(err)
+	}
+
+	if req.Accepts("text/html", PROBLEM_JSON_CONTENT_TYPE, "application/json") != "text/html" {
+		httpError := InternalServerError(err)
+
+		httpError.Detail = err.Error()
+
+		return req.Response.Problem(httpError)
+	}
+
+	return req.Response.SetStatus(HTTP_RESPONSE_INTERNAL_SERVER_ERROR).Html(pages.DebugPage(debugInformation(req, err)))
+}
+
+// Comment
+func debugSource(file string, line int) []pages.DebugLine {
+	f, err := os.Open(file)
+
+	if err != nil {
+		return nil
+	}
+
+	defer f.Close()
+
+	source := []pages.DebugLine{}
+	scanner := bufio.NewScanner(f)
+
+	for number := 1; scanner.Scan(); number++ {
+		if number < line-DEBUG_SOURCE_LINES {
+			continue
+		}
+
+		if number > line+DEBUG_SOURCE_LINES {
+			break
+		}
+
+		source = append(source, pages.DebugLine{
+			Number:  number,
+			Code:    scanner.Text(),
+			Current: number == line,
+		})
+	}
+
+	return source
+}
+
+// Comment
+func debugFrames(pcs []uintptr) []pages.DebugFrame {
+	frames := []pages.DebugFrame{}
+	callers := runtime.CallersFrames(pcs)
+
+	for {
+		frame, more := callers.Next()
+
+		if !strings.HasPrefix(frame.Function, "runtime.") && len(frames) < DEBUG_MAX_FRAMES {
+			frames = append(frames, pages.DebugFrame{
+				Function: frame.Function,
+				File:     frame.File,
+				Line:     frame.Line,
+				Source:   debugSource(frame.File, frame.Line),
+			})
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return frames
+}
+
+// Comment
+func debugInformation(req *Request, err *PanicError) pages.Debug {
+	information := pages.Debug{
+		Message: err.Error(),
+		Method:  req.Method,
+		Url:     req.URL.String(),
+		Stack:   string(err.Stack),
+		Frames:  debugFrames(err.pcs),
+		Headers: make(map[string]string),
+		Session: make(map[string]string),
+	}
+
+	if route := req.Route(); route != nil {
+		information.Route = strings.Join([]string{route.Method(), route.Path()}, " ")
+	}
+
+	for k, v := range req.Header {
+		information.Headers[k] = strings.Join(v, ", ")
+
+		if slices.ContainsFunc(DEBUG_REDACTED_HEADERS, func(header string) bool { return strings.EqualFold(header, k) }) {
+			information.Headers[k] = DEBUG_REDACTED
+		}
+	}
+
+	// Session values hold the csrf token and user state, only the keys are shown.
+	if req.Session != nil {
+		for k := range req.Session.All() {
+			information.Session[k] = DEBUG_REDACTED
+		}
+	}
+
+	return information
+}
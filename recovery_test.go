@@ -0,0 +1,197 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucas11776-golang/http/types"
+)
+
+func TestRecover(t *testing.T) {
+	request := func(t *testing.T, server *HTTP, accept string) *Response {
+		req, err := NewRequest("GET", "orders/1", "HTTP/1.1", types.Headers{"accept": accept}, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		return server.HandleRequest(server.NewRequest(req.Request, nil))
+	}
+
+	panicServer := func(debug bool) *HTTP {
+		server := Server("127.0.0.1", 0).Debug(debug)
+
+		server.Route().Get("orders/{order}", func(req *Request, res *Response) *Response {
+			var orders map[string]string
+
+			orders[req.Parameters.Get("order")] = "paid"
+
+			return res.Json(orders)
+		})
+
+		return server
+	}
+
+	t.Run("TestRecoverProduction", func(t *testing.T) {
+		server := panicServer(false)
+
+		res := request(t, server, "text/html")
+
+		if res == nil {
+			t.Fatalf("Expected panic to be recovered with a response")
+		}
+
+		body, _ := io.ReadAll(res.Body)
+
+		if res.StatusCode != int(HTTP_RESPONSE_INTERNAL_SERVER_ERROR) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", HTTP_RESPONSE_INTERNAL_SERVER_ERROR, res.StatusCode)
+		}
+
+		if strings.Contains(string(body), "assignment to entry in nil map") {
+			t.Fatalf("Expected production error page to not contain panic message but got (%s)", string(body))
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestRecoverDebugPage", func(t *testing.T) {
+		server := panicServer(true)
+
+		res := request(t, server, "text/html")
+
+		body, _ := io.ReadAll(res.Body)
+
+		if res.StatusCode != int(HTTP_RESPONSE_INTERNAL_SERVER_ERROR) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", HTTP_RESPONSE_INTERNAL_SERVER_ERROR, res.StatusCode)
+		}
+
+		for _, expected := range []string{
+			"assignment to entry in nil map",
+			"recovery_test.go",
+			`orders[req.Parameters.Get(&#34;order&#34;)] = &#34;paid&#34;`,
+			"GET orders/{order}",
+			"Accept",
+		} {
+			if !strings.Contains(string(body), expected) {
+				t.Fatalf("Expected debug page to contain (%s)", expected)
+			}
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestRecoverDebugJson", func(t *testing.T) {
+		server := panicServer(true)
+
+		res := request(t, server, "application/json")
+
+		body, _ := io.ReadAll(res.Body)
+		problem := Problem{}
+
+		if err := json.Unmarshal(body, &problem); err != nil {
+			t.Fatalf("Failed to parse problem: %v", err)
+		}
+
+		if !strings.Contains(problem.Detail, "assignment to entry in nil map") {
+			t.Fatalf("Expected problem detail to contain panic message but got (%s)", problem.Detail)
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestRecoverDebugRedacted", func(t *testing.T) {
+		// Secrets are built at runtime because the debug page shows the source around each frame.
+		secret := func(name string) string { return strings.Join([]string{"secret", name, "token"}, "-") }
+
+		server := Server("127.0.0.1", 0).Debug(true)
+
+		server.Session(nil, NewMemorySessionStore())
+
+		server.Route().Get("account", func(req *Request, res *Response) *Response {
+			req.Session.Set("api_token", secret("session"))
+
+			panic("account failed")
+		})
+
+		req, err := NewRequest("GET", "account", "HTTP/1.1", types.Headers{
+			"accept":        "text/html",
+			"authorization": "Bearer " + secret("bearer"),
+			"cookie":        "remember=" + secret("cookie"),
+		}, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		request := server.NewRequest(req.Request, nil)
+
+		body, _ := io.ReadAll(server.HandleRequest(request).Body)
+
+		for _, secret := range []string{secret("session"), secret("bearer"), secret("cookie"), request.Session.CsrfToken()} {
+			if strings.Contains(string(body), secret) {
+				t.Fatalf("Expected debug page to not contain (%s)", secret)
+			}
+		}
+
+		for _, expected := range []string{"api_token", "Authorization", DEBUG_REDACTED} {
+			if !strings.Contains(string(body), expected) {
+				t.Fatalf("Expected debug page to contain (%s)", expected)
+			}
+		}
+
+		server.Close()
+	})
+
+	t.Run("TestRecoverWebsocket", func(t *testing.T) {
+		server := Server("127.0.0.1", 0)
+
+		server.Route().Ws("feed", func(req *Request, ws *Ws) {
+			panic("feed failed")
+		})
+
+		go server.Listen()
+
+		conn, err := net.Dial("tcp", server.Host())
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to connect: %v", err)
+		}
+
+		defer conn.Close()
+
+		_, err = conn.Write([]byte(strings.Join([]string{
+			"GET /feed HTTP/1.1",
+			"Host: " + server.Host(),
+			"Connection: Upgrade",
+			"Upgrade: websocket",
+			"Sec-Websocket-Key: TnjNK5ivR7MUvlou4Ilj9g==",
+			"Sec-Websocket-Version: 13",
+			"\r\n",
+		}, "\r\n")))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to send handshake: %v", err)
+		}
+
+		conn.SetReadDeadline(time.Now().Add(time.Second * 2))
+
+		reader := bufio.NewReader(conn)
+
+		for {
+			if _, err := reader.ReadString('\n'); err != nil {
+				if err != io.EOF {
+					t.Fatalf("Expected connection to be closed but got (%v)", err)
+				}
+
+				break
+			}
+		}
+
+		server.Close()
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/lucas11776-golang/http/server/connection"
 	"github.com/lucas11776-golang/http/types"
@@ -34,6 +35,7 @@ const (
 const (
 	FormValidationErrorMessage string = "Form validation faild check errors below"
 	RequestFormMethodName      string = "__METHOD__"
+	AuthorizationMessage       string = "This action is unauthorized."
 )
 
 type Request struct {
@@ -46,8 +48,17 @@ type Request struct {
 	Parameters Parameters
 	Validator  *validation.Validator
 	isStatic   bool
+	route      *Route
+	values     map[string]interface{}
 }
 
+type Authorizer func(req *Request, ability string, arguments ...interface{}) bool
+
+var (
+	authorizer      Authorizer
+	authorizerMutex sync.RWMutex
+)
+
 type HttpRequestHeader struct {
 	method   string
 	path     string
@@ -71,11 +82,8 @@ func FormRequest(rules validation.RulesBag) Middleware {
 		switch Method(req.Method) {
 		case METHOD_POST, METHOD_PATCH, METHOD_PUT, METHOD_DELETE:
 			if req.Validator = validation.Validation(req.Request, rules); !req.Validator.Validate() {
-				if strings.ToLower(req.ContentType()) == "application/json" || req.GetHeader("accept") == "application/json" {
-					return res.SetStatus(HTTP_RESPONSE_UNPROCESSABLE_CONTENT).Json(JsonErrorResponse{
-						Message: FormValidationErrorMessage,
-						Errors:  SessionErrorsBag(req.Validator.Errors()),
-					})
+				if strings.ToLower(req.ContentType()) == "application/json" || req.WantsJson() {
+					return res.Error(Validation(SessionErrorsBag(req.Validator.Errors())))
 				}
 
 				if req.Session != nil {
@@ -118,6 +126,65 @@ func (ctx *Request) Path() string {
 	return strings.Trim(ctx.URL.Path, "/")
 }
 
+// Comment
+func (ctx *Request) Route() *Route {
+	return ctx.route
+}
+
+// Comment
+func (ctx *Request) Set(key string, value interface{}) *Request {
+	if ctx.values == nil {
+		ctx.values = make(map[string]interface{})
+	}
+
+	ctx.values[key] = value
+
+	return ctx
+}
+
+// Comment
+func (ctx *Request) Get(key string) interface{} {
+	return ctx.values[key]
+}
+
+// Comment
+func UseAuthorizer(callback Authorizer) {
+	authorizerMutex.Lock()
+	defer authorizerMutex.Unlock()
+
+	authorizer = callback
+}
+
+// Comment
+func (ctx *HTTP) Authorizer(callback Authorizer) *HTTP {
+	ctx.authorizer = callback
+
+	return ctx
+}
+
+// Comment
+func (ctx *Request) Can(ability string, arguments ...interface{}) bool {
+	authorizerMutex.RLock()
+	callback := authorizer
+	authorizerMutex.RUnlock()
+
+	// The authorizer of the server is used before the global authorizer.
+	if ctx.Server != nil && ctx.Server.authorizer != nil {
+		callback = ctx.Server.authorizer
+	}
+
+	return callback != nil && callback(ctx, ability, arguments...)
+}
+
+// Comment
+func (ctx *Request) Authorize(ability string, arguments ...interface{}) error {
+	if !ctx.Can(ability, arguments...) {
+		return Forbidden(AuthorizationMessage)
+	}
+
+	return nil
+}
+
 // Comment
 func (ctx *Request) Protocol() string {
 	return ctx.Proto
@@ -139,6 +206,16 @@ func (ctx *Request) GetHeader(key string) string {
 	return strings.Join(header, ";")
 }
 
+// Comment
+func (ctx *Request) Accepts(offers ...string) string {
+	return h.ParseAccept(strings.Join(ctx.Header.Values("Accept"), ",")).Negotiate(offers...)
+}
+
+// Comment
+func (ctx *Request) WantsJson() bool {
+	return ctx.Accepts("text/html", "application/json") == "application/json"
+}
+
 // Comment
 func (ctx *Request) IP() string {
 	return ctx.Conn.IP()
@@ -189,4 +189,68 @@ func TestRequest(t *testing.T) {
 			)
 		}
 	})
+
+	t.Run("TestAccepts", func(t *testing.T) {
+		req, err := NewRequest("GET", "products", "HTTP/1.1", types.Headers{
+			"accept": "text/html;q=0.5, application/json",
+		}, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		if accept := req.Accepts("text/html", "application/json"); accept != "application/json" {
+			t.Fatalf("Expected accept to be (%s) but got (%s)", "application/json", accept)
+		}
+
+		if accept := req.Accepts("application/xml"); accept != "" {
+			t.Fatalf("Expected accept to be empty but got (%s)", accept)
+		}
+
+		if !req.WantsJson() {
+			t.Fatalf("Expected request to want json")
+		}
+	})
+
+	t.Run("TestValues", func(t *testing.T) {
+		req, err := NewRequest("GET", "products", "HTTP/1.1", types.Headers{}, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		if value := req.Get("user"); value != nil {
+			t.Fatalf("Expected missing value to be nil but got (%v)", value)
+		}
+
+		if value := req.Set("user", "jeo").Get("user"); value != "jeo" {
+			t.Fatalf("Expected value to be (%s) but got (%v)", "jeo", value)
+		}
+	})
+
+	t.Run("TestAuthorize", func(t *testing.T) {
+		req, err := NewRequest("PUT", "posts/1", "HTTP/1.1", types.Headers{}, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		if err := req.Authorize("update"); ToHttpError(err).Status != HTTP_RESPONSE_FORBIDDEN {
+			t.Fatalf("Expected request without authorizer to be forbidden but got (%v)", err)
+		}
+
+		UseAuthorizer(func(req *Request, ability string, arguments ...interface{}) bool {
+			return ability == "update" && len(arguments) == 1 && arguments[0] == "post"
+		})
+
+		defer UseAuthorizer(nil)
+
+		if err := req.Authorize("update", "post"); err != nil {
+			t.Fatalf("Expected request to be authorized but got (%v)", err)
+		}
+
+		if req.Can("delete", "post") {
+			t.Fatalf("Expected request not to be able to delete post")
+		}
+	})
 }
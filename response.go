@@ -2,7 +2,9 @@ package http
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"io"
 	"net/http"
@@ -13,8 +15,10 @@ import (
 	"github.com/lucas11776-golang/http/types"
 	h "github.com/lucas11776-golang/http/utils/headers"
 	"github.com/lucas11776-golang/http/utils/response"
+	"github.com/vmihailenco/msgpack/v5"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
 )
 
 type Status int
@@ -62,6 +66,7 @@ const (
 	HTTP_RESPONSE_RANGE_NOT_SATISFIABLE           Status = 416
 	HTTP_RESPONSE_EXPECTATION_FAILED              Status = 417
 	HTTP_RESPONSE_IM_A_TEAPOT                     Status = 418
+	HTTP_RESPONSE_PAGE_EXPIRED                    Status = 419
 	HTTP_RESPONSE_MISDIRECTED_REQUEST             Status = 421
 	HTTP_RESPONSE_UNPROCESSABLE_CONTENT           Status = 422
 	HTTP_RESPONSE_LOCKED                          Status = 423
@@ -116,6 +121,13 @@ type Writer struct {
 	response *Response
 }
 
+type Negotiator struct {
+	Type    string
+	Handler func() *Response
+}
+
+type Negotiators []Negotiator
+
 // Comment
 func (ctx *Response) Protocol() string {
 	return ctx.Proto
@@ -208,6 +220,15 @@ func (ctx *Response) WithError(key string, value string) *Response {
 	return ctx
 }
 
+// Comment
+func (ctx *Response) With(key string, value interface{}) *Response {
+	if ctx.Session != nil {
+		ctx.Session.Flash(key, value)
+	}
+
+	return ctx
+}
+
 // Comment
 func (ctx *Response) WithErrors(errors SessionErrorsBag) *Response {
 	if ctx.Session != nil {
@@ -231,19 +252,80 @@ func (ctx *Response) Html(html string) *Response {
 
 // Comment
 func (ctx *Response) Json(v any) *Response {
-	ctx.SetHeader("content-type", "application/json")
-
 	data, err := json.Marshal(v)
 
+	return ctx.encoded("application/json", data, err)
+}
+
+// Comment
+func (ctx *Response) encoded(contentType string, data []byte, err error) *Response {
 	if err != nil {
-		data, _ := json.Marshal(map[string]string{
-			"message": "parse error",
-		})
+		return ctx.Error(err)
+	}
+
+	return ctx.SetHeader("content-type", contentType).SetBody(data)
+}
+
+// Comment
+func (ctx *Response) Text(text string) *Response {
+	return ctx.SetHeader("content-type", "text/plain").SetBody([]byte(text))
+}
+
+// Comment
+func (ctx *Response) Xml(v any) *Response {
+	data, err := xml.Marshal(v)
+
+	return ctx.encoded("application/xml", append([]byte(xml.Header), data...), err)
+}
+
+// Comment
+func (ctx *Response) Yaml(v any) *Response {
+	data, err := yaml.Marshal(v)
+
+	return ctx.encoded("application/yaml", data, err)
+}
+
+// Comment
+func (ctx *Response) MsgPack(v any) *Response {
+	data, err := msgpack.Marshal(v)
+
+	return ctx.encoded("application/msgpack", data, err)
+}
+
+// Comment
+func (ctx *Response) Csv(records [][]string) *Response {
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+
+	err := writer.WriteAll(records)
+
+	return ctx.encoded("text/csv", buffer.Bytes(), err)
+}
+
+// Comment
+func (ctx *Response) Negotiate(negotiators Negotiators) *Response {
+	offers := []string{}
+
+	for _, negotiator := range negotiators {
+		offers = append(offers, negotiator.Type)
+	}
+
+	accept := ""
+
+	// Offers are tried in order so the first one wins when the request accepts several equally e.g. */*.
+	if ctx.Request != nil {
+		accept = ctx.Request.Accepts(offers...)
+	} else if len(offers) != 0 {
+		accept = offers[0]
+	}
 
-		return ctx.SetBody(data)
+	for _, negotiator := range negotiators {
+		if accept != "" && negotiator.Type == accept {
+			return negotiator.Handler()
+		}
 	}
 
-	return ctx.SetBody(data)
+	return ctx.Error(NewHttpError(HTTP_RESPONSE_NOT_ACCEPTABLE, ""))
 }
 
 // Comment
@@ -281,7 +363,7 @@ func (ctx *Response) View(view string, data ViewData) *Response {
 		Read(ctx.Bag.View.Name, ctx.Bag.View.Data, ctx.Request)
 
 	if err != nil {
-		return ctx.SetStatus(HTTP_RESPONSE_INTERNAL_SERVER_ERROR).Html(err.Error())
+		return ctx.Error(err)
 	}
 
 	return ctx.Html(string(html))
@@ -385,6 +467,8 @@ func StatusText(status Status) string {
 		return "Expectation Failed"
 	case HTTP_RESPONSE_IM_A_TEAPOT:
 		return "I`m a teapot"
+	case HTTP_RESPONSE_PAGE_EXPIRED:
+		return "Page Expired"
 	case HTTP_RESPONSE_MISDIRECTED_REQUEST:
 		return "Misdirected Request"
 	case HTTP_RESPONSE_UNPROCESSABLE_CONTENT:
@@ -3,6 +3,7 @@ package http
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
 	"io"
 	"math/rand"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"github.com/lucas11776-golang/http/utils/helper"
 	"github.com/lucas11776-golang/http/utils/response"
 	"github.com/open2b/scriggo"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 func TestResponse(t *testing.T) {
@@ -276,4 +278,91 @@ func TestResponse(t *testing.T) {
 
 		req.Server.Close()
 	})
+
+	t.Run("TestResponseFormats", func(t *testing.T) {
+		type Product struct {
+			ID   int64  `json:"id" xml:"id" yaml:"id" msgpack:"id"`
+			Name string `json:"name" xml:"name" yaml:"name" msgpack:"name"`
+		}
+
+		product := Product{ID: 1, Name: "Keyboard"}
+
+		tests := []struct {
+			response    *Response
+			contentType string
+			body        string
+		}{
+			{InitResponse().Text("Hello World"), "text/plain", "Hello World"},
+			{InitResponse().Xml(product), "application/xml", xml.Header + "<Product><id>1</id><name>Keyboard</name></Product>"},
+			{InitResponse().Yaml(product), "application/yaml", "id: 1\nname: Keyboard\n"},
+			{InitResponse().Csv([][]string{{"id", "name"}, {"1", "Keyboard"}}), "text/csv", "id,name\n1,Keyboard\n"},
+		}
+
+		for _, test := range tests {
+			body, err := io.ReadAll(test.response.Body)
+
+			if err != nil {
+				t.Fatalf("Failed to read body: %v", err)
+			}
+
+			if test.response.GetHeader("content-type") != test.contentType {
+				t.Fatalf("Expected content-type header to be (%s) but got (%s)", test.contentType, test.response.GetHeader("content-type"))
+			}
+
+			if string(body) != test.body {
+				t.Fatalf("Expected response body to be (%s) but got (%s)", test.body, string(body))
+			}
+		}
+
+		res := InitResponse().MsgPack(product)
+
+		body, err := io.ReadAll(res.Body)
+
+		if err != nil {
+			t.Fatalf("Failed to read body: %v", err)
+		}
+
+		actual := Product{}
+
+		if err := msgpack.Unmarshal(body, &actual); err != nil {
+			t.Fatalf("Failed to decode msgpack body: %v", err)
+		}
+
+		if actual != product {
+			t.Fatalf("Expected msgpack product to be (%v) but got (%v)", product, actual)
+		}
+	})
+
+	t.Run("TestResponseNegotiate", func(t *testing.T) {
+		negotiate := func(accept string) *Response {
+			req, err := NewRequest("GET", "products", "HTTP/1.1", types.Headers{"accept": accept}, strings.NewReader(""))
+
+			if err != nil {
+				t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+			}
+
+			req.Response.Request = req
+
+			return req.Response.Negotiate(Negotiators{
+				{Type: "text/html", Handler: func() *Response { return req.Response.Html("<h1>Products</h1>") }},
+				{Type: "application/json", Handler: func() *Response { return req.Response.Json([]string{"products"}) }},
+			})
+		}
+
+		if res := negotiate("*/*"); res.GetHeader("content-type") != "text/html" {
+			t.Fatalf("Expected content-type header to be (%s) but got (%s)", "text/html", res.GetHeader("content-type"))
+		}
+
+		if res := negotiate("application/json"); res.GetHeader("content-type") != "application/json" {
+			t.Fatalf("Expected content-type header to be (%s) but got (%s)", "application/json", res.GetHeader("content-type"))
+		}
+
+		if res := negotiate("text/html,*/*;q=0.8"); res.GetHeader("content-type") != "text/html" {
+			t.Fatalf("Expected content-type header to be (%s) but got (%s)", "text/html", res.GetHeader("content-type"))
+		}
+
+		if res := negotiate("image/png"); res.StatusCode != int(HTTP_RESPONSE_NOT_ACCEPTABLE) {
+			t.Fatalf("Expected response status code to be (%d) but got (%d)", HTTP_RESPONSE_NOT_ACCEPTABLE, res.StatusCode)
+		}
+	})
 }
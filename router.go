@@ -4,6 +4,7 @@ import (
 	"net"
 	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 
 	str "github.com/lucas11776-golang/http/utils/strings"
@@ -34,6 +35,7 @@ type Route struct {
 	method     string
 	path       []string
 	middleware []Middleware
+	cors       Middleware
 	router     *Router
 	callback   reflect.Value
 }
@@ -50,6 +52,7 @@ type Router struct {
 	subdomain   string
 	path        string
 	middlewares []Middleware
+	cors        Middleware
 	routes      *RouterGroup
 }
 
@@ -164,7 +167,11 @@ func mergeMap(m map[string]string, maps ...map[string]string) map[string]string
 
 // comment
 func routeMatch(routes Routes, req *Request) (*Route, Parameters) {
-	method := req.Method
+	return routeMatchMethod(routes, req, req.Method)
+}
+
+// Comment
+func routeMatchMethod(routes Routes, req *Request, method string) (*Route, Parameters) {
 	uri := req.Path()
 	path := strings.Split(strings.Trim(uri, "/"), "/")
 	requestSubdomain := strings.Split(getSubdomain(req.Host), ".")
@@ -217,6 +224,29 @@ func (ctx *RouterGroup) MatchWebRoute(req *Request) *Route {
 	return route
 }
 
+// Comment
+func (ctx *RouterGroup) MatchOptionsRoutes(req *Request) Routes {
+	matched := Routes{}
+	methods := []string{}
+
+	for _, route := range ctx.web {
+		if !slices.Contains(methods, route.Method()) {
+			methods = append(methods, route.Method())
+		}
+	}
+
+	for _, method := range methods {
+		route, parameters := routeMatchMethod(ctx.web, req, method)
+
+		if route != nil {
+			req.Parameters = parameters
+			matched = append(matched, route)
+		}
+	}
+
+	return matched
+}
+
 // Comment
 func (ctx *RouterGroup) MatchWsRoute(req *Request) *Route {
 	route, parameters := routeMatch(ctx.ws, req)
@@ -235,6 +265,7 @@ func (ctx *Router) getRoute(router *Router, method string, uri string, callback
 		method:     strings.ToUpper(method),
 		path:       strings.Split(str.JoinPath(ctx.path, uri), "/"),
 		middleware: append(ctx.middlewares, middleware...),
+		cors:       ctx.cors,
 		router:     router,
 		callback:   callback,
 	}
@@ -259,6 +290,7 @@ func (ctx *Router) Callback(group GroupCallback, middleware ...Middleware) {
 		path:        ctx.path,
 		routes:      ctx.routes,
 		middlewares: append(ctx.middlewares, middleware...),
+		cors:        ctx.cors,
 	})
 }
 
@@ -269,6 +301,7 @@ func (ctx *Router) Subdomain(subdomain string, group GroupCallback, middleware .
 		path:        ctx.path,
 		routes:      ctx.routes,
 		middlewares: append(ctx.middlewares, middleware...),
+		cors:        ctx.cors,
 	})
 }
 
@@ -279,6 +312,7 @@ func (ctx *Router) Group(prefix string, group GroupCallback, middleware ...Middl
 		path:        str.JoinPath(ctx.path, prefix),
 		routes:      ctx.routes,
 		middlewares: append(ctx.middlewares, middleware...),
+		cors:        ctx.cors,
 	})
 }
 
@@ -289,6 +323,13 @@ func (ctx *Router) Middleware(middlewares ...Middleware) *Router {
 	return ctx
 }
 
+// Comment
+func (ctx *Router) Cors(cors Middleware) *Router {
+	ctx.cors = cors
+
+	return ctx
+}
+
 // Comment
 func (ctx *Router) Get(uri string, callback WebCallback, middleware ...Middleware) *Route {
 	return ctx.Route("GET", uri, callback, middleware...)
@@ -310,4 +310,38 @@ func TestRouter(t *testing.T) {
 		// WebSocket  Route Test
 		testingRoute(t, router.ws, router.MatchWsRoute(routeRequest("127.0.0.1:8080", "GET", "chats/c-43gpdmwr")), 0, "GET", "chats/{id}", 1)
 	})
+
+	t.Run("TestOptionsMatch", func(t *testing.T) {
+		router := &RouterGroup{}
+		cors := func(req *Request, res *Response, next Next) *Response {
+			return next()
+		}
+
+		router.Router().Group("api", func(router *Router) {
+			router.Cors(cors)
+			router.Get("products/{id}", func(req *Request, res *Response) *Response {
+				return res
+			})
+			router.Group("admin", func(router *Router) {
+				router.Delete("products/{id}", func(req *Request, res *Response) *Response {
+					return res
+				})
+			})
+		})
+
+		req := routeRequest("127.0.0.1:8080", "OPTIONS", "api/products/7")
+		routes := router.MatchOptionsRoutes(req)
+
+		if len(routes) != 1 || routes[0].Method() != "GET" || routes[0].cors == nil || req.Parameters.Get("id") != "7" {
+			t.Fatalf("Expected options to match route (%s) with cors", "GET api/products/{id}")
+		}
+
+		if routes := router.MatchOptionsRoutes(routeRequest("127.0.0.1:8080", "OPTIONS", "api/admin/products/7")); len(routes) != 1 || routes[0].cors == nil {
+			t.Fatalf("Expected nested group to inherit cors")
+		}
+
+		if routes := router.MatchOptionsRoutes(routeRequest("127.0.0.1:8080", "OPTIONS", "api/orders")); len(routes) != 0 {
+			t.Fatalf("Expected options to match (%d) routes but got (%d)", 0, len(routes))
+		}
+	})
 }
@@ -4,20 +4,24 @@ import (
 	"crypto/sha1"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/lucas11776-golang/http/config"
+	"github.com/lucas11776-golang/http/encryption/key"
 
 	"github.com/lucas11776-golang/http/server/connection"
 	"github.com/lucas11776-golang/http/server/tcp"
 	"github.com/lucas11776-golang/http/server/udp"
 	"github.com/lucas11776-golang/http/types"
+	"github.com/lucas11776-golang/http/utils/env"
 	"github.com/lucas11776-golang/http/utils/response"
 	"github.com/lucas11776-golang/http/utils/slices"
-	str "github.com/lucas11776-golang/http/utils/strings"
 )
 
 const (
@@ -49,6 +53,15 @@ type HTTP struct {
 	MaxWebSocketPayloadSize int
 	dependency              Dependencies
 	parseJson               bool
+	errorHandler            ErrorHandler
+	errorView               string
+	middlewares             []Middleware
+	debug                   bool
+	keyring                 *key.Keyring
+	keyError                error
+	logger                  *slog.Logger
+	viewHelpers             map[string]ViewHelperCallback
+	authorizer              Authorizer
 }
 
 type HttpHandler interface {
@@ -80,7 +93,7 @@ func (ctx *HTTP) handleStatic(req *Request) *Response {
 	if err != nil {
 		// TODO: must improve the checking is temp
 		if len(strings.Split(slices.End(strings.Split(req.Path(), "/")), ".")) > 1 {
-			return req.Response.SetStatus(HTTP_RESPONSE_NOT_FOUND)
+			return req.Response.Error(NotFound(""))
 		}
 
 		return nil
@@ -123,6 +136,36 @@ func (ctx *HTTP) handleRouteMiddleware(route *Route, req *Request) *Response {
 	return nil
 }
 
+// Comment
+func (ctx *HTTP) Use(middlewares ...Middleware) *HTTP {
+	ctx.middlewares = append(ctx.middlewares, middlewares...)
+
+	return ctx
+}
+
+// Comment
+func (ctx *HTTP) Debug(debug bool) *HTTP {
+	ctx.debug = debug
+
+	return ctx
+}
+
+// Comment
+func (ctx *HTTP) IsDebug() bool {
+	return ctx.debug
+}
+
+// Comment
+func pipeline(middlewares []Middleware, req *Request, handler Next) *Response {
+	if len(middlewares) == 0 {
+		return handler()
+	}
+
+	return middlewares[0](req, req.Response, func() *Response {
+		return pipeline(middlewares[1:], req, handler)
+	})
+}
+
 // Comment
 func (ctx *HTTP) NewRequest(rq *http.Request, conn *connection.Connection) *Request {
 	req := &Request{
@@ -167,8 +210,12 @@ func (ctx *HTTP) SetMaxWebsocketPayload(size int) *HTTP {
 }
 
 // Comment
-func (ctx *HTTP) Session(key []byte) SessionsManager {
-	return ctx.Set("session", InitSession(SESSION_NAME, key)).Get("session").(SessionsManager)
+func (ctx *HTTP) Session(secret []byte, store ...SessionStore) SessionsManager {
+	if secret == nil {
+		return ctx.Set("session", InitSessionKeyring(SESSION_NAME, ctx.keyring, store...)).Get("session").(SessionsManager)
+	}
+
+	return ctx.Set("session", InitSession(SESSION_NAME, secret, store...)).Get("session").(SessionsManager)
 }
 
 func (ctx *HTTP) ParseJson(parse bool) *HTTP {
@@ -179,7 +226,7 @@ func (ctx *HTTP) ParseJson(parse bool) *HTTP {
 
 // Comment
 func defaultRouteFallback(req *Request, res *Response) *Response {
-	return res.SetStatus(HTTP_RESPONSE_NOT_FOUND)
+	return res.Error(NotFound(""))
 }
 
 type RequestHandler interface {
@@ -206,15 +253,60 @@ func (ctx *HTTP) setupRequest(req *Request) *Request {
 func (ctx *HTTP) requestHandler(req *Request) *Response {
 	route := ctx.Router().MatchWebRoute(req)
 
+	if route == nil && strings.ToUpper(req.Method) == string(METHOD_OPTIONS) {
+		if res := ctx.optionsHandler(req); res != nil {
+			return res
+		}
+	}
+
 	if route == nil {
 		return ctx.routeNotFound(req)
 	}
 
-	if res := ctx.handleRouteMiddleware(route, req); res != nil {
-		return res
+	req.route = route
+
+	middlewares := route.middleware
+
+	// Cors runs before the route middleware so preflight requests are answered first.
+	if route.cors != nil {
+		middlewares = append([]Middleware{route.cors}, middlewares...)
 	}
 
-	return route.Call(reflect.ValueOf(req), reflect.ValueOf(req.Response))
+	return pipeline(middlewares, req, func() *Response {
+		return route.Call(reflect.ValueOf(req), reflect.ValueOf(req.Response))
+	})
+}
+
+// Comment
+func (ctx *HTTP) optionsHandler(req *Request) *Response {
+	routes := ctx.Router().MatchOptionsRoutes(req)
+
+	if len(routes) == 0 {
+		return nil
+	}
+
+	route := routes[0]
+	methods := []string{string(METHOD_OPTIONS)}
+
+	for _, r := range routes {
+		if r.Method() == strings.ToUpper(req.GetHeader("access-control-request-method")) {
+			route = r
+		}
+
+		methods = append(methods, r.Method())
+	}
+
+	req.route = route
+
+	handler := func() *Response {
+		return req.Response.SetStatus(HTTP_RESPONSE_NO_CONTENT).SetHeader("Allow", strings.Join(methods, ", "))
+	}
+
+	if route.cors == nil {
+		return handler()
+	}
+
+	return route.cors(req, req.Response, handler)
 }
 
 // Comment
@@ -266,11 +358,23 @@ func (ctx *HTTP) websocketHandler(req *Request) {
 		return
 	}
 
-	route.Call(reflect.ValueOf(req), reflect.ValueOf(ws))
+	websocketConnections.Inc()
+	defer websocketConnections.Dec()
+
+	// Panics in the route or event callbacks are logged and close the connection instead of the server.
+	res := Recover(req, req.Response, func() *Response {
+		route.Call(reflect.ValueOf(req), reflect.ValueOf(ws))
+
+		ws.isReady()
 
-	ws.isReady()
+		ws.Listen()
+
+		return nil
+	})
 
-	ws.Listen()
+	if res != nil {
+		req.Conn.Close()
+	}
 }
 
 // Comment
@@ -282,7 +386,20 @@ func (ctx *HTTP) HandleRequest(req *Request) *Response {
 		return nil
 
 	default:
-		return ctx.requestHandler(req)
+		start := time.Now()
+
+		requestsInFlight.Inc()
+		defer requestsInFlight.Dec()
+
+		res := notModified(req, pipeline(ctx.middlewares, req, func() *Response {
+			return ctx.requestHandler(req)
+		}))
+
+		if res != nil {
+			observeRequest(req, res, start)
+		}
+
+		return res
 	}
 }
 
@@ -295,7 +412,7 @@ func (ctx *HTTP) Handler(conn *connection.Connection, req *Request) *Response {
 	}
 
 	for key, value := range res.Header {
-		req.Response.Writer.Header().Set(key, value[0])
+		req.Response.Writer.Header()[key] = value
 	}
 
 	return res
@@ -305,16 +422,35 @@ func (ctx *HTTP) Handler(conn *connection.Connection, req *Request) *Response {
 func Init(tcp HttpServer, udp HttpServer) *HTTP {
 	server := &HTTP{
 		MaxWebSocketPayloadSize: MAX_WEBSOCKET_PAYLOAD,
+		debug:                   env.EnvBool("APP_DEBUG"),
 		dependency: Dependencies{
 			"config": config.Init(),
 		},
+		viewHelpers: map[string]ViewHelperCallback{},
 	}
 
 	server.tcp = tcp
 	server.udp = udp
 
 	server.Set("router", InitRouter()).Get("router").(*RouterGroup).fallback = defaultRouteFallback
-	server.Session([]byte(str.Random(10)))
+	keyring, err := key.Load()
+
+	// A random key logs everyone out on restart, NewServer returns the error instead of starting with it.
+	if err != nil {
+		server.keyError = fmt.Errorf("%s: %w", key.APP_KEY, err)
+
+		if err == key.ErrMissingKey {
+			server.Logger().Warn("Missing " + key.APP_KEY + " using a random key")
+		} else {
+			server.Logger().Error("Invalid "+key.APP_KEY+" using a random key", slog.Any("error", err))
+		}
+
+		keyring = key.Random()
+	}
+
+	server.SetKeyring(keyring)
+	server.Session(nil)
+	server.Use(Recover)
 
 	server.tcp.OnRequest(server.onRequest) // HTTP/1.1 and HTTP/2.0 requests
 	server.udp.OnRequest(server.onRequest) // HTTP/3.0 requests
@@ -324,8 +460,8 @@ func Init(tcp HttpServer, udp HttpServer) *HTTP {
 
 // Comment
 func (ctx *HTTP) writeResponse(res *Response, w http.ResponseWriter) error {
-	for k := range res.Response.Header {
-		w.Header().Set(k, res.GetHeader(k))
+	for k, v := range res.Response.Header {
+		w.Header()[k] = v
 	}
 
 	if !res.Request.isStatic {
@@ -364,7 +500,7 @@ func (ctx *HTTP) onRequest(conn *connection.Connection, w http.ResponseWriter, r
 	}
 
 	if err := ctx.writeResponse(res, w); err != nil {
-		// TODO: log error
+		req.Logger().Error("Failed to write response", slog.Any("error", err))
 	}
 }
 
@@ -384,6 +520,27 @@ func Server(address string, port int) *HTTP {
 	return Init(tcp, udp)
 }
 
+// Comment
+func NewServerTLS(host string, port int, certFile string, keyFile string) (*HTTP, error) {
+	return strict(ServerTLS(host, port, certFile, keyFile))
+}
+
+// Comment
+func NewServer(address string, port int) (*HTTP, error) {
+	return strict(Server(address, port))
+}
+
+// Comment
+func strict(server *HTTP) (*HTTP, error) {
+	if server.keyError != nil {
+		server.Close()
+
+		return nil, server.keyError
+	}
+
+	return server, nil
+}
+
 // Comm
 func (ctx *HTTP) Host() string {
 	return ctx.tcp.Host()
@@ -1,10 +1,12 @@
 package http
 
 import (
+	"errors"
 	"math/rand"
 	"testing"
 
 	"github.com/lucas11776-golang/http/config"
+	"github.com/lucas11776-golang/http/encryption/key"
 )
 
 func TestServer(t *testing.T) {
@@ -38,3 +40,30 @@ func TestServer(t *testing.T) {
 
 	serve.Close()
 }
+
+func TestServerKey(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		expected error
+	}{
+		"valid":   {key.Generate(), nil},
+		"invalid": {"base64:c2hvcnQ=", key.ErrInvalidKey},
+		"missing": {"", key.ErrMissingKey},
+	}
+
+	for name, test := range tests {
+		t.Setenv(key.APP_KEY, test.value)
+
+		Server("127.0.0.1", 0).Close()
+
+		server, err := NewServer("127.0.0.1", 0)
+
+		if !errors.Is(err, test.expected) {
+			t.Fatalf("Expected (%s) key error to be (%v) but got (%v)", name, test.expected, err)
+		}
+
+		if server != nil {
+			server.Close()
+		}
+	}
+}
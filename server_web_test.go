@@ -272,7 +272,7 @@ func TestServerWeb(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			_, statusCode, _, body, err := response.ParseHttpToResponse(http)
+			_, statusCode, headers, body, err := response.ParseHttpToResponse(http)
 
 			if err != nil {
 				t.Fatal(err)
@@ -282,17 +282,21 @@ func TestServerWeb(t *testing.T) {
 				t.Fatalf("Expected response statuts code to be (%d) but got (%d)", HTTP_RESPONSE_UNPROCESSABLE_CONTENT, statusCode)
 			}
 
-			var jsonErrorResponse JsonErrorResponse
+			if contentType := headers["Content-Type"]; contentType != PROBLEM_JSON_CONTENT_TYPE {
+				t.Fatalf("Expected content type to be (%s) but got (%s)", PROBLEM_JSON_CONTENT_TYPE, contentType)
+			}
+
+			var problem Problem
 
-			if err := json.Unmarshal(body, &jsonErrorResponse); err != nil {
+			if err := json.Unmarshal(body, &problem); err != nil {
 				t.Fatal(err)
 			}
 
-			if jsonErrorResponse.Message != FormValidationErrorMessage {
-				t.Fatalf("Expected json error message to be (%s) but got (%s)", FormValidationErrorMessage, jsonErrorResponse.Message)
+			if problem.Detail != FormValidationErrorMessage {
+				t.Fatalf("Expected problem detail to be (%s) but got (%s)", FormValidationErrorMessage, problem.Detail)
 			}
 
-			if msg := jsonErrorResponse.Errors["email"]; msg != emailErrMsg {
+			if msg := problem.Errors["email"]; msg != emailErrMsg {
 				t.Fatalf("Expected email error to be (%s) but got (%s)", emailErrMsg, msg)
 			}
 		})
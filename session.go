@@ -3,13 +3,17 @@ package http
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/url"
+	"reflect"
+	"strconv"
 	"sync"
 	"time"
 
 	str "strings"
 
 	"github.com/gorilla/sessions"
+	"github.com/lucas11776-golang/http/encryption/key"
 	"github.com/lucas11776-golang/http/utils/strings"
 	"github.com/spf13/cast"
 )
@@ -27,9 +31,19 @@ const (
 	CSRF_INPUT_NAME     = "__CSRF__"
 )
 
+const (
+	SESSION_USER_KEY     = "__USER_SESSION__"
+	SESSION_CREATED_KEY  = "__CREATED_SESSION__"
+	SESSION_ACTIVITY_KEY = "__ACTIVITY_SESSION__"
+	SESSION_FLASH_KEY    = "__FLASH_SESSION__"
+)
+
+type SessionFlashBag map[string]string
+
 type SessionManager interface {
 	Set(key string, value interface{}) SessionManager
 	Get(key string) string
+	All() SessionBag
 	Clear() SessionManager
 	Path(path string) SessionManager
 	Remove(key string) SessionManager
@@ -41,6 +55,17 @@ type SessionManager interface {
 	CsrfToken() string
 	CsrfName() string
 	Old(key string) string
+	Id() string
+	Regenerate() SessionManager
+	Invalidate() SessionManager
+	Migrate(destroyOld bool) SessionManager
+	SetUser(user string) SessionManager
+	User() string
+	LogoutOtherDevices() error
+	Flash(key string, value interface{}) SessionManager
+	Flashed(key string) string
+	Reflash() SessionManager
+	Keep(keys ...string) SessionManager
 }
 
 type SessionsManager interface {
@@ -51,11 +76,21 @@ type SessionsManager interface {
 	HttpOnly(httpOnly bool) SessionsManager
 	SameSite(sameSite bool) SessionsManager
 	Path(path string) SessionsManager
+	IdleTimeout(timeout time.Duration) SessionsManager
+	AbsoluteTimeout(timeout time.Duration) SessionsManager
+	Gc() error
+	Active(user string) ([]string, error)
+	Destroy(user string, except ...string) error
 }
 
 type Sessions struct {
-	store *sessions.CookieStore
-	name  string
+	store    *sessions.CookieStore
+	backend  sessions.Store
+	server   *sessionBackend
+	name     string
+	idle     time.Duration
+	absolute time.Duration
+	keyring  bool
 }
 
 type Session struct {
@@ -65,25 +100,48 @@ type Session struct {
 	storeErrors SessionErrorsBag
 	errors      SessionErrorsBag
 	old         SessionOldBag
+	flash       SessionFlashBag
+	flashed     SessionFlashBag
+	changes     map[string]struct{}
 	valuesMutex sync.Mutex
 	store       *sessions.CookieStore
+	sessions    *Sessions
+}
+
+// Comment
+func InitSession(name string, key []byte, store ...SessionStore) *Sessions {
+	return initSessions(name, sessions.NewCookieStore(key), store...)
 }
 
 // Comment
-func InitSession(name string, key []byte) *Sessions {
-	s := sessions.NewCookieStore(key)
+func InitSessionKeyring(name string, keyring *key.Keyring, store ...SessionStore) *Sessions {
+	s := initSessions(name, sessions.NewCookieStore(keyring.Pairs()...), store...)
+
+	s.keyring = true
+
+	return s
+}
 
+// Comment
+func initSessions(name string, s *sessions.CookieStore, store ...SessionStore) *Sessions {
 	s.Options = &sessions.Options{
 		MaxAge: SESSION_DEFAULT_EXPIRE,
 	}
 
-	return &Sessions{name: name, store: s}
+	if len(store) == 0 || store[0] == nil {
+		return &Sessions{name: name, store: s, backend: s}
+	}
+
+	backend := newSessionBackend(s, store[0])
+
+	return &Sessions{name: name, store: s, backend: backend, server: backend}
 }
 
 // Comment
 func (ctx *Session) setValues(key string, value interface{}) *Session {
 	ctx.valuesMutex.Lock()
 	ctx.session.Values[key] = value
+	ctx.changes[key] = struct{}{}
 	ctx.valuesMutex.Unlock()
 
 	ctx.save = true
@@ -104,6 +162,7 @@ func (ctx *Session) getValues(key string) interface{} {
 func (ctx *Session) removeValues(key interface{}) *Session {
 	ctx.valuesMutex.Lock()
 	delete(ctx.session.Values, key)
+	ctx.changes[fmt.Sprint(key)] = struct{}{}
 	ctx.valuesMutex.Unlock()
 
 	ctx.save = true
@@ -136,6 +195,36 @@ func (ctx *Session) initCsrf() *Session {
 	return ctx
 }
 
+// Comment
+func (ctx *Session) expired(key string, timeout time.Duration, now time.Time) bool {
+	at := cast.ToInt64(ctx.getValues(key))
+
+	return timeout > 0 && at != 0 && now.After(time.Unix(at, 0).Add(timeout))
+}
+
+// Comment
+func (ctx *Session) initTimeouts() *Session {
+	if ctx.sessions.idle == 0 && ctx.sessions.absolute == 0 {
+		return ctx
+	}
+
+	now := time.Now()
+
+	if ctx.expired(SESSION_CREATED_KEY, ctx.sessions.absolute, now) || ctx.expired(SESSION_ACTIVITY_KEY, ctx.sessions.idle, now) {
+		ctx.Invalidate()
+	}
+
+	if ctx.getValues(SESSION_CREATED_KEY) == nil {
+		ctx.setValues(SESSION_CREATED_KEY, strconv.FormatInt(now.Unix(), 10))
+	}
+
+	if ctx.sessions.idle != 0 {
+		ctx.setValues(SESSION_ACTIVITY_KEY, strconv.FormatInt(now.Unix(), 10))
+	}
+
+	return ctx
+}
+
 // Comment
 func (ctx *Session) initErrors() *Session {
 	data := ctx.getValues(ERROR_KEY_STORE_KEY)
@@ -186,19 +275,42 @@ func (ctx *Session) initOld() *Session {
 	return ctx
 }
 
+// Comment
+func (ctx *Session) initFlash() *Session {
+	values := ctx.getValues(SESSION_FLASH_KEY)
+
+	if values == nil {
+		return ctx
+	}
+
+	flashed := SessionFlashBag{}
+
+	json.Unmarshal([]byte(cast.ToString(values)), &flashed)
+
+	ctx.flashed = flashed
+
+	ctx.removeValues(SESSION_FLASH_KEY)
+
+	return ctx
+}
+
 // Comment
 func (ctx *Sessions) Session(req *Request) SessionManager {
-	session, _ := ctx.store.Get(req.Request, ctx.name)
+	session, _ := ctx.backend.Get(req.Request, ctx.name)
 
 	s := &Session{
 		session:     session,
 		request:     req,
 		storeErrors: make(SessionErrorsBag),
 		errors:      make(SessionErrorsBag),
+		flash:       make(SessionFlashBag),
+		flashed:     make(SessionFlashBag),
+		changes:     make(map[string]struct{}),
 		store:       ctx.store,
+		sessions:    ctx,
 	}
 
-	return s.initCsrf().initErrors().initOld()
+	return s.initTimeouts().initCsrf().initErrors().initOld().initFlash()
 }
 
 // Comment
@@ -247,6 +359,47 @@ func (ctx *Sessions) Path(domain string) SessionsManager {
 	return ctx
 }
 
+// Comment
+func (ctx *Sessions) IdleTimeout(timeout time.Duration) SessionsManager {
+	ctx.idle = timeout
+
+	return ctx
+}
+
+// Comment
+func (ctx *Sessions) AbsoluteTimeout(timeout time.Duration) SessionsManager {
+	ctx.absolute = timeout
+
+	return ctx
+}
+
+// Comment
+func (ctx *Sessions) Gc() error {
+	if ctx.server == nil {
+		return nil
+	}
+
+	return ctx.server.store.Gc()
+}
+
+// Comment
+func (ctx *Sessions) Active(user string) ([]string, error) {
+	if ctx.server == nil {
+		return nil, ErrSessionStoreRequired
+	}
+
+	return ctx.server.active(user)
+}
+
+// Comment
+func (ctx *Sessions) Destroy(user string, except ...string) error {
+	if ctx.server == nil {
+		return ErrSessionStoreRequired
+	}
+
+	return ctx.server.destroy(user, except...)
+}
+
 // Comment
 func (ctx *Session) Path(path string) SessionManager {
 	ctx.session.Options.Path = path
@@ -256,9 +409,68 @@ func (ctx *Session) Path(path string) SessionManager {
 
 type SessionBag map[string]interface{}
 
+// Comment
+func sessionEncode(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return cast.ToString(v)
+	}
+
+	if reflect.ValueOf(value).Kind() == reflect.String {
+		return reflect.ValueOf(value).String()
+	}
+
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return cast.ToString(value)
+	}
+
+	return string(data)
+}
+
+// Comment
+func sessionDecode[T any](raw string) T {
+	var value T
+
+	if raw == "" {
+		return value
+	}
+
+	if v, ok := any(raw).(T); ok {
+		return v
+	}
+
+	if rv := reflect.ValueOf(&value).Elem(); rv.Kind() == reflect.String {
+		rv.SetString(raw)
+
+		return value
+	}
+
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		var zero T
+
+		return zero
+	}
+
+	return value
+}
+
+// Comment
+func SessionGet[T any](session SessionManager, key string) T {
+	return sessionDecode[T](session.Get(key))
+}
+
+// Comment
+func FlashGet[T any](session SessionManager, key string) T {
+	return sessionDecode[T](session.Flashed(key))
+}
+
 // Comment
 func (ctx *Session) Set(key string, value interface{}) SessionManager {
-	ctx.setValues(key, cast.ToString(value))
+	ctx.setValues(key, sessionEncode(value))
 
 	ctx.save = true
 
@@ -273,7 +485,21 @@ func (ctx *Session) Get(key string) string {
 		return ""
 	}
 
-	return value.(string)
+	return cast.ToString(value)
+}
+
+// Comment
+func (ctx *Session) All() SessionBag {
+	ctx.valuesMutex.Lock()
+	defer ctx.valuesMutex.Unlock()
+
+	values := make(SessionBag)
+
+	for k, v := range ctx.session.Values {
+		values[fmt.Sprint(k)] = v
+	}
+
+	return values
 }
 
 // Comment
@@ -296,6 +522,58 @@ func (ctx *Session) Remove(key string) SessionManager {
 	return ctx
 }
 
+// Comment
+func (ctx *Session) Id() string {
+	return ctx.session.ID
+}
+
+// Comment
+func (ctx *Session) Migrate(destroyOld bool) SessionManager {
+	if id := ctx.session.ID; id != "" && destroyOld && ctx.sessions.server != nil {
+		ctx.sessions.server.store.Destroy(id)
+	}
+
+	ctx.session.ID = ""
+	ctx.session.IsNew = true
+
+	ctx.newCsrf()
+
+	return ctx
+}
+
+// Comment
+func (ctx *Session) Regenerate() SessionManager {
+	return ctx.Migrate(true)
+}
+
+// Comment
+func (ctx *Session) Invalidate() SessionManager {
+	ctx.Clear()
+
+	return ctx.Migrate(true)
+}
+
+// Comment
+func (ctx *Session) SetUser(user string) SessionManager {
+	return ctx.setValues(SESSION_USER_KEY, user)
+}
+
+// Comment
+func (ctx *Session) User() string {
+	user, _ := ctx.getValues(SESSION_USER_KEY).(string)
+
+	return user
+}
+
+// Comment
+func (ctx *Session) LogoutOtherDevices() error {
+	if ctx.User() == "" {
+		return nil
+	}
+
+	return ctx.sessions.Destroy(ctx.User(), ctx.session.ID)
+}
+
 // Comment
 func (ctx *Session) CanSave() bool {
 	return ctx.save
@@ -331,10 +609,69 @@ func (ctx *Session) Save() SessionManager {
 		ctx.setValues(ERROR_KEY_STORE_KEY, string(errors))
 	}
 
+	if len(ctx.flash) != 0 {
+		flash, _ := json.Marshal(ctx.flash)
+
+		ctx.setValues(SESSION_FLASH_KEY, string(flash))
+	}
+
+	if ctx.sessions.server != nil {
+		if err := ctx.sessions.server.merge(ctx.request.Request, ctx.request.Response.Writer, ctx.session, ctx.changes); err != nil {
+			ctx.request.Logger().Error("Failed to save session", slog.Any("error", err))
+		}
+
+		return ctx
+	}
+
 	if err := ctx.session.Save(ctx.request.Request, ctx.request.Response.Writer); err != nil {
-		// TODO: log error
+		ctx.request.Logger().Error("Failed to save session", slog.Any("error", err))
+	}
+
+	return ctx
+}
+
+// Comment
+func (ctx *Session) Flash(key string, value interface{}) SessionManager {
+	ctx.flash[key] = sessionEncode(value)
+	ctx.flashed[key] = ctx.flash[key]
+
+	ctx.save = true
+
+	return ctx
+}
+
+// Comment
+func (ctx *Session) Flashed(key string) string {
+	value, ok := ctx.flashed[key]
+
+	if !ok {
+		return ""
+	}
+
+	return value
+}
+
+// Comment
+func (ctx *Session) Reflash() SessionManager {
+	for k, v := range ctx.flashed {
+		ctx.flash[k] = v
 	}
 
+	ctx.save = true
+
+	return ctx
+}
+
+// Comment
+func (ctx *Session) Keep(keys ...string) SessionManager {
+	for _, key := range keys {
+		if value, ok := ctx.flashed[key]; ok {
+			ctx.flash[key] = value
+		}
+	}
+
+	ctx.save = true
+
 	return ctx
 }
 
@@ -413,6 +750,13 @@ func SessionValue(req *Request) func(key string) string {
 	}
 }
 
+// Comment
+func SessionFlash(req *Request) func(key string) string {
+	return func(key string) string {
+		return req.Session.Flashed(key)
+	}
+}
+
 // Comment
 func SessionHas(req *Request) func(key string) bool {
 	return func(key string) bool {
@@ -437,7 +781,7 @@ func SessionErrors(req *Request) func() SessionErrorsBag {
 // Comment
 func SessionCsrfToken(req *Request) func() string {
 	return func() string {
-		return req.Session.CsrfToken()
+		return MaskCsrfToken(req.Session.CsrfToken())
 	}
 }
 
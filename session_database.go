@@ -0,0 +1,139 @@
+package http
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lucas11776-golang/http/utils/database"
+	"github.com/lucas11776-golang/orm"
+	"github.com/spf13/cast"
+)
+
+const SESSION_TABLE = "sessions"
+
+type SessionRecord struct {
+	Table     string `table:"sessions"`
+	ID        int64  `column:"id" type:"primary_key"`
+	SessionId string `column:"session_id" type:"string"`
+	Payload   string `column:"payload" type:"text"`
+	ExpiresAt int64  `column:"expires_at" type:"integer"`
+}
+
+type DatabaseSessionStore struct {
+	connection string
+}
+
+// Comment
+func NewDatabaseSessionStore(connection string) *DatabaseSessionStore {
+	return &DatabaseSessionStore{connection: connection}
+}
+
+// Comment
+func (ctx *DatabaseSessionStore) database() (orm.Database, error) {
+	db := orm.DB.Database(ctx.connection)
+
+	if db == nil {
+		return nil, fmt.Errorf("database connection %s does not exists", ctx.connection)
+	}
+
+	return db, nil
+}
+
+// Comment
+func (ctx *DatabaseSessionStore) Migrate() error {
+	db, err := ctx.database()
+
+	if err != nil {
+		return err
+	}
+
+	if err := db.Migration().Migrate(orm.Models{SessionRecord{}}); err != nil {
+		return err
+	}
+
+	conn, err := database.Connection(ctx.connection)
+
+	if err != nil {
+		return err
+	}
+
+	return database.Unique(conn, SESSION_TABLE, "session_id")
+}
+
+// Comment
+func (ctx *DatabaseSessionStore) where(id string) []interface{} {
+	return []interface{}{&orm.Where{Key: "session_id", Operator: orm.EQUALS, Value: id}}
+}
+
+// Comment
+func (ctx *DatabaseSessionStore) Read(id string) ([]byte, error) {
+	db, err := ctx.database()
+
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := db.Query(&orm.Statement{
+		Table: SESSION_TABLE,
+		Where: []interface{}{
+			ctx.where(id)[0],
+			orm.AND,
+			&orm.Where{Key: "expires_at", Operator: orm.GREATER_THEN, Value: time.Now().Unix()},
+		},
+		Limit: 1,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	return []byte(cast.ToString(results[0]["payload"])), nil
+}
+
+// Comment
+func (ctx *DatabaseSessionStore) Write(id string, data []byte, ttl time.Duration) error {
+	values := orm.Values{"session_id": id, "payload": string(data), "expires_at": time.Now().Add(ttl).Unix()}
+
+	// Concurrent first writes of a session insert one row instead of one row each.
+	inserted, err := database.Insert(ctx.connection, SESSION_TABLE, values, "session_id")
+
+	if err != nil || inserted {
+		return err
+	}
+
+	// A row destroyed between the insert and the update stays destroyed.
+	_, err = database.Update(ctx.connection, SESSION_TABLE, orm.Values{"payload": values["payload"], "expires_at": values["expires_at"]},
+		&orm.Where{Key: "session_id", Operator: orm.EQUALS, Value: id},
+	)
+
+	return err
+}
+
+// Comment
+func (ctx *DatabaseSessionStore) Destroy(id string) error {
+	db, err := ctx.database()
+
+	if err != nil {
+		return err
+	}
+
+	return db.Delete(&orm.Statement{Table: SESSION_TABLE, Where: ctx.where(id)})
+}
+
+// Comment
+func (ctx *DatabaseSessionStore) Gc() error {
+	db, err := ctx.database()
+
+	if err != nil {
+		return err
+	}
+
+	return db.Delete(&orm.Statement{
+		Table: SESSION_TABLE,
+		Where: []interface{}{&orm.Where{Key: "expires_at", Operator: orm.LESS_THEN_EQUALS, Value: time.Now().Unix()}},
+	})
+}
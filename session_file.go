@@ -0,0 +1,158 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const SESSION_FILE_PREFIX = "sess_"
+
+var (
+	ErrInvalidSessionId = errors.New("invalid session id")
+	sessionIdRegex      = regexp.MustCompile("^[A-Za-z0-9_-]+$")
+)
+
+type FileSessionStore struct {
+	mutex sync.RWMutex
+	path  string
+}
+
+// Comment
+func NewFileSessionStore(path string) *FileSessionStore {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		panic(err)
+	}
+
+	return &FileSessionStore{path: path}
+}
+
+// Comment
+func (ctx *FileSessionStore) filename(id string) (string, error) {
+	if !sessionIdRegex.MatchString(id) {
+		return "", ErrInvalidSessionId
+	}
+
+	return filepath.Join(ctx.path, SESSION_FILE_PREFIX+id), nil
+}
+
+// Comment
+func (ctx *FileSessionStore) read(filename string) ([]byte, time.Time, error) {
+	content, err := os.ReadFile(filename)
+
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	expires, data, ok := bytes.Cut(content, []byte("\n"))
+
+	if !ok {
+		return nil, time.Time{}, ErrInvalidSessionId
+	}
+
+	unix, err := strconv.ParseInt(string(expires), 10, 64)
+
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return data, time.Unix(unix, 0), nil
+}
+
+// Comment
+func (ctx *FileSessionStore) Read(id string) ([]byte, error) {
+	filename, err := ctx.filename(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.mutex.RLock()
+	defer ctx.mutex.RUnlock()
+
+	data, expires, err := ctx.read(filename)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(expires) {
+		return nil, nil
+	}
+
+	return data, nil
+}
+
+// Comment
+func (ctx *FileSessionStore) Write(id string, data []byte, ttl time.Duration) error {
+	filename, err := ctx.filename(id)
+
+	if err != nil {
+		return err
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	content := append([]byte(strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)+"\n"), data...)
+
+	return os.WriteFile(filename, content, 0600)
+}
+
+// Comment
+func (ctx *FileSessionStore) Destroy(id string) error {
+	filename, err := ctx.filename(id)
+
+	if err != nil {
+		return err
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if err := os.Remove(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// Comment
+func (ctx *FileSessionStore) Gc() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	entries, err := os.ReadDir(ctx.path)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), SESSION_FILE_PREFIX) {
+			continue
+		}
+
+		filename := filepath.Join(ctx.path, entry.Name())
+
+		if _, expires, err := ctx.read(filename); err == nil && now.Before(expires) {
+			continue
+		}
+
+		os.Remove(filename)
+	}
+
+	return nil
+}
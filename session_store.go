@@ -0,0 +1,415 @@
+package http
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/lucas11776-golang/http/encryption/crypt"
+)
+
+const (
+	SESSION_GC_PROBABILITY = 2
+	SESSION_GC_DIVISOR     = 100
+	SESSION_INDEX_PREFIX   = "user-"
+	SESSION_LOCKS          = 64
+)
+
+var (
+	ErrSessionStoreRequired = errors.New("session store is required to track user sessions")
+)
+
+type SessionStore interface {
+	Read(id string) ([]byte, error)
+	Write(id string, data []byte, ttl time.Duration) error
+	Destroy(id string) error
+	Gc() error
+}
+
+type sessionBackend struct {
+	cookie *sessions.CookieStore
+	store  SessionStore
+	mutex  sync.Mutex
+	locks  [SESSION_LOCKS]sync.Mutex
+}
+
+type memorySession struct {
+	data    []byte
+	expires time.Time
+}
+
+type MemorySessionStore struct {
+	mutex    sync.Mutex
+	sessions map[string]*memorySession
+}
+
+// Comment
+func newSessionBackend(cookie *sessions.CookieStore, store SessionStore) *sessionBackend {
+	return &sessionBackend{cookie: cookie, store: &metricsSessionStore{store: store}}
+}
+
+// Comment
+func newSessionId() string {
+	return strings.TrimRight(base32.StdEncoding.EncodeToString(crypt.RandomBytes(32)), "=")
+}
+
+// Comment
+func sessionTtl(maxAge int) time.Duration {
+	if maxAge <= 0 {
+		return time.Duration(SESSION_DEFAULT_EXPIRE) * time.Second
+	}
+
+	return time.Duration(maxAge) * time.Second
+}
+
+// Comment
+func (ctx *sessionBackend) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(ctx, name)
+}
+
+// Comment
+func (ctx *sessionBackend) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(ctx, name)
+	options := *ctx.cookie.Options
+
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+
+	if err != nil {
+		return session, nil
+	}
+
+	if err := securecookie.DecodeMulti(name, cookie.Value, &session.ID, ctx.cookie.Codecs...); err != nil {
+		return session, nil
+	}
+
+	data, err := ctx.store.Read(session.ID)
+
+	if err != nil || data == nil {
+		session.ID = ""
+
+		return session, nil
+	}
+
+	values := map[string]interface{}{}
+
+	if err := json.Unmarshal(data, &values); err != nil {
+		session.ID = ""
+
+		return session, nil
+	}
+
+	for k, v := range values {
+		session.Values[k] = v
+	}
+
+	session.IsNew = false
+
+	return session, nil
+}
+
+// Comment
+func (ctx *sessionBackend) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := ctx.store.Destroy(session.ID); err != nil {
+				return err
+			}
+		}
+
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = newSessionId()
+	}
+
+	values := map[string]interface{}{}
+
+	for k, v := range session.Values {
+		values[fmt.Sprint(k)] = v
+	}
+
+	data, err := json.Marshal(values)
+
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.store.Write(session.ID, data, sessionTtl(session.Options.MaxAge)); err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, ctx.cookie.Codecs...)
+
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+
+	if user, ok := session.Values[SESSION_USER_KEY].(string); ok && user != "" {
+		if err := ctx.attach(user, session.ID, sessionTtl(session.Options.MaxAge)); err != nil {
+			return err
+		}
+	}
+
+	if rand.IntN(SESSION_GC_DIVISOR) < SESSION_GC_PROBABILITY {
+		return ctx.store.Gc()
+	}
+
+	return nil
+}
+
+// Comment
+func (ctx *sessionBackend) lock(id string) *sync.Mutex {
+	// The lock only serializes saves in this process, stores shared by several processes are not locked.
+	hash := fnv.New32a()
+
+	hash.Write([]byte(id))
+
+	return &ctx.locks[hash.Sum32()%SESSION_LOCKS]
+}
+
+// Comment
+func (ctx *sessionBackend) merge(r *http.Request, w http.ResponseWriter, session *sessions.Session, changes map[string]struct{}) error {
+	if session.ID == "" || session.Options.MaxAge < 0 {
+		return ctx.Save(r, w, session)
+	}
+
+	lock := ctx.lock(session.ID)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	data, err := ctx.store.Read(session.ID)
+
+	// A loaded session that is gone was destroyed during the request, saving it would undo the logout.
+	if err == nil && data == nil && !session.IsNew {
+		return nil
+	}
+
+	if err != nil || data == nil {
+		return ctx.Save(r, w, session)
+	}
+
+	stored := map[string]interface{}{}
+
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return ctx.Save(r, w, session)
+	}
+
+	for k := range session.Values {
+		if _, changed := changes[fmt.Sprint(k)]; !changed {
+			delete(session.Values, k)
+		}
+	}
+
+	for k, v := range stored {
+		if _, changed := changes[k]; !changed {
+			session.Values[k] = v
+		}
+	}
+
+	return ctx.Save(r, w, session)
+}
+
+// Comment
+func (ctx *sessionBackend) indexKey(user string) string {
+	return SESSION_INDEX_PREFIX + hex.EncodeToString([]byte(user))
+}
+
+// Comment
+func (ctx *sessionBackend) index(user string) ([]string, error) {
+	data, err := ctx.store.Read(ctx.indexKey(user))
+
+	if err != nil || data == nil {
+		return []string{}, err
+	}
+
+	ids := []string{}
+
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return []string{}, nil
+	}
+
+	return ids, nil
+}
+
+// Comment
+func (ctx *sessionBackend) writeIndex(user string, ids []string, ttl time.Duration) error {
+	if len(ids) == 0 {
+		return ctx.store.Destroy(ctx.indexKey(user))
+	}
+
+	data, err := json.Marshal(ids)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.store.Write(ctx.indexKey(user), data, ttl)
+}
+
+// Comment
+func (ctx *sessionBackend) owner(id string) string {
+	data, err := ctx.store.Read(id)
+
+	if err != nil || data == nil {
+		return ""
+	}
+
+	values := map[string]interface{}{}
+
+	if err := json.Unmarshal(data, &values); err != nil {
+		return ""
+	}
+
+	user, _ := values[SESSION_USER_KEY].(string)
+
+	return user
+}
+
+// Comment
+func (ctx *sessionBackend) attach(user string, id string, ttl time.Duration) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ids, err := ctx.index(user)
+
+	if err != nil {
+		return err
+	}
+
+	for _, i := range ids {
+		if i == id {
+			return ctx.writeIndex(user, ids, ttl)
+		}
+	}
+
+	return ctx.writeIndex(user, append(ids, id), ttl)
+}
+
+// Comment
+func (ctx *sessionBackend) active(user string) ([]string, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ids, err := ctx.index(user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	active := []string{}
+
+	for _, id := range ids {
+		if ctx.owner(id) == user {
+			active = append(active, id)
+		}
+	}
+
+	if len(active) != len(ids) {
+		err = ctx.writeIndex(user, active, sessionTtl(ctx.cookie.Options.MaxAge))
+	}
+
+	return active, err
+}
+
+// Comment
+func (ctx *sessionBackend) destroy(user string, except ...string) error {
+	ids, err := ctx.active(user)
+
+	if err != nil {
+		return err
+	}
+
+	keep := []string{}
+
+	for _, id := range ids {
+		if slices.Contains(except, id) {
+			keep = append(keep, id)
+
+			continue
+		}
+
+		if err := ctx.store.Destroy(id); err != nil {
+			return err
+		}
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	return ctx.writeIndex(user, keep, sessionTtl(ctx.cookie.Options.MaxAge))
+}
+
+// Comment
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]*memorySession)}
+}
+
+// Comment
+func (ctx *MemorySessionStore) Read(id string) ([]byte, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	session, ok := ctx.sessions[id]
+
+	if !ok || time.Now().After(session.expires) {
+		return nil, nil
+	}
+
+	return session.data, nil
+}
+
+// Comment
+func (ctx *MemorySessionStore) Write(id string, data []byte, ttl time.Duration) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.sessions[id] = &memorySession{data: data, expires: time.Now().Add(ttl)}
+
+	return nil
+}
+
+// Comment
+func (ctx *MemorySessionStore) Destroy(id string) error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	delete(ctx.sessions, id)
+
+	return nil
+}
+
+// Comment
+func (ctx *MemorySessionStore) Gc() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	now := time.Now()
+
+	for id, session := range ctx.sessions {
+		if now.After(session.expires) {
+			delete(ctx.sessions, id)
+		}
+	}
+
+	return nil
+}
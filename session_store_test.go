@@ -0,0 +1,393 @@
+package http
+
+import (
+	"bytes"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lucas11776-golang/http/types"
+	str "github.com/lucas11776-golang/http/utils/strings"
+	"github.com/lucas11776-golang/orm"
+	"github.com/lucas11776-golang/orm/databases/sqlite"
+)
+
+func TestSessionStore(t *testing.T) {
+	orm.DB.Add("sessions", sqlite.Connect(":memory:"))
+
+	database := NewDatabaseSessionStore("sessions")
+
+	if err := database.Migrate(); err != nil {
+		t.Fatalf("Something went wrong when trying to migrate sessions table: %v", err)
+	}
+
+	stores := map[string]SessionStore{
+		"memory":   NewMemorySessionStore(),
+		"file":     NewFileSessionStore(t.TempDir()),
+		"database": database,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Run("TestReadWriteDestroy", func(t *testing.T) {
+				id := newSessionId()
+
+				if data, err := store.Read(id); err != nil || data != nil {
+					t.Fatalf("Expected missing session to be empty but got (%s, %v)", string(data), err)
+				}
+
+				store.Write(id, []byte(`{"user_id":"1"}`), time.Minute)
+				store.Write(id, []byte(`{"user_id":"2"}`), time.Minute)
+
+				if data, _ := store.Read(id); string(data) != `{"user_id":"2"}` {
+					t.Fatalf("Expected session payload to be (%s) but got (%s)", `{"user_id":"2"}`, string(data))
+				}
+
+				store.Destroy(id)
+
+				if data, _ := store.Read(id); data != nil {
+					t.Fatalf("Expected destroyed session to be empty but got (%s)", string(data))
+				}
+			})
+
+			t.Run("TestExpiry", func(t *testing.T) {
+				expired := newSessionId()
+				active := newSessionId()
+
+				store.Write(expired, []byte(`{"user_id":"1"}`), -time.Second)
+				store.Write(active, []byte(`{"user_id":"2"}`), time.Minute)
+
+				if data, _ := store.Read(expired); data != nil {
+					t.Fatalf("Expected expired session to be empty but got (%s)", string(data))
+				}
+
+				if err := store.Gc(); err != nil {
+					t.Fatalf("Something went wrong when trying to sweep sessions: %v", err)
+				}
+
+				if data, _ := store.Read(active); string(data) != `{"user_id":"2"}` {
+					t.Fatalf("Expected active session to survive sweep but got (%s)", string(data))
+				}
+			})
+
+			t.Run("TestSessionManager", func(t *testing.T) {
+				sessions := InitSession("session", []byte(str.Random(10)), store)
+
+				req, err := NewRequest("POST", "/", "HTTP/1.1", make(types.Headers), bytes.NewReader([]byte{}))
+
+				if err != nil {
+					t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+				}
+
+				sessions.Session(req).Set("user_id", "1").SetError("email", "The email is required").Save()
+
+				cookie, err := url.ParseQuery(strings.ReplaceAll(req.Response.GetHeader("Set-Cookie"), "; ", "&"))
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(cookie.Get("session")) > 200 {
+					t.Fatalf("Expected session cookie to only hold the session id but got (%s)", cookie.Get("session"))
+				}
+
+				headers := types.Headers{
+					"cookie": strings.Join([]string{"session", cookie.Get("session")}, "="),
+				}
+
+				req, err = NewRequest("GET", "/", "HTTP/1.1", headers, bytes.NewReader([]byte{}))
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				session := sessions.Session(req)
+
+				if session.Get("user_id") != "1" {
+					t.Fatalf("Expected user id to be (%s) but got (%s)", "1", session.Get("user_id"))
+				}
+
+				if session.Error("email") != "The email is required" {
+					t.Fatalf("Expected email error to be (%s) but got (%s)", "The email is required", session.Error("email"))
+				}
+			})
+		})
+	}
+
+	t.Run("TestDatabaseConcurrentWrites", func(t *testing.T) {
+		id := newSessionId()
+
+		var group sync.WaitGroup
+
+		for i := 0; i < 10; i++ {
+			group.Add(1)
+
+			go func(i int) {
+				defer group.Done()
+
+				if err := database.Write(id, []byte(`{"user_id":"`+strconv.Itoa(i)+`"}`), time.Minute); err != nil {
+					t.Errorf("Something went wrong when trying to write session: %v", err)
+				}
+			}(i)
+		}
+
+		group.Wait()
+
+		count, err := orm.DB.Database("sessions").Count(&orm.Statement{Table: SESSION_TABLE, Where: database.where(id)})
+
+		if err != nil || count != 1 {
+			t.Fatalf("Expected session rows to be (%d) but got (%d, %v)", 1, count, err)
+		}
+	})
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	request := func(t *testing.T, cookie string) *Request {
+		headers := make(types.Headers)
+
+		if cookie != "" {
+			headers["cookie"] = strings.Join([]string{"session", cookie}, "=")
+		}
+
+		req, err := NewRequest("GET", "/", "HTTP/1.1", headers, bytes.NewReader([]byte{}))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		return req
+	}
+
+	cookie := func(t *testing.T, req *Request) string {
+		cookie, err := url.ParseQuery(strings.ReplaceAll(req.Response.GetHeader("Set-Cookie"), "; ", "&"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return cookie.Get("session")
+	}
+
+	t.Run("TestRegenerate", func(t *testing.T) {
+		store := NewMemorySessionStore()
+		sessions := InitSession("session", []byte(str.Random(10)), store)
+
+		req := request(t, "")
+		sessions.Session(req).Set("cart", "keyboard").Save()
+
+		req = request(t, cookie(t, req))
+		session := sessions.Session(req)
+		id := session.Id()
+		csrf := session.CsrfToken()
+
+		session.Regenerate().Set("user_id", "1").Save()
+
+		if session.Id() == id || session.Id() == "" {
+			t.Fatalf("Expected session id to be regenerated but got (%s)", session.Id())
+		}
+
+		if session.CsrfToken() == csrf {
+			t.Fatalf("Expected csrf token to be regenerated")
+		}
+
+		if data, _ := store.Read(id); data != nil {
+			t.Fatalf("Expected old session to be destroyed but got (%s)", string(data))
+		}
+
+		session = sessions.Session(request(t, cookie(t, req)))
+
+		if session.Get("cart") != "keyboard" || session.Get("user_id") != "1" {
+			t.Fatalf("Expected regenerated session to keep values but got (%s, %s)", session.Get("cart"), session.Get("user_id"))
+		}
+	})
+
+	t.Run("TestMigrateKeepOld", func(t *testing.T) {
+		store := NewMemorySessionStore()
+		sessions := InitSession("session", []byte(str.Random(10)), store)
+
+		req := request(t, "")
+		sessions.Session(req).Set("cart", "keyboard").Save()
+
+		session := sessions.Session(request(t, cookie(t, req)))
+		id := session.Id()
+
+		session.Migrate(false)
+
+		if data, _ := store.Read(id); data == nil {
+			t.Fatalf("Expected old session to be kept")
+		}
+	})
+
+	t.Run("TestInvalidate", func(t *testing.T) {
+		sessions := InitSession("session", []byte(str.Random(10)), NewMemorySessionStore())
+
+		req := request(t, "")
+		sessions.Session(req).Set("user_id", "1").Save()
+
+		req = request(t, cookie(t, req))
+		sessions.Session(req).Invalidate().Save()
+
+		if session := sessions.Session(request(t, cookie(t, req))); session.Get("user_id") != "" {
+			t.Fatalf("Expected invalidated session to be empty but got (%s)", session.Get("user_id"))
+		}
+	})
+
+	t.Run("TestTimeouts", func(t *testing.T) {
+		store := NewMemorySessionStore()
+		sessions := InitSession("session", []byte(str.Random(10)), store)
+
+		req := request(t, "")
+		sessions.Session(req).Set("user_id", "1").Save()
+
+		value := cookie(t, req)
+
+		sessions.IdleTimeout(time.Minute)
+
+		if session := sessions.Session(request(t, value)); session.Get("user_id") != "1" {
+			t.Fatalf("Expected active session to have user id (%s) but got (%s)", "1", session.Get("user_id"))
+		}
+
+		sessions.IdleTimeout(0).AbsoluteTimeout(time.Minute)
+
+		session := sessions.Session(request(t, value))
+
+		session.Set(SESSION_CREATED_KEY, time.Now().Add(-time.Hour).Unix()).Save()
+
+		if session := sessions.Session(request(t, value)); session.Get("user_id") != "" {
+			t.Fatalf("Expected session past absolute timeout to be empty but got (%s)", session.Get("user_id"))
+		}
+
+		sessions.AbsoluteTimeout(0).IdleTimeout(time.Minute)
+
+		req = request(t, "")
+		session = sessions.Session(req)
+		session.Set("user_id", "1").Set(SESSION_ACTIVITY_KEY, time.Now().Add(-time.Hour).Unix()).Save()
+
+		if session := sessions.Session(request(t, cookie(t, req))); session.Get("user_id") != "" {
+			t.Fatalf("Expected idle session to be empty but got (%s)", session.Get("user_id"))
+		}
+	})
+
+	t.Run("TestLogoutOtherDevices", func(t *testing.T) {
+		sessions := InitSession("session", []byte(str.Random(10)), NewMemorySessionStore())
+		devices := []string{}
+
+		for i := 0; i < 3; i++ {
+			req := request(t, "")
+			sessions.Session(req).SetUser("1").Save()
+
+			devices = append(devices, cookie(t, req))
+		}
+
+		req := request(t, "")
+		sessions.Session(req).SetUser("2").Save()
+
+		other := cookie(t, req)
+
+		if active, _ := sessions.Active("1"); len(active) != 3 {
+			t.Fatalf("Expected user to have (%d) active sessions but got (%d)", 3, len(active))
+		}
+
+		if err := sessions.Session(request(t, devices[0])).LogoutOtherDevices(); err != nil {
+			t.Fatal(err)
+		}
+
+		if active, _ := sessions.Active("1"); len(active) != 1 {
+			t.Fatalf("Expected user to have (%d) active sessions but got (%d)", 1, len(active))
+		}
+
+		if session := sessions.Session(request(t, devices[0])); session.User() != "1" {
+			t.Fatalf("Expected current device to stay logged in but got user (%s)", session.User())
+		}
+
+		if session := sessions.Session(request(t, devices[1])); session.User() != "" {
+			t.Fatalf("Expected other device to be logged out but got user (%s)", session.User())
+		}
+
+		if session := sessions.Session(request(t, other)); session.User() != "2" {
+			t.Fatalf("Expected other user to stay logged in but got user (%s)", session.User())
+		}
+
+		if _, err := InitSession("session", []byte(str.Random(10))).Active("1"); err != ErrSessionStoreRequired {
+			t.Fatalf("Expected cookie sessions to return (%v) but got (%v)", ErrSessionStoreRequired, err)
+		}
+	})
+
+	t.Run("TestDestroyedDuringRequest", func(t *testing.T) {
+		store := NewMemorySessionStore()
+		sessions := InitSession("session", []byte(str.Random(10)), store)
+
+		req := request(t, "")
+		sessions.Session(req).SetUser("1").Save()
+
+		value := cookie(t, req)
+		session := sessions.Session(request(t, value))
+
+		if err := sessions.Destroy("1"); err != nil {
+			t.Fatal(err)
+		}
+
+		session.Set("cart", "keyboard").Save()
+
+		if data, _ := store.Read(session.Id()); data != nil {
+			t.Fatalf("Expected destroyed session to stay destroyed but got (%s)", string(data))
+		}
+
+		if active, _ := sessions.Active("1"); len(active) != 0 {
+			t.Fatalf("Expected user to have (%d) active sessions but got (%d)", 0, len(active))
+		}
+
+		if session := sessions.Session(request(t, value)); session.User() != "" {
+			t.Fatalf("Expected destroyed session to be logged out but got user (%s)", session.User())
+		}
+	})
+
+	t.Run("TestConcurrentUpdates", func(t *testing.T) {
+		sessions := InitSession("session", []byte(str.Random(10)), NewMemorySessionStore())
+
+		req := request(t, "")
+		sessions.Session(req).Set("user_id", "1").Save()
+
+		value := cookie(t, req)
+		loaded := make([]*Session, 20)
+
+		for i := range loaded {
+			loaded[i] = sessions.Session(request(t, value)).(*Session)
+		}
+
+		wg := sync.WaitGroup{}
+
+		for i, session := range loaded {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				session.Set(strings.Join([]string{"item", strconv.Itoa(i)}, "_"), strconv.Itoa(i)).Save()
+			}()
+		}
+
+		wg.Wait()
+
+		session := sessions.Session(request(t, value))
+
+		if session.Get("user_id") != "1" {
+			t.Fatalf("Expected user id to be (%s) but got (%s)", "1", session.Get("user_id"))
+		}
+
+		for i := range loaded {
+			if key := strings.Join([]string{"item", strconv.Itoa(i)}, "_"); session.Get(key) != strconv.Itoa(i) {
+				t.Fatalf("Expected session value (%s) to be (%d) but got (%s)", key, i, session.Get(key))
+			}
+		}
+
+		loaded[0].Remove("user_id").Save()
+
+		if session := sessions.Session(request(t, value)); session.Get("user_id") != "" || session.Get("item_1") != "1" {
+			t.Fatalf("Expected removed value to be empty and others kept but got (%s, %s)", session.Get("user_id"), session.Get("item_1"))
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/lucas11776-golang/http/types"
 	str "github.com/lucas11776-golang/http/utils/strings"
+	"github.com/open2b/scriggo"
 	"github.com/spf13/cast"
 )
 
@@ -468,8 +469,12 @@ func TestSession(t *testing.T) {
 		}
 
 		// Function - Csrf
-		if token := SessionCsrfToken(req)(); token != req.Session.CsrfToken() {
-			t.Fatalf("Expected csrf token to be (%s) but got (%s)", req.Session.CsrfToken(), token)
+		if token := SessionCsrfToken(req)(); token == req.Session.CsrfToken() || UnmaskCsrfToken(token) != req.Session.CsrfToken() {
+			t.Fatalf("Expected csrf token to be masked (%s) but got (%s)", req.Session.CsrfToken(), token)
+		}
+
+		if SessionCsrfToken(req)() == SessionCsrfToken(req)() {
+			t.Fatalf("Expected csrf token to be masked with a new pad on every call")
 		}
 
 		if name := SessionCsrfName(req)(); name == "" {
@@ -477,3 +482,157 @@ func TestSession(t *testing.T) {
 		}
 	})
 }
+
+func TestSessionValues(t *testing.T) {
+	request := func(t *testing.T, cookie string) *Request {
+		req, err := NewRequest("GET", "/", "HTTP/1.1", types.Headers{"cookie": cookie}, bytes.NewReader([]byte{}))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		return req
+	}
+
+	cookie := func(t *testing.T, req *Request) string {
+		cookie, err := url.ParseQuery(strings.ReplaceAll(req.Response.GetHeader("Set-Cookie"), "; ", "&"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return strings.Join([]string{"session", cookie.Get("session")}, "=")
+	}
+
+	t.Run("TestSessionGet", func(t *testing.T) {
+		type Role string
+
+		type Cart struct {
+			Products []string `json:"products"`
+			Total    float64  `json:"total"`
+		}
+
+		sessions := InitSession("session", []byte(str.Random(10)))
+
+		req := request(t, "")
+
+		sessions.Session(req).
+			Set("user_id", 1).
+			Set("admin", true).
+			Set("role", Role("editor")).
+			Set("cart", Cart{Products: []string{"keyboard", "mouse"}, Total: 99.5}).
+			Save()
+
+		session := sessions.Session(request(t, cookie(t, req)))
+
+		if id := SessionGet[int](session, "user_id"); id != 1 {
+			t.Fatalf("Expected user id to be (%d) but got (%d)", 1, id)
+		}
+
+		if admin := SessionGet[bool](session, "admin"); !admin {
+			t.Fatalf("Expected admin to be (%t) but got (%t)", true, admin)
+		}
+
+		if role := SessionGet[Role](session, "role"); role != "editor" {
+			t.Fatalf("Expected role to be (%s) but got (%s)", "editor", role)
+		}
+
+		cart := SessionGet[Cart](session, "cart")
+
+		if len(cart.Products) != 2 || cart.Total != 99.5 {
+			t.Fatalf("Expected cart to be decoded but got (%v)", cart)
+		}
+
+		if missing := SessionGet[int](session, "missing"); missing != 0 {
+			t.Fatalf("Expected missing value to be (%d) but got (%d)", 0, missing)
+		}
+	})
+
+	t.Run("TestFlash", func(t *testing.T) {
+		sessions := InitSession("session", []byte(str.Random(10)))
+
+		req := request(t, "")
+		session := sessions.Session(req)
+
+		session.Flash("status", "Profile updated").Flash("count", 3)
+
+		if session.Flashed("status") != "Profile updated" {
+			t.Fatalf("Expected flash to be available in current request but got (%s)", session.Flashed("status"))
+		}
+
+		session.Save()
+
+		// Second Request
+		req = request(t, cookie(t, req))
+		session = sessions.Session(req)
+
+		if session.Flashed("status") != "Profile updated" {
+			t.Fatalf("Expected flash status to be (%s) but got (%s)", "Profile updated", session.Flashed("status"))
+		}
+
+		if count := FlashGet[int](session, "count"); count != 3 {
+			t.Fatalf("Expected flash count to be (%d) but got (%d)", 3, count)
+		}
+
+		session.Keep("status").Save()
+
+		// Third Request
+		req = request(t, cookie(t, req))
+		session = sessions.Session(req)
+
+		if session.Flashed("status") != "Profile updated" {
+			t.Fatalf("Expected kept flash status to be (%s) but got (%s)", "Profile updated", session.Flashed("status"))
+		}
+
+		if session.Flashed("count") != "" {
+			t.Fatalf("Expected flash count to be removed but got (%s)", session.Flashed("count"))
+		}
+
+		session.Reflash().Save()
+
+		// Fourth Request
+		req = request(t, cookie(t, req))
+		session = sessions.Session(req)
+
+		if session.Flashed("status") != "Profile updated" {
+			t.Fatalf("Expected reflashed status to be (%s) but got (%s)", "Profile updated", session.Flashed("status"))
+		}
+
+		session.Save()
+
+		// Fifth Request
+		if session := sessions.Session(request(t, cookie(t, req))); session.Flashed("status") != "" {
+			t.Fatalf("Expected flash status to be removed but got (%s)", session.Flashed("status"))
+		}
+	})
+	t.Run("TestFlashWithViewHelper", func(t *testing.T) {
+		sessions := InitSession("session", []byte(str.Random(10)))
+
+		req := request(t, "")
+		req.Session = sessions.Session(req)
+		req.Response.Session = req.Session
+
+		req.Response.With("status", "Profile updated").Redirect("profile")
+
+		req.Session.Save()
+
+		req = request(t, cookie(t, req))
+		req.Session = sessions.Session(req)
+
+		view := NewView(&viewReaderTest{
+			Files: scriggo.Files{
+				"profile.html": []byte(`<p>{{ flash("status") }}</p>`),
+			},
+		}, "html")
+
+		data, err := view.Read("profile", ViewData{}, req)
+
+		if err != nil {
+			t.Fatalf("Failed to parse view: %s", err.Error())
+		}
+
+		if string(data) != "<p>Profile updated</p>" {
+			t.Fatalf("Expected view to be (%s) but got (%s)", "<p>Profile updated</p>", string(data))
+		}
+	})
+}
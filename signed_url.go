@@ -0,0 +1,106 @@
+package http
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucas11776-golang/http/encryption/crypt"
+	"github.com/lucas11776-golang/http/encryption/key"
+	"github.com/lucas11776-golang/http/utils/helper"
+)
+
+const (
+	SIGNATURE_QUERY_NAME = "signature"
+	EXPIRES_QUERY_NAME   = "expires"
+)
+
+const InvalidSignatureMessage = "Invalid signature."
+
+// Comment
+func signedUrlPayload(u *url.URL) string {
+	query := u.Query()
+
+	query.Del(SIGNATURE_QUERY_NAME)
+
+	return strings.Join([]string{strings.Trim(u.Path, "/"), query.Encode()}, "?")
+}
+
+// Comment
+func SignUrl(keyring *key.Keyring, to string, expires time.Time) string {
+	u, err := url.Parse(helper.GetUrl(to))
+
+	if err != nil {
+		return ""
+	}
+
+	query := u.Query()
+
+	if !expires.IsZero() {
+		query.Set(EXPIRES_QUERY_NAME, strconv.FormatInt(expires.Unix(), 10))
+	}
+
+	u.RawQuery = query.Encode()
+
+	query.Set(SIGNATURE_QUERY_NAME, crypt.Signature(keyring.SigningKey(), signedUrlPayload(u)))
+
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// Comment
+func ValidUrlSignature(keyring *key.Keyring, u *url.URL) bool {
+	if keyring == nil {
+		return false
+	}
+
+	query := u.Query()
+
+	if expires := query.Get(EXPIRES_QUERY_NAME); expires != "" {
+		unix, err := strconv.ParseInt(expires, 10, 64)
+
+		if err != nil || time.Now().Unix() > unix {
+			return false
+		}
+	}
+
+	signature := query.Get(SIGNATURE_QUERY_NAME)
+
+	if signature == "" {
+		return false
+	}
+
+	for _, k := range keyring.SigningKeys() {
+		if crypt.ValidSignature(k, signedUrlPayload(u), signature) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Comment
+func (ctx *HTTP) SignedUrl(to string) string {
+	return SignUrl(ctx.keyring, to, time.Time{})
+}
+
+// Comment
+func (ctx *HTTP) TemporarySignedUrl(to string, ttl time.Duration) string {
+	return SignUrl(ctx.keyring, to, time.Now().Add(ttl))
+}
+
+// Comment
+func (ctx *Request) HasValidSignature() bool {
+	return ValidUrlSignature(cookieKeyring(ctx), ctx.URL)
+}
+
+// Comment
+func ValidSignature(req *Request, res *Response, next Next) *Response {
+	if !req.HasValidSignature() {
+		return res.Error(Forbidden(InvalidSignatureMessage))
+	}
+
+	return next()
+}
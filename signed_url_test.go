@@ -0,0 +1,103 @@
+package http
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucas11776-golang/http/encryption/key"
+	"github.com/lucas11776-golang/http/types"
+)
+
+func TestSignedUrl(t *testing.T) {
+	server := Server("127.0.0.1", 0)
+
+	request := func(t *testing.T, to string) *Request {
+		u, err := url.Parse(to)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := NewRequest("GET", u.RequestURI(), "HTTP/1.1", types.Headers{}, strings.NewReader(""))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create request: %s", err.Error())
+		}
+
+		req.Server = server
+		req.Response.Request = req
+
+		return req
+	}
+
+	handle := func(req *Request) *Response {
+		return ValidSignature(req, req.Response, func() *Response {
+			return req.Response.Html("<h1>Unsubscribed</h1>")
+		})
+	}
+
+	t.Run("TestSignedUrl", func(t *testing.T) {
+		signed := server.SignedUrl("newsletter/unsubscribe?user=1")
+
+		if !strings.Contains(signed, "signature=") || strings.Contains(signed, "expires=") {
+			t.Fatalf("Expected url to be signed without expiry but got (%s)", signed)
+		}
+
+		if res := handle(request(t, signed)); res.StatusCode != int(HTTP_RESPONSE_OK) {
+			t.Fatalf("Expected status code to be (%d) but got (%d)", HTTP_RESPONSE_OK, res.StatusCode)
+		}
+
+		tampered := strings.Replace(signed, "user=1", "user=2", 1)
+
+		if res := handle(request(t, tampered)); res.StatusCode != int(HTTP_RESPONSE_FORBIDDEN) {
+			t.Fatalf("Expected tampered url status code to be (%d) but got (%d)", HTTP_RESPONSE_FORBIDDEN, res.StatusCode)
+		}
+
+		if request(t, "/newsletter/unsubscribe?user=1").HasValidSignature() {
+			t.Fatalf("Expected unsigned url to be invalid")
+		}
+	})
+
+	t.Run("TestTemporarySignedUrl", func(t *testing.T) {
+		if req := request(t, server.TemporarySignedUrl("email/verify/1", time.Minute)); !req.HasValidSignature() {
+			t.Fatalf("Expected temporary signed url to be valid")
+		}
+
+		if req := request(t, server.TemporarySignedUrl("email/verify/1", -time.Minute)); req.HasValidSignature() {
+			t.Fatalf("Expected expired signed url to be invalid")
+		}
+
+		signed := server.TemporarySignedUrl("email/verify/1", time.Minute)
+		u, _ := url.Parse(signed)
+		query := u.Query()
+
+		query.Set(EXPIRES_QUERY_NAME, "9999999999")
+		u.RawQuery = query.Encode()
+
+		if request(t, u.String()).HasValidSignature() {
+			t.Fatalf("Expected url with extended expiry to be invalid")
+		}
+	})
+
+	t.Run("TestKeyRotation", func(t *testing.T) {
+		old := key.Random()
+
+		server.SetKeyring(old)
+
+		signed := server.SignedUrl("invoices/1")
+
+		server.SetKeyring(key.NewKeyring(key.Random().Current(), old.Current()))
+
+		if !request(t, signed).HasValidSignature() {
+			t.Fatalf("Expected url signed with previous key to be valid")
+		}
+
+		server.SetKeyring(key.Random())
+
+		if request(t, signed).HasValidSignature() {
+			t.Fatalf("Expected url signed with removed key to be invalid")
+		}
+	})
+}
@@ -2,13 +2,14 @@ package testing
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"math/rand"
 	h "net/http"
-	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lucas11776-golang/http"
 	"github.com/lucas11776-golang/http/types"
@@ -33,6 +34,7 @@ type Request struct {
 	testing  *Testing
 	request  *http.Request
 	session  Values
+	cookies  Values
 	protocol string
 	path     string
 	method   http.Method
@@ -61,6 +63,7 @@ func NewRequest(testcase *TestCase) *Request {
 		method:   "GET",
 		headers:  make(types.Headers),
 		session:  make(Values),
+		cookies:  make(Values),
 	}
 
 	req.request, _ = req.make()
@@ -103,6 +106,11 @@ func (ctx *Request) SetHeaders(headers types.Headers) *Request {
 	return ctx
 }
 
+// Comment
+func (ctx *Request) WithBasicAuth(username string, password string) *Request {
+	return ctx.SetHeader("authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+}
+
 // Comment
 func (ctx *Request) setBody(body []byte) *Request {
 	ctx.body = body
@@ -129,7 +137,43 @@ func (ctx *Request) make() (*http.Request, error) {
 	req.Proto = ctx.protocol
 	req.Header = headers.ToHeader(ctx.headers)
 
-	return req, nil
+	return ctx.addCookieHeader(req), nil
+}
+
+// Comment
+func (ctx *Request) addCookieHeader(req *http.Request) *http.Request {
+	cookies := []string{}
+
+	for name, value := range ctx.cookies {
+		cookies = append(cookies, strings.Join([]string{name, value}, "="))
+	}
+
+	if len(cookies) == 0 {
+		return req
+	}
+
+	req.Header["Cookie"] = []string{strings.Join(cookies, "; ")}
+
+	return req
+}
+
+// Comment
+func (ctx *Request) storeCookies(res *http.Response) {
+	for _, line := range res.Header.Values("Set-Cookie") {
+		cookie, err := h.ParseSetCookie(line)
+
+		if err != nil {
+			continue
+		}
+
+		if cookie.MaxAge < 0 || (!cookie.Expires.IsZero() && cookie.Expires.Before(time.Now())) {
+			delete(ctx.cookies, cookie.Name)
+
+			continue
+		}
+
+		ctx.cookies[cookie.Name] = cookie.Value
+	}
 }
 
 // Comment
@@ -144,7 +188,7 @@ func (ctx *Request) addSessionHeader(req *http.Request) *http.Request {
 		ctx.testing.Fatalf("Something went wrong when trying to create request for session: %v", err)
 	}
 
-	rq := ctx.testCase.http.NewRequest(r, nil)
+	rq := ctx.addCookieHeader(ctx.testCase.http.NewRequest(r, nil))
 	session := ctx.testCase.http.Get("session").(http.SessionsManager).Session(rq)
 
 	for k, v := range ctx.session {
@@ -153,15 +197,9 @@ func (ctx *Request) addSessionHeader(req *http.Request) *http.Request {
 
 	session.Save()
 
-	cookie, err := url.ParseQuery(strings.ReplaceAll(rq.Response.GetHeader("set-cookie"), ";", "&"))
-
-	if err != nil {
-		ctx.testing.Fatalf("Something went wrong when trying to convert session to query: %v", err)
-	}
-
-	req.Header["Cookie"] = []string{strings.Join([]string{http.SESSION_NAME, cookie.Get(http.SESSION_NAME)}, "=")}
+	ctx.storeCookies(rq.Response)
 
-	return req
+	return ctx.addCookieHeader(req)
 }
 
 // Comment
@@ -176,6 +214,12 @@ func (ctx *Request) makeRequest(req *http.Request) *Response {
 
 	req.Response = res
 
+	if res.Session != nil {
+		res.Session.Save()
+	}
+
+	ctx.storeCookies(res)
+
 	return NewResponse(ctx, res)
 }
 
@@ -210,6 +254,18 @@ func (ctx *Request) Sessions(sessions Values) *Request {
 	return ctx
 }
 
+// Comment
+func (ctx *Request) Cookie(name string, value string) *Request {
+	ctx.cookies[name] = value
+
+	return ctx
+}
+
+// Comment
+func (ctx *Request) Cookies() Values {
+	return ctx.cookies
+}
+
 // Comment
 func (ctx *Request) Get(uri string) *Response {
 	return ctx.Call(http.METHOD_GET, uri, []byte{})
@@ -237,3 +237,37 @@ func TestFormUrlencoded(t *testing.T) {
 
 	req.testCase.Cleanup()
 }
+
+func TestCookieJar(t *testing.T) {
+	req := NewRequest(NewTestCase(t, http.Server("127.0.0.1", 0), false))
+
+	req.testCase.http.Route().Post("cart", func(req *http.Request, res *http.Response) *http.Response {
+		req.Session.Set("cart", req.FormValue("product"))
+
+		return res.SetCookie(http.NewCookie("theme", "dark")).Html("")
+	})
+
+	req.testCase.http.Route().Get("cart", func(req *http.Request, res *http.Response) *http.Response {
+		return res.Html(strings.Join([]string{req.Cookie("theme"), req.Session.Get("cart"), req.Cookie("locale")}, ","))
+	})
+
+	req.testCase.http.Route().Delete("theme", func(req *http.Request, res *http.Response) *http.Response {
+		return res.ForgetCookie("theme").Html("")
+	})
+
+	req.Cookie("locale", "en")
+
+	req.FormUrlencoded().Value("product", "keyboard").Send(http.METHOD_POST, "cart").AssertOk()
+
+	req.Get("cart").AssertBody([]byte("dark,keyboard,en"))
+
+	req.Delete("theme").AssertOk()
+
+	if _, ok := req.Cookies()["theme"]; ok {
+		t.Fatalf("Expected forgotten cookie to be removed from cookie jar")
+	}
+
+	req.Get("cart").AssertBody([]byte(",keyboard,en"))
+
+	req.testCase.Cleanup()
+}
@@ -227,7 +227,8 @@ func (ctx *Response) AssertSessionErrors(errs map[string]string) *Response {
 
 // Comment
 func (ctx *Response) parseResponseJsonErrorsBody() (*http.JsonErrorResponse, error) {
-	if contentType := ctx.Response.GetHeader("content-type"); contentType != "application/json" {
+	// Validation errors are problem+json, the errors of both bodies are read the same way.
+	if contentType := ctx.Response.GetHeader("content-type"); contentType != "application/json" && contentType != http.PROBLEM_JSON_CONTENT_TYPE {
 		return nil, fmt.Errorf("Response content type is not application/json but is %s", contentType)
 	}
 
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lucas11776-golang/orm"
+)
+
+var (
+	ErrConnection  = errors.New("database connection does not exist")
+	ErrUnsupported = errors.New("database connection does not support sql statements")
+	ErrCondition   = errors.New("database statement requires a condition")
+	ErrOperator    = errors.New("database operator is not supported")
+)
+
+// Comment
+func Connection(name string) (*sql.DB, error) {
+	db := orm.DB.Database(name)
+
+	if db == nil {
+		return nil, fmt.Errorf("%w: %s", ErrConnection, name)
+	}
+
+	conn, ok := db.Database().(*sql.DB)
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, name)
+	}
+
+	return conn, nil
+}
+
+// Statements the orm can not express use the identifier quoting of the orm, so they run on
+// every connection the orm supports.
+func quote(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "") + "`"
+}
+
+// Comment
+func columns(names ...string) string {
+	quoted := []string{}
+
+	for _, name := range names {
+		quoted = append(quoted, quote(name))
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// Comment
+func Unique(db *sql.DB, table string, names ...string) error {
+	name := table + "_" + strings.Join(names, "_") + "_unique"
+	list := columns(names...)
+
+	// Duplicates written before the index existed would make the index fail, the newest row is kept.
+	_, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id NOT IN (SELECT MAX(id) FROM %s GROUP BY %s)", quote(table), quote(table), list))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s)", quote(name), quote(table), list))
+
+	return err
+}
+
+// Comment
+func where(conditions []*orm.Where) (string, []interface{}, error) {
+	if len(conditions) == 0 {
+		return "", nil, ErrCondition
+	}
+
+	list := []string{}
+	values := []interface{}{}
+
+	for _, condition := range conditions {
+		switch condition.Operator {
+		case orm.EQUALS, orm.NOT_EQUALS, orm.LESS_THEN, orm.LESS_THEN_EQUALS, orm.GREATER_THEN, orm.GREATER_THEN_EQUALS:
+			list = append(list, strings.Join([]string{quote(condition.Key), condition.Operator, "?"}, " "))
+			values = append(values, condition.Value)
+
+		default:
+			return "", nil, fmt.Errorf("%w: %s", ErrOperator, condition.Operator)
+		}
+	}
+
+	return strings.Join(list, " AND "), values, nil
+}
+
+// Comment
+func exec(name string, query string, values []interface{}) (int64, error) {
+	db, err := Connection(name)
+
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := db.Exec(query, values...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Comment
+func Insert(name string, table string, values orm.Values, unique ...string) (bool, error) {
+	db := orm.DB.Database(name)
+
+	if db == nil {
+		return false, fmt.Errorf("%w: %s", ErrConnection, name)
+	}
+
+	_, err := db.Insert(&orm.Statement{Table: table, Values: values, PrimaryKey: "id"})
+
+	if err == nil || len(unique) == 0 {
+		return err == nil, err
+	}
+
+	// The unique index rejected the row when a row with the same unique columns exists.
+	conditions := []interface{}{}
+
+	for i, column := range unique {
+		if i != 0 {
+			conditions = append(conditions, orm.AND)
+		}
+
+		conditions = append(conditions, &orm.Where{Key: column, Operator: orm.EQUALS, Value: values[column]})
+	}
+
+	if count, e := db.Count(&orm.Statement{Table: table, Where: conditions}); e == nil && count != 0 {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// Comment
+func Update(name string, table string, values orm.Values, conditions ...*orm.Where) (int64, error) {
+	condition, args, err := where(conditions)
+
+	if err != nil {
+		return 0, err
+	}
+
+	keys := []string{}
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	set := []string{}
+	params := []interface{}{}
+
+	for _, key := range keys {
+		set = append(set, quote(key)+" = ?")
+		params = append(params, values[key])
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", quote(table), strings.Join(set, ", "), condition)
+
+	return exec(name, query, append(params, args...))
+}
+
+// Comment
+func Delete(name string, table string, conditions ...*orm.Where) (int64, error) {
+	condition, args, err := where(conditions)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return exec(name, fmt.Sprintf("DELETE FROM %s WHERE %s", quote(table), condition), args)
+}
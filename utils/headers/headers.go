@@ -2,12 +2,23 @@ package headers
 
 import (
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/lucas11776-golang/http/types"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
 
+type MediaRange struct {
+	Type    string
+	Subtype string
+	Quality float64
+}
+
+type MediaRanges []*MediaRange
+
 // Comment
 func ToHeader(headers types.Headers) http.Header {
 	header := make(http.Header)
@@ -18,3 +29,134 @@ func ToHeader(headers types.Headers) http.Header {
 
 	return header
 }
+
+// Comment
+func parseMediaRange(value string) *MediaRange {
+	params := strings.Split(value, ";")
+	mime := strings.Split(strings.ToLower(strings.TrimSpace(params[0])), "/")
+
+	if len(mime) != 2 || mime[0] == "" || mime[1] == "" {
+		return nil
+	}
+
+	media := &MediaRange{Type: mime[0], Subtype: mime[1], Quality: 1}
+
+	for _, param := range params[1:] {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+
+		if len(kv) != 2 || strings.ToLower(kv[0]) != "q" {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+
+		if err != nil || q < 0 || q > 1 {
+			continue
+		}
+
+		media.Quality = q
+	}
+
+	return media
+}
+
+// Comment
+func ParseAccept(accept string) MediaRanges {
+	ranges := MediaRanges{}
+
+	if strings.TrimSpace(accept) == "" {
+		return MediaRanges{{Type: "*", Subtype: "*", Quality: 1}}
+	}
+
+	for _, value := range strings.Split(accept, ",") {
+		if media := parseMediaRange(value); media != nil {
+			ranges = append(ranges, media)
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].Quality > ranges[j].Quality
+	})
+
+	return ranges
+}
+
+// Comment
+func (ctx *MediaRange) Specificity() int {
+	switch {
+	case ctx.Type == "*":
+		return 1
+	case ctx.Subtype == "*":
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Comment
+func (ctx *MediaRange) Match(mime string) bool {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(strings.Split(mime, ";")[0])), "/")
+
+	if len(parts) != 2 {
+		return false
+	}
+
+	if ctx.Type != "*" && ctx.Type != parts[0] {
+		return false
+	}
+
+	return ctx.Subtype == "*" || ctx.Subtype == parts[1]
+}
+
+// Comment
+func (ctx MediaRanges) match(mime string) *MediaRange {
+	var matched *MediaRange
+
+	for _, media := range ctx {
+		if !media.Match(mime) {
+			continue
+		}
+
+		if matched == nil || media.Specificity() > matched.Specificity() {
+			matched = media
+		}
+	}
+
+	return matched
+}
+
+// Comment
+func (ctx MediaRanges) Negotiate(offers ...string) string {
+	best, quality, specificity := "", 0.0, 0
+
+	for _, offer := range offers {
+		media := ctx.match(offer)
+
+		if media == nil || media.Quality == 0 {
+			continue
+		}
+
+		if media.Quality > quality || (media.Quality == quality && media.Specificity() > specificity) {
+			best, quality, specificity = offer, media.Quality, media.Specificity()
+		}
+	}
+
+	return best
+}
+
+// Comment
+func MatchETag(header string, etag string) bool {
+	if header == "" || etag == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -29,3 +29,64 @@ func TestHeader(t *testing.T) {
 		}
 	})
 }
+
+func TestAccept(t *testing.T) {
+	t.Run("TestParseAccept", func(t *testing.T) {
+		ranges := ParseAccept("text/html;q=0.8, application/json, */*;q=0.1")
+
+		if len(ranges) != 3 {
+			t.Fatalf("Expected media ranges to be (%d) but got (%d)", 3, len(ranges))
+		}
+
+		if ranges[0].Type != "application" || ranges[0].Subtype != "json" {
+			t.Fatalf("Expected first media range to be (%s) but got (%s/%s)", "application/json", ranges[0].Type, ranges[0].Subtype)
+		}
+
+		if ranges[1].Quality != 0.8 {
+			t.Fatalf("Expected media range quality to be (%f) but got (%f)", 0.8, ranges[1].Quality)
+		}
+	})
+
+	t.Run("TestNegotiate", func(t *testing.T) {
+		tests := []struct {
+			accept   string
+			offers   []string
+			expected string
+		}{
+			{"", []string{"text/html", "application/json"}, "text/html"},
+			{"application/json, text/plain, */*", []string{"text/html", "application/json"}, "application/json"},
+			{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", []string{"application/json", "text/html"}, "text/html"},
+			{"text/*;q=0.5, application/json;q=0.4", []string{"application/json", "text/csv"}, "text/csv"},
+			{"application/json;q=0", []string{"application/json"}, ""},
+			{"image/png", []string{"text/html"}, ""},
+		}
+
+		for _, test := range tests {
+			if actual := ParseAccept(test.accept).Negotiate(test.offers...); actual != test.expected {
+				t.Fatalf("Expected accept (%s) to negotiate (%s) but got (%s)", test.accept, test.expected, actual)
+			}
+		}
+	})
+}
+
+func TestETag(t *testing.T) {
+	tests := []struct {
+		header   string
+		etag     string
+		expected bool
+	}{
+		{`"abc"`, `"abc"`, true},
+		{`"xyz", "abc"`, `"abc"`, true},
+		{`W/"abc"`, `"abc"`, true},
+		{`"abc"`, `W/"abc"`, true},
+		{`*`, `"abc"`, true},
+		{`"xyz"`, `"abc"`, false},
+		{``, `"abc"`, false},
+	}
+
+	for _, test := range tests {
+		if actual := MatchETag(test.header, test.etag); actual != test.expected {
+			t.Fatalf("Expected if-none-match (%s) with etag (%s) to be (%t) but got (%t)", test.header, test.etag, test.expected, actual)
+		}
+	}
+}
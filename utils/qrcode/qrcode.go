@@ -0,0 +1,580 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+const (
+	QUIET_ZONE  = 4
+	MAX_VERSION = 20
+)
+
+var ErrDataTooLong = errors.New("data is too long to be encoded in a qr code")
+
+// Error correction codewords per block and the number of blocks with their data codewords
+// for error correction level M.
+type blocks struct {
+	ec     int
+	count1 int
+	data1  int
+	count2 int
+	data2  int
+}
+
+var versions = [MAX_VERSION + 1]blocks{
+	{},
+	{10, 1, 16, 0, 0},
+	{16, 1, 28, 0, 0},
+	{26, 1, 44, 0, 0},
+	{18, 2, 32, 0, 0},
+	{24, 2, 43, 0, 0},
+	{16, 4, 27, 0, 0},
+	{18, 4, 31, 0, 0},
+	{22, 2, 38, 2, 39},
+	{22, 3, 36, 2, 37},
+	{26, 4, 43, 1, 44},
+	{30, 1, 50, 4, 51},
+	{22, 6, 36, 2, 37},
+	{22, 8, 37, 1, 38},
+	{24, 4, 40, 5, 41},
+	{24, 5, 41, 5, 42},
+	{28, 7, 45, 3, 46},
+	{28, 10, 46, 1, 47},
+	{26, 9, 43, 4, 44},
+	{26, 3, 44, 11, 45},
+	{26, 3, 41, 13, 42},
+}
+
+type QrCode struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Comment
+func Encode(data string) (*QrCode, error) {
+	return encode([]byte(data), -1)
+}
+
+// Comment
+func encode(data []byte, mask int) (*QrCode, error) {
+	version := 1
+
+	for ; version <= MAX_VERSION; version++ {
+		if 4+countBits(version)+len(data)*8 <= capacity(version)*8 {
+			break
+		}
+	}
+
+	if version > MAX_VERSION {
+		return nil, ErrDataTooLong
+	}
+
+	qr := &QrCode{version: version, size: version*4 + 17}
+
+	qr.modules = make([][]bool, qr.size)
+	qr.function = make([][]bool, qr.size)
+
+	for i := range qr.modules {
+		qr.modules[i] = make([]bool, qr.size)
+		qr.function[i] = make([]bool, qr.size)
+	}
+
+	qr.drawFunctionPatterns()
+	qr.drawCodewords(qr.codewords(data))
+
+	if mask == -1 {
+		mask = qr.bestMask()
+	}
+
+	qr.applyMask(mask)
+	qr.drawFormat(mask)
+
+	return qr, nil
+}
+
+// Comment
+func capacity(version int) int {
+	b := versions[version]
+
+	return b.count1*b.data1 + b.count2*b.data2
+}
+
+// Comment
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+
+	return 16
+}
+
+// Comment
+func (ctx *QrCode) Version() int {
+	return ctx.version
+}
+
+// Comment
+func (ctx *QrCode) Size() int {
+	return ctx.size
+}
+
+// Comment
+func (ctx *QrCode) Module(x int, y int) bool {
+	if x < 0 || y < 0 || x >= ctx.size || y >= ctx.size {
+		return false
+	}
+
+	return ctx.modules[y][x]
+}
+
+// Comment
+func (ctx *QrCode) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+
+	width := (ctx.size + QUIET_ZONE*2) * scale
+	img := image.NewGray(image.Rect(0, 0, width, width))
+
+	for y := 0; y < width; y++ {
+		for x := 0; x < width; x++ {
+			if ctx.Module(x/scale-QUIET_ZONE, y/scale-QUIET_ZONE) {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			} else {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	return img
+}
+
+// Comment
+func (ctx *QrCode) PNG(scale int) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+
+	if err := png.Encode(buffer, ctx.Image(scale)); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Comment
+func PNG(data string, scale int) ([]byte, error) {
+	qr, err := Encode(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return qr.PNG(scale)
+}
+
+// Comment
+func (ctx *QrCode) set(x int, y int, dark bool) {
+	ctx.modules[y][x] = dark
+	ctx.function[y][x] = true
+}
+
+// Comment
+func (ctx *QrCode) drawFunctionPatterns() {
+	for i := 0; i < ctx.size; i++ {
+		ctx.set(6, i, i%2 == 0)
+		ctx.set(i, 6, i%2 == 0)
+	}
+
+	ctx.drawFinder(3, 3)
+	ctx.drawFinder(ctx.size-4, 3)
+	ctx.drawFinder(3, ctx.size-4)
+
+	positions := ctx.alignmentPositions()
+	last := len(positions) - 1
+
+	for i, x := range positions {
+		for j, y := range positions {
+			// Alignment patterns never overlap the finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+
+			ctx.drawAlignment(x, y)
+		}
+	}
+
+	ctx.drawFormat(0)
+	ctx.drawVersion()
+}
+
+// Comment
+func (ctx *QrCode) drawFinder(x int, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+
+			if xx < 0 || yy < 0 || xx >= ctx.size || yy >= ctx.size {
+				continue
+			}
+
+			distance := max(abs(dx), abs(dy))
+
+			ctx.set(xx, yy, distance != 2 && distance != 4)
+		}
+	}
+}
+
+// Comment
+func (ctx *QrCode) drawAlignment(x int, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			ctx.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// Comment
+func (ctx *QrCode) alignmentPositions() []int {
+	if ctx.version == 1 {
+		return []int{}
+	}
+
+	count := ctx.version/7 + 2
+	step := (ctx.version*4 + count*2 + 1) / (count*2 - 2) * 2
+	positions := make([]int, count)
+
+	positions[0] = 6
+
+	for i, position := count-1, ctx.size-7; i >= 1; i, position = i-1, position-step {
+		positions[i] = position
+	}
+
+	return positions
+}
+
+// Comment
+func (ctx *QrCode) drawFormat(mask int) {
+	// Error correction level M is encoded as 00.
+	data := mask
+	remainder := data
+
+	for i := 0; i < 10; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 9) * 0x537)
+	}
+
+	bits := (data<<10 | remainder) ^ 0x5412
+
+	bit := func(i int) bool {
+		return (bits>>i)&1 != 0
+	}
+
+	for i := 0; i <= 5; i++ {
+		ctx.set(8, i, bit(i))
+	}
+
+	ctx.set(8, 7, bit(6))
+	ctx.set(8, 8, bit(7))
+	ctx.set(7, 8, bit(8))
+
+	for i := 9; i < 15; i++ {
+		ctx.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		ctx.set(ctx.size-1-i, 8, bit(i))
+	}
+
+	for i := 8; i < 15; i++ {
+		ctx.set(8, ctx.size-15+i, bit(i))
+	}
+
+	ctx.set(8, ctx.size-8, true)
+}
+
+// Comment
+func (ctx *QrCode) drawVersion() {
+	if ctx.version < 7 {
+		return
+	}
+
+	remainder := ctx.version
+
+	for i := 0; i < 12; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 11) * 0x1F25)
+	}
+
+	bits := ctx.version<<12 | remainder
+
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 != 0
+		a, b := ctx.size-11+i%3, i/3
+
+		ctx.set(a, b, dark)
+		ctx.set(b, a, dark)
+	}
+}
+
+// Comment
+func (ctx *QrCode) codewords(data []byte) []byte {
+	total := capacity(ctx.version) * 8
+	bits := make([]bool, 0, total)
+
+	push := func(value int, length int) {
+		for i := length - 1; i >= 0; i-- {
+			bits = append(bits, (value>>i)&1 != 0)
+		}
+	}
+
+	// Byte mode indicator followed by the character count.
+	push(0b0100, 4)
+	push(len(data), countBits(ctx.version))
+
+	for _, b := range data {
+		push(int(b), 8)
+	}
+
+	push(0, min(4, total-len(bits)))
+	push(0, (8-len(bits)%8)%8)
+
+	for pad := 0xEC; len(bits) < total; pad ^= 0xEC ^ 0x11 {
+		push(pad, 8)
+	}
+
+	codewords := make([]byte, len(bits)/8)
+
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	return ctx.interleave(codewords)
+}
+
+// Comment
+func (ctx *QrCode) interleave(codewords []byte) []byte {
+	b := versions[ctx.version]
+	generator := rsGenerator(b.ec)
+	data := [][]byte{}
+	ec := [][]byte{}
+
+	for i, offset := 0, 0; i < b.count1+b.count2; i++ {
+		length := b.data1
+
+		if i >= b.count1 {
+			length = b.data2
+		}
+
+		block := codewords[offset : offset+length]
+		offset += length
+
+		data = append(data, block)
+		ec = append(ec, rsRemainder(block, generator))
+	}
+
+	result := make([]byte, 0, len(codewords)+len(ec)*b.ec)
+
+	for i := 0; i < max(b.data1, b.data2); i++ {
+		for _, block := range data {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+
+	for i := 0; i < b.ec; i++ {
+		for _, block := range ec {
+			result = append(result, block[i])
+		}
+	}
+
+	return result
+}
+
+// Comment
+func (ctx *QrCode) drawCodewords(codewords []byte) {
+	i := 0
+
+	for right := ctx.size - 1; right >= 1; right -= 2 {
+		// Skip the vertical timing pattern.
+		if right == 6 {
+			right = 5
+		}
+
+		upward := (right+1)&2 == 0
+
+		for vertical := 0; vertical < ctx.size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vertical
+
+				if upward {
+					y = ctx.size - 1 - vertical
+				}
+
+				if ctx.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+
+				ctx.modules[y][x] = (codewords[i/8]>>(7-i%8))&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+// Comment
+func masked(mask int, x int, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// Comment
+func (ctx *QrCode) applyMask(mask int) {
+	for y := 0; y < ctx.size; y++ {
+		for x := 0; x < ctx.size; x++ {
+			if !ctx.function[y][x] && masked(mask, x, y) {
+				ctx.modules[y][x] = !ctx.modules[y][x]
+			}
+		}
+	}
+}
+
+// Comment
+func (ctx *QrCode) bestMask() int {
+	best, lowest := 0, -1
+
+	for mask := 0; mask < 8; mask++ {
+		ctx.applyMask(mask)
+		ctx.drawFormat(mask)
+
+		if penalty := ctx.penalty(); lowest == -1 || penalty < lowest {
+			best, lowest = mask, penalty
+		}
+
+		// Masking is its own inverse.
+		ctx.applyMask(mask)
+	}
+
+	return best
+}
+
+// Comment
+func (ctx *QrCode) penalty() int {
+	penalty, dark := 0, 0
+	finder := []bool{true, false, true, true, true, false, true}
+
+	for i := 0; i < ctx.size; i++ {
+		row := make([]bool, ctx.size)
+		column := make([]bool, ctx.size)
+
+		for j := 0; j < ctx.size; j++ {
+			row[j], column[j] = ctx.modules[i][j], ctx.modules[j][i]
+
+			if row[j] {
+				dark++
+			}
+		}
+
+		for _, line := range [][]bool{row, column} {
+			penalty += runPenalty(line) + finderPenalty(line, finder)
+		}
+	}
+
+	for y := 0; y < ctx.size-1; y++ {
+		for x := 0; x < ctx.size-1; x++ {
+			color := ctx.modules[y][x]
+
+			if color == ctx.modules[y][x+1] && color == ctx.modules[y+1][x] && color == ctx.modules[y+1][x+1] {
+				penalty += 3
+			}
+		}
+	}
+
+	total := ctx.size * ctx.size
+
+	return penalty + (abs(dark*20-total*10)+total-1)/total*10 - 10
+}
+
+// Comment
+func runPenalty(line []bool) int {
+	penalty, run := 0, 1
+
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+
+			continue
+		}
+
+		if run >= 5 {
+			penalty += run - 2
+		}
+
+		run = 1
+	}
+
+	return penalty
+}
+
+// Comment
+func finderPenalty(line []bool, finder []bool) int {
+	penalty := 0
+
+	light := func(from int, to int) bool {
+		for i := from; i < to; i++ {
+			if i >= 0 && i < len(line) && line[i] {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	for i := 0; i+len(finder) <= len(line); i++ {
+		match := true
+
+		for j, dark := range finder {
+			if line[i+j] != dark {
+				match = false
+
+				break
+			}
+		}
+
+		if match && (light(i-4, i) || light(i+len(finder), i+len(finder)+4)) {
+			penalty += 40
+		}
+	}
+
+	return penalty
+}
+
+// Comment
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
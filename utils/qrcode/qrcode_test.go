@@ -0,0 +1,301 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Comment
+func decode(qr *QrCode) (string, int, error) {
+	format := 0
+
+	// The first copy of the format bits is read from around the top left finder pattern.
+	for i := 0; i < 15; i++ {
+		x, y := 8, i
+
+		switch {
+		case i == 6:
+			y = 7
+		case i == 7:
+			y = 8
+		case i == 8:
+			x, y = 7, 8
+		case i > 8:
+			x, y = 14-i, 8
+		}
+
+		if qr.Module(x, y) {
+			format |= 1 << i
+		}
+	}
+
+	format ^= 0x5412
+	remainder := format >> 10
+
+	for i := 0; i < 10; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 9) * 0x537)
+	}
+
+	if remainder != format&0x3FF || format>>13 != 0 {
+		return "", 0, errors.New("format is not error correction level M")
+	}
+
+	mask := format >> 10 & 7
+
+	// The function patterns of the version tell which modules hold codewords.
+	layout := &QrCode{version: qr.version, size: qr.size}
+
+	layout.modules = make([][]bool, layout.size)
+	layout.function = make([][]bool, layout.size)
+
+	for i := range layout.modules {
+		layout.modules[i] = make([]bool, layout.size)
+		layout.function[i] = make([]bool, layout.size)
+	}
+
+	layout.drawFunctionPatterns()
+
+	b := versions[qr.version]
+	codewords := make([]byte, capacity(qr.version)+(b.count1+b.count2)*b.ec)
+	i := 0
+
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+
+		for vertical := 0; vertical < qr.size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vertical
+
+				if (right+1)&2 == 0 {
+					y = qr.size - 1 - vertical
+				}
+
+				if layout.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+
+				if qr.Module(x, y) != masked(mask, x, y) {
+					codewords[i/8] |= 1 << (7 - i%8)
+				}
+
+				i++
+			}
+		}
+	}
+
+	blocks := make([][]byte, b.count1+b.count2)
+	offset := 0
+
+	for i := 0; i < max(b.data1, b.data2); i++ {
+		for j := range blocks {
+			if j < b.count1 && i >= b.data1 {
+				continue
+			}
+
+			blocks[j] = append(blocks[j], codewords[offset])
+			offset++
+		}
+	}
+
+	data := []byte{}
+
+	for j, block := range blocks {
+		ec := []byte{}
+
+		for i := 0; i < b.ec; i++ {
+			ec = append(ec, codewords[offset+i*len(blocks)+j])
+		}
+
+		if !bytes.Equal(rsRemainder(block, rsGenerator(b.ec)), ec) {
+			return "", 0, fmt.Errorf("error correction of block (%d) does not match", j)
+		}
+
+		data = append(data, block...)
+	}
+
+	bit := func(start int, length int) int {
+		value := 0
+
+		for i := start; i < start+length; i++ {
+			value = value<<1 | int(data[i/8]>>(7-i%8)&1)
+		}
+
+		return value
+	}
+
+	if bit(0, 4) != 0b0100 {
+		return "", 0, errors.New("data is not in byte mode")
+	}
+
+	count := countBits(qr.version)
+	length := bit(4, count)
+	text := []byte{}
+
+	for i := 0; i < length; i++ {
+		text = append(text, byte(bit(4+count+i*8, 8)))
+	}
+
+	return string(text), mask, nil
+}
+
+func TestQrCode(t *testing.T) {
+	t.Run("TestVersion", func(t *testing.T) {
+		tests := map[int]int{0: 1, 14: 1, 15: 2, 106: 6, 107: 7, 213: 10, 666: 20}
+
+		for length, version := range tests {
+			qr, err := Encode(strings.Repeat("a", length))
+
+			if err != nil {
+				t.Fatalf("Something went wrong when trying to encode (%d) bytes: %v", length, err)
+			}
+
+			if qr.Version() != version || qr.Size() != version*4+17 {
+				t.Fatalf("Expected (%d) bytes to be version (%d) but got (%d)", length, version, qr.Version())
+			}
+		}
+
+		if _, err := Encode(strings.Repeat("a", 667)); err != ErrDataTooLong {
+			t.Fatalf("Expected error to be (%v) but got (%v)", ErrDataTooLong, err)
+		}
+	})
+
+	t.Run("TestFunctionPatterns", func(t *testing.T) {
+		qr, _ := Encode("otpauth://totp/App:jeo@doe.com?secret=JBSWY3DPEHPK3PXP&issuer=App")
+
+		for _, corner := range [][2]int{{0, 0}, {qr.Size() - 7, 0}, {0, qr.Size() - 7}} {
+			for i := 0; i < 7; i++ {
+				if !qr.Module(corner[0]+i, corner[1]) || !qr.Module(corner[0], corner[1]+i) {
+					t.Fatalf("Expected finder pattern at (%d, %d)", corner[0], corner[1])
+				}
+			}
+		}
+
+		for i := 8; i < qr.Size()-8; i++ {
+			if qr.Module(i, 6) != (i%2 == 0) || qr.Module(6, i) != (i%2 == 0) {
+				t.Fatalf("Expected timing pattern module (%d) to be (%t)", i, i%2 == 0)
+			}
+		}
+
+		if !qr.Module(8, qr.Size()-8) {
+			t.Fatalf("Expected dark module to be set")
+		}
+	})
+
+	t.Run("TestReedSolomon", func(t *testing.T) {
+		// Version 1-M example from the QR code specification.
+		data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+		expected := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+
+		if ec := rsRemainder(data, rsGenerator(10)); !bytes.Equal(ec, expected) {
+			t.Fatalf("Expected error correction to be (%X) but got (%X)", expected, ec)
+		}
+	})
+
+	t.Run("TestReferenceMatrices", func(t *testing.T) {
+		// The matrices were generated by rsc.io/qr/coding at error correction level M with the mask in the file name.
+		files, _ := filepath.Glob("testdata/*.txt")
+
+		if len(files) == 0 {
+			t.Fatalf("Expected reference matrices in testdata")
+		}
+
+		for _, file := range files {
+			raw, err := os.ReadFile(file)
+
+			if err != nil {
+				t.Fatalf("Something went wrong when trying to read (%s): %v", file, err)
+			}
+
+			version, mask := 0, 0
+			lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+
+			fmt.Sscanf(filepath.Base(file), "version-%d-mask-%d.txt", &version, &mask)
+
+			qr, err := encode([]byte(lines[0]), mask)
+
+			if err != nil {
+				t.Fatalf("Something went wrong when trying to encode (%s): %v", file, err)
+			}
+
+			if qr.Version() != version || qr.Size() != len(lines)-1 {
+				t.Fatalf("Expected (%s) to be version (%d) but got (%d)", file, version, qr.Version())
+			}
+
+			for y, row := range lines[1:] {
+				for x, module := range row {
+					if qr.Module(x, y) != (module == '#') {
+						t.Fatalf("Expected module (%d, %d) of (%s) to be (%t)", x, y, file, module == '#')
+					}
+				}
+			}
+
+			if text, _, err := decode(qr); err != nil || text != lines[0] {
+				t.Fatalf("Expected (%s) to decode to (%s) but got (%s): %v", file, lines[0], text, err)
+			}
+		}
+	})
+
+	t.Run("TestRoundTrip", func(t *testing.T) {
+		for version := 1; version <= MAX_VERSION; version++ {
+			data := make([]byte, capacity(version)-2-countBits(version)/8)
+
+			for i := range data {
+				data[i] = byte(i*31 + version)
+			}
+
+			for mask := -1; mask < 8; mask++ {
+				qr, err := encode(data, mask)
+
+				if err != nil {
+					t.Fatalf("Something went wrong when trying to encode version (%d): %v", version, err)
+				}
+
+				text, used, err := decode(qr)
+
+				if err != nil || text != string(data) || qr.Version() != version {
+					t.Fatalf("Expected version (%d) mask (%d) to decode but got error (%v)", version, mask, err)
+				}
+
+				if mask != -1 && used != mask {
+					t.Fatalf("Expected mask to be (%d) but got (%d)", mask, used)
+				}
+			}
+		}
+	})
+
+	t.Run("TestPNG", func(t *testing.T) {
+		raw, err := PNG("otpauth://totp/App:jeo@doe.com?secret=JBSWY3DPEHPK3PXP", 4)
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to create png: %v", err)
+		}
+
+		img, err := png.Decode(bytes.NewReader(raw))
+
+		if err != nil {
+			t.Fatalf("Something went wrong when trying to decode png: %v", err)
+		}
+
+		qr, _ := Encode("otpauth://totp/App:jeo@doe.com?secret=JBSWY3DPEHPK3PXP")
+
+		if width := (qr.Size() + QUIET_ZONE*2) * 4; img.Bounds().Dx() != width {
+			t.Fatalf("Expected png width to be (%d) but got (%d)", width, img.Bounds().Dx())
+		}
+
+		if r, _, _, _ := img.At(0, 0).RGBA(); r != 0xFFFF {
+			t.Fatalf("Expected quiet zone to be white")
+		}
+
+		if r, _, _, _ := img.At(QUIET_ZONE*4, QUIET_ZONE*4).RGBA(); r != 0 {
+			t.Fatalf("Expected finder pattern to be black")
+		}
+	})
+}
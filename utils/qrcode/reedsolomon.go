@@ -0,0 +1,74 @@
+package qrcode
+
+var (
+	gfExp [512]byte
+	gfLog [256]byte
+)
+
+// Comment
+func init() {
+	x := 1
+
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+
+		// Galois field GF(2^8) with the primitive polynomial x^8 + x^4 + x^3 + x^2 + 1.
+		if x <<= 1; x >= 256 {
+			x ^= 0x11D
+		}
+	}
+
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+// Comment
+func gfMultiply(a byte, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// Comment
+func rsGenerator(degree int) []byte {
+	generator := []byte{1}
+
+	for i := 0; i < degree; i++ {
+		next := make([]byte, len(generator)+1)
+
+		for j, coefficient := range generator {
+			next[j] ^= coefficient
+			next[j+1] ^= gfMultiply(coefficient, gfExp[i])
+		}
+
+		generator = next
+	}
+
+	return generator
+}
+
+// Comment
+func rsRemainder(data []byte, generator []byte) []byte {
+	degree := len(generator) - 1
+	message := make([]byte, len(data)+degree)
+
+	copy(message, data)
+
+	for i := range data {
+		coefficient := message[i]
+
+		if coefficient == 0 {
+			continue
+		}
+
+		for j := 1; j <= degree; j++ {
+			message[i+j] ^= gfMultiply(generator[j], coefficient)
+		}
+	}
+
+	return message[len(data):]
+}
@@ -4,6 +4,8 @@ import (
 	"io/fs"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/lucas11776-golang/http/utils/helper"
 	"github.com/lucas11776-golang/http/utils/path"
@@ -27,6 +29,28 @@ type ViewInterface interface {
 	Read(view string, data ViewData) ([]byte, error)
 }
 
+type ViewHelperCallback func(req *Request) interface{}
+
+var (
+	viewHelpers      = map[string]ViewHelperCallback{}
+	viewHelpersMutex sync.RWMutex
+)
+
+// Comment
+func ViewHelper(name string, helper ViewHelperCallback) {
+	viewHelpersMutex.Lock()
+	defer viewHelpersMutex.Unlock()
+
+	viewHelpers[name] = helper
+}
+
+// Comment
+func (ctx *HTTP) ViewHelper(name string, helper ViewHelperCallback) *HTTP {
+	ctx.viewHelpers[name] = helper
+
+	return ctx
+}
+
 // Comment
 func (ctx *ViewWriter) Write(p []byte) (n int, err error) {
 	ctx.parsed = append(ctx.parsed, p...)
@@ -70,7 +94,7 @@ func (ctx *View) buildTemplate(view string, options *scriggo.BuildOptions) (*scr
 
 // Comment
 func viewDeclarationsWithHelpers(req *Request) native.Declarations {
-	return native.Declarations{
+	declarations := native.Declarations{
 		"url":             helper.Url,
 		"subdomain":       helper.Subdomain,
 		"format":          helper.Format,
@@ -80,7 +104,10 @@ func viewDeclarationsWithHelpers(req *Request) native.Declarations {
 		"errors":          SessionErrors(req),
 		"csrf_name":       SessionCsrfName(req),
 		"csrf_token":      SessionCsrfToken(req),
+		"csrf_field":      SessionCsrfField(req),
+		"csp_nonce":       RequestCspNonce(req),
 		"old":             SessionOld(req),
+		"flash":           SessionFlash(req),
 		"method_name":     func() string { return RequestFormMethodName },
 		"request":         func() *Request { return req },
 		"replace":         strings.ReplaceAll,
@@ -89,10 +116,29 @@ func viewDeclarationsWithHelpers(req *Request) native.Declarations {
 		"query_to_string": helper.QueryToString,
 		"current":         func() string { return helper.Url(req.Path()) }, // TODO: create url cast e.g url().Current(), url().To("login")...
 	}
+
+	viewHelpersMutex.RLock()
+
+	for name, helper := range viewHelpers {
+		declarations[name] = helper(req)
+	}
+
+	viewHelpersMutex.RUnlock()
+
+	// Helpers of the server are only available to its requests and replace global helpers.
+	if req != nil && req.Server != nil {
+		for name, helper := range req.Server.viewHelpers {
+			declarations[name] = helper(req)
+		}
+	}
+
+	return declarations
 }
 
 // Comment
 func (ctx *View) Read(view string, data ViewData, req *Request) ([]byte, error) {
+	defer viewRenderDuration.ObserveSince(time.Now(), view)
+
 	globals := viewDeclarationsWithHelpers(req)
 
 	for _, declarations := range ctx.declarations {
@@ -98,12 +98,23 @@ func (ctx *Ws) Emit(event Event, data []byte) {
 
 // Comment
 func (ctx *Ws) Write(data []byte) error {
-	return ctx.conn.Write(frame.Encode(frame.OPCODE_TEXT, data).Payload())
+	return ctx.write(frame.OPCODE_TEXT, data)
 }
 
 // Comment
 func (ctx *Ws) WriteBinary(data []byte) error {
-	return ctx.conn.Write(frame.Encode(frame.OPCODE_BINARY, data).Payload())
+	return ctx.write(frame.OPCODE_BINARY, data)
+}
+
+// Comment
+func (ctx *Ws) write(opcode frame.Opcode, data []byte) error {
+	if err := ctx.conn.Write(frame.Encode(opcode, data).Payload()); err != nil {
+		return err
+	}
+
+	websocketMessages.Inc("sent")
+
+	return nil
 }
 
 // Comment
@@ -129,6 +140,8 @@ func (ctx *Ws) emitter(opcode frame.Opcode, data []byte) {
 	case frame.OPCODE_CONTINUATION:
 
 	case frame.OPCODE_BINARY, frame.OPCODE_TEXT:
+		websocketMessages.Inc("received")
+
 		ctx.Emit(EVENT_MESSAGE, data)
 
 	case frame.OPCODE_CONNECTION_CLOSE: